@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/gammazero/workerpool"
+	multierror "github.com/hashicorp/go-multierror"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+
+	"github.com/golang-common-packages/hash"
+)
+
+// GridFSClient manage all GridFS file actions
+// File IDs are the hex of the ObjectID of GridFS files. GridFS has no folders, so the parents
+// given to Upload and Move are labels kept in the parents field of the file metadata.
+type GridFSClient struct {
+	client *MongoClient
+	config *GridFS
+}
+
+var (
+	// gridFSClientSessionMapping singleton pattern
+	gridFSClientSessionMapping = make(map[string]*GridFSClient)
+)
+
+// newGridFS init new instance
+func newGridFS(mongoConfig *MongoDB, config *GridFS) IFILE {
+	hasher := &hash.Client{}
+	configAsJSON, err := json.Marshal(struct {
+		MongoDB *MongoDB `json:"mongodb"`
+		GridFS  *GridFS  `json:"gridFS"`
+	}{mongoConfig, config})
+	if err != nil {
+		log.Fatalln("Unable to marshal GridFS configuration: ", err)
+	}
+	configAsString := hasher.SHA1(string(configAsJSON))
+
+	currentGridFSSession := gridFSClientSessionMapping[configAsString]
+	if currentGridFSSession == nil {
+		if config.DB == "" {
+			log.Fatalln("Unable to use GridFS: database cannot be empty")
+		}
+
+		currentGridFSSession = &GridFSClient{client: newMongoDB(mongoConfig).(*MongoClient), config: config}
+		gridFSClientSessionMapping[configAsString] = currentGridFSSession
+		log.Println("File GridFS is ready")
+	}
+
+	return currentGridFSSession
+}
+
+// List files by _id, pageToken is the NextPageToken of the previous page
+func (g *GridFSClient) List(pageSize int64, pageToken ...string) (interface{}, error) {
+	filter := bson.D{}
+	if len(pageToken) > 0 && pageToken[0] != "" {
+		filter = bson.D{{Key: "_id", Value: bson.D{{Key: "$gt", Value: gridFSFileID(pageToken[0])}}}}
+	}
+
+	files, err := g.client.ListFiles(ctx, g.config.DB, g.config.Bucket, filter, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GridFSFileList{Files: files}
+	if pageSize > 0 && int64(len(files)) == pageSize {
+		result.NextPageToken = gridFSFileToken(files[len(files)-1].ID)
+	}
+
+	return result, nil
+}
+
+// GetMetaData returns the *gridfs.File based on fileID, or gridfs.ErrFileNotFound
+func (g *GridFSClient) GetMetaData(fileID string) (interface{}, error) {
+	files, err := g.client.ListFiles(ctx, g.config.DB, g.config.Bucket, bson.D{{Key: "_id", Value: gridFSFileID(fileID)}}, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, gridfs.ErrFileNotFound
+	}
+
+	return &files[0], nil
+}
+
+// CreateFolder is not supported, GridFS has no folders
+func (g *GridFSClient) CreateFolder(name string, parents ...string) (interface{}, error) {
+	return nil, fmt.Errorf("GridFS does not support folders")
+}
+
+// Upload file content and return its *gridfs.File
+func (g *GridFSClient) Upload(name string, fileContent io.Reader, parents ...string) (interface{}, error) {
+	var metadata interface{}
+	if len(parents) > 0 {
+		metadata = bson.D{{Key: "parents", Value: parents}}
+	}
+
+	id, err := g.client.UploadFile(ctx, g.config.DB, g.config.Bucket, name, fileContent, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.GetMetaData(id.Hex())
+}
+
+// Download returns the *gridfs.DownloadStream of the file content, which the caller must close
+func (g *GridFSClient) Download(fileID string) (interface{}, error) {
+	return g.client.OpenFile(ctx, g.config.DB, g.config.Bucket, gridFSFileID(fileID))
+}
+
+// Move file from the oldParentID label to the newParentID one
+func (g *GridFSClient) Move(fileID, oldParentID, newParentID string) (interface{}, error) {
+	bucket, err := g.client.bucket(ctx, g.config.DB, g.config.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.D{{Key: "_id", Value: gridFSFileID(fileID)}}
+	update := bson.D{{Key: "$addToSet", Value: bson.D{{Key: "metadata.parents", Value: newParentID}}}}
+	if oldParentID != "" {
+		filter = append(filter, bson.E{Key: "metadata.parents", Value: oldParentID})
+		update = bson.D{{Key: "$set", Value: bson.D{{Key: "metadata.parents.$", Value: newParentID}}}}
+	}
+
+	result, err := bucket.GetFilesCollection().UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Println("Unable to move file: ", err)
+		return nil, err
+	}
+
+	if result.MatchedCount == 0 {
+		return nil, gridfs.ErrFileNotFound
+	}
+
+	return g.GetMetaData(fileID)
+}
+
+// Delete files based on IDs
+func (g *GridFSClient) Delete(fileIDs []string) error {
+	var mu sync.Mutex
+	var errs *multierror.Error
+	dwp := workerpool.New(g.config.PoolSize)
+
+	for _, fileID := range fileIDs {
+		fileID := fileID
+		dwp.Submit(func() {
+			if err := g.client.DeleteFile(ctx, g.config.DB, g.config.Bucket, gridFSFileID(fileID)); err != nil {
+				mu.Lock()
+				errs = multierror.Append(errs, err)
+				mu.Unlock()
+			}
+		})
+	}
+
+	dwp.StopWait()
+
+	return errs.ErrorOrNil()
+}
+
+// gridFSFileID returns the GridFS _id of a file ID, an ObjectID unless it is not a valid hex
+func gridFSFileID(fileID string) interface{} {
+	if id, err := primitive.ObjectIDFromHex(fileID); err == nil {
+		return id
+	}
+
+	return fileID
+}
+
+// gridFSFileToken returns the file ID of a GridFS _id
+func gridFSFileToken(id interface{}) string {
+	if objectID, ok := id.(primitive.ObjectID); ok {
+		return objectID.Hex()
+	}
+
+	return fmt.Sprint(id)
+}
@@ -18,6 +18,8 @@ const (
 	DRIVE = iota
 	// CUSTOMFILE file services
 	CUSTOMFILE
+	// GRIDFS MongoDB file services
+	GRIDFS
 )
 
 // newFile Factory Pattern
@@ -30,6 +32,8 @@ func newFile(
 		return newDrive(&config.GoogleDrive)
 	case CUSTOMFILE:
 		return newCustomFile(&config.CustomFile)
+	case GRIDFS:
+		return newGridFS(&config.MongoDB, &config.GridFS)
 	}
 
 	return nil
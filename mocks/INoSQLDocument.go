@@ -3,13 +3,105 @@
 package mocks
 
 import mock "github.com/stretchr/testify/mock"
+import context "context"
 import reflect "reflect"
+import storage "github.com/golang-common-packages/storage"
 
 // INoSQLDocument is an autogenerated mock type for the INoSQLDocument type
 type INoSQLDocument struct {
 	mock.Mock
 }
 
+// Aggregate provides a mock function with given fields: ctx, databaseName, collectionName, pipeline, dataModel, aggregateOptions
+func (_m *INoSQLDocument) Aggregate(ctx context.Context, databaseName string, collectionName string, pipeline interface{}, dataModel reflect.Type, aggregateOptions *storage.AggregateOptions) (interface{}, error) {
+	ret := _m.Called(ctx, databaseName, collectionName, pipeline, dataModel, aggregateOptions)
+
+	var r0 interface{}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, interface{}, reflect.Type, *storage.AggregateOptions) interface{}); ok {
+		r0 = rf(ctx, databaseName, collectionName, pipeline, dataModel, aggregateOptions)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interface{})
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, interface{}, reflect.Type, *storage.AggregateOptions) error); ok {
+		r1 = rf(ctx, databaseName, collectionName, pipeline, dataModel, aggregateOptions)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AggregateStream provides a mock function with given fields: ctx, databaseName, collectionName, pipeline, aggregateOptions
+func (_m *INoSQLDocument) AggregateStream(ctx context.Context, databaseName string, collectionName string, pipeline interface{}, aggregateOptions *storage.AggregateOptions) (storage.IDocumentCursor, error) {
+	ret := _m.Called(ctx, databaseName, collectionName, pipeline, aggregateOptions)
+
+	var r0 storage.IDocumentCursor
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, interface{}, *storage.AggregateOptions) storage.IDocumentCursor); ok {
+		r0 = rf(ctx, databaseName, collectionName, pipeline, aggregateOptions)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(storage.IDocumentCursor)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, interface{}, *storage.AggregateOptions) error); ok {
+		r1 = rf(ctx, databaseName, collectionName, pipeline, aggregateOptions)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BulkWrite provides a mock function with given fields: ctx, databaseName, collectionName, models, ordered
+func (_m *INoSQLDocument) BulkWrite(ctx context.Context, databaseName string, collectionName string, models []storage.WriteModel, ordered bool) (*storage.BulkWriteResult, error) {
+	ret := _m.Called(ctx, databaseName, collectionName, models, ordered)
+
+	var r0 *storage.BulkWriteResult
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []storage.WriteModel, bool) *storage.BulkWriteResult); ok {
+		r0 = rf(ctx, databaseName, collectionName, models, ordered)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*storage.BulkWriteResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, []storage.WriteModel, bool) error); ok {
+		r1 = rf(ctx, databaseName, collectionName, models, ordered)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Count provides a mock function with given fields: ctx, databaseName, collectionName, filter
+func (_m *INoSQLDocument) Count(ctx context.Context, databaseName string, collectionName string, filter interface{}) (int64, error) {
+	ret := _m.Called(ctx, databaseName, collectionName, filter)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, interface{}) int64); ok {
+		r0 = rf(ctx, databaseName, collectionName, filter)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, interface{}) error); ok {
+		r1 = rf(ctx, databaseName, collectionName, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Create provides a mock function with given fields: databaseName, collectionName, documents
 func (_m *INoSQLDocument) Create(databaseName string, collectionName string, documents []interface{}) (interface{}, error) {
 	ret := _m.Called(databaseName, collectionName, documents)
@@ -56,6 +148,200 @@ func (_m *INoSQLDocument) Delete(databaseName string, collectionName string, fil
 	return r0, r1
 }
 
+// DeleteOne provides a mock function with given fields: ctx, databaseName, collectionName, filter
+func (_m *INoSQLDocument) DeleteOne(ctx context.Context, databaseName string, collectionName string, filter interface{}) (interface{}, error) {
+	ret := _m.Called(ctx, databaseName, collectionName, filter)
+
+	var r0 interface{}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, interface{}) interface{}); ok {
+		r0 = rf(ctx, databaseName, collectionName, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interface{})
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, interface{}) error); ok {
+		r1 = rf(ctx, databaseName, collectionName, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Distinct provides a mock function with given fields: ctx, databaseName, collectionName, field, filter
+func (_m *INoSQLDocument) Distinct(ctx context.Context, databaseName string, collectionName string, field string, filter interface{}) ([]interface{}, error) {
+	ret := _m.Called(ctx, databaseName, collectionName, field, filter)
+
+	var r0 []interface{}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, interface{}) []interface{}); ok {
+		r0 = rf(ctx, databaseName, collectionName, field, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]interface{})
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, interface{}) error); ok {
+		r1 = rf(ctx, databaseName, collectionName, field, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DropIndex provides a mock function with given fields: ctx, databaseName, collectionName, indexName
+func (_m *INoSQLDocument) DropIndex(ctx context.Context, databaseName string, collectionName string, indexName string) error {
+	ret := _m.Called(ctx, databaseName, collectionName, indexName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, databaseName, collectionName, indexName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnsureIndexes provides a mock function with given fields: ctx, databaseName, collectionName, indexes
+func (_m *INoSQLDocument) EnsureIndexes(ctx context.Context, databaseName string, collectionName string, indexes []storage.IndexSpec) ([]string, error) {
+	ret := _m.Called(ctx, databaseName, collectionName, indexes)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []storage.IndexSpec) []string); ok {
+		r0 = rf(ctx, databaseName, collectionName, indexes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, []storage.IndexSpec) error); ok {
+		r1 = rf(ctx, databaseName, collectionName, indexes)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EstimatedCount provides a mock function with given fields: ctx, databaseName, collectionName
+func (_m *INoSQLDocument) EstimatedCount(ctx context.Context, databaseName string, collectionName string) (int64, error) {
+	ret := _m.Called(ctx, databaseName, collectionName)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) int64); ok {
+		r0 = rf(ctx, databaseName, collectionName)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, databaseName, collectionName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Exists provides a mock function with given fields: ctx, databaseName, collectionName, filter
+func (_m *INoSQLDocument) Exists(ctx context.Context, databaseName string, collectionName string, filter interface{}) (bool, error) {
+	ret := _m.Called(ctx, databaseName, collectionName, filter)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, interface{}) bool); ok {
+		r0 = rf(ctx, databaseName, collectionName, filter)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, interface{}) error); ok {
+		r1 = rf(ctx, databaseName, collectionName, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindOne provides a mock function with given fields: ctx, databaseName, collectionName, filter, dataModel
+func (_m *INoSQLDocument) FindOne(ctx context.Context, databaseName string, collectionName string, filter interface{}, dataModel reflect.Type) (interface{}, error) {
+	ret := _m.Called(ctx, databaseName, collectionName, filter, dataModel)
+
+	var r0 interface{}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, interface{}, reflect.Type) interface{}); ok {
+		r0 = rf(ctx, databaseName, collectionName, filter, dataModel)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interface{})
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, interface{}, reflect.Type) error); ok {
+		r1 = rf(ctx, databaseName, collectionName, filter, dataModel)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindOneAndUpdate provides a mock function with given fields: ctx, databaseName, collectionName, filter, update, returnDocument, dataModel
+func (_m *INoSQLDocument) FindOneAndUpdate(ctx context.Context, databaseName string, collectionName string, filter interface{}, update interface{}, returnDocument storage.ReturnDocument, dataModel reflect.Type) (interface{}, error) {
+	ret := _m.Called(ctx, databaseName, collectionName, filter, update, returnDocument, dataModel)
+
+	var r0 interface{}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, interface{}, interface{}, storage.ReturnDocument, reflect.Type) interface{}); ok {
+		r0 = rf(ctx, databaseName, collectionName, filter, update, returnDocument, dataModel)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interface{})
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, interface{}, interface{}, storage.ReturnDocument, reflect.Type) error); ok {
+		r1 = rf(ctx, databaseName, collectionName, filter, update, returnDocument, dataModel)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListIndexes provides a mock function with given fields: ctx, databaseName, collectionName
+func (_m *INoSQLDocument) ListIndexes(ctx context.Context, databaseName string, collectionName string) ([]storage.IndexSpec, error) {
+	ret := _m.Called(ctx, databaseName, collectionName)
+
+	var r0 []storage.IndexSpec
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []storage.IndexSpec); ok {
+		r0 = rf(ctx, databaseName, collectionName)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.IndexSpec)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, databaseName, collectionName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Read provides a mock function with given fields: databaseName, collectionName, filter, limit, dataModel
 func (_m *INoSQLDocument) Read(databaseName string, collectionName string, filter interface{}, limit int64, dataModel reflect.Type) (interface{}, error) {
 	ret := _m.Called(databaseName, collectionName, filter, limit, dataModel)
@@ -79,6 +365,89 @@ func (_m *INoSQLDocument) Read(databaseName string, collectionName string, filte
 	return r0, r1
 }
 
+// ReadPage provides a mock function with given fields: ctx, databaseName, collectionName, filter, findOptions, dataModel
+func (_m *INoSQLDocument) ReadPage(ctx context.Context, databaseName string, collectionName string, filter interface{}, findOptions *storage.FindOptions, dataModel reflect.Type) (*storage.Page, error) {
+	ret := _m.Called(ctx, databaseName, collectionName, filter, findOptions, dataModel)
+
+	var r0 *storage.Page
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, interface{}, *storage.FindOptions, reflect.Type) *storage.Page); ok {
+		r0 = rf(ctx, databaseName, collectionName, filter, findOptions, dataModel)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*storage.Page)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, interface{}, *storage.FindOptions, reflect.Type) error); ok {
+		r1 = rf(ctx, databaseName, collectionName, filter, findOptions, dataModel)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReadStream provides a mock function with given fields: ctx, databaseName, collectionName, filter, findOptions
+func (_m *INoSQLDocument) ReadStream(ctx context.Context, databaseName string, collectionName string, filter interface{}, findOptions *storage.FindOptions) (storage.IDocumentCursor, error) {
+	ret := _m.Called(ctx, databaseName, collectionName, filter, findOptions)
+
+	var r0 storage.IDocumentCursor
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, interface{}, *storage.FindOptions) storage.IDocumentCursor); ok {
+		r0 = rf(ctx, databaseName, collectionName, filter, findOptions)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(storage.IDocumentCursor)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, interface{}, *storage.FindOptions) error); ok {
+		r1 = rf(ctx, databaseName, collectionName, filter, findOptions)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReplaceOne provides a mock function with given fields: ctx, databaseName, collectionName, filter, replacement
+func (_m *INoSQLDocument) ReplaceOne(ctx context.Context, databaseName string, collectionName string, filter interface{}, replacement interface{}) (interface{}, error) {
+	ret := _m.Called(ctx, databaseName, collectionName, filter, replacement)
+
+	var r0 interface{}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, interface{}, interface{}) interface{}); ok {
+		r0 = rf(ctx, databaseName, collectionName, filter, replacement)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interface{})
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, interface{}, interface{}) error); ok {
+		r1 = rf(ctx, databaseName, collectionName, filter, replacement)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetValidator provides a mock function with given fields: ctx, databaseName, collectionName, schema
+func (_m *INoSQLDocument) SetValidator(ctx context.Context, databaseName string, collectionName string, schema interface{}) error {
+	ret := _m.Called(ctx, databaseName, collectionName, schema)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, interface{}) error); ok {
+		r0 = rf(ctx, databaseName, collectionName, schema)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: databaseName, collectionName, filter, update
 func (_m *INoSQLDocument) Update(databaseName string, collectionName string, filter interface{}, update interface{}) (interface{}, error) {
 	ret := _m.Called(databaseName, collectionName, filter, update)
@@ -101,3 +470,86 @@ func (_m *INoSQLDocument) Update(databaseName string, collectionName string, fil
 
 	return r0, r1
 }
+
+// UpdateOne provides a mock function with given fields: ctx, databaseName, collectionName, filter, update
+func (_m *INoSQLDocument) UpdateOne(ctx context.Context, databaseName string, collectionName string, filter interface{}, update interface{}) (interface{}, error) {
+	ret := _m.Called(ctx, databaseName, collectionName, filter, update)
+
+	var r0 interface{}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, interface{}, interface{}) interface{}); ok {
+		r0 = rf(ctx, databaseName, collectionName, filter, update)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interface{})
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, interface{}, interface{}) error); ok {
+		r1 = rf(ctx, databaseName, collectionName, filter, update)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Upsert provides a mock function with given fields: ctx, databaseName, collectionName, filter, update
+func (_m *INoSQLDocument) Upsert(ctx context.Context, databaseName string, collectionName string, filter interface{}, update interface{}) (interface{}, error) {
+	ret := _m.Called(ctx, databaseName, collectionName, filter, update)
+
+	var r0 interface{}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, interface{}, interface{}) interface{}); ok {
+		r0 = rf(ctx, databaseName, collectionName, filter, update)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interface{})
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, interface{}, interface{}) error); ok {
+		r1 = rf(ctx, databaseName, collectionName, filter, update)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Watch provides a mock function with given fields: ctx, databaseName, collectionName, pipeline, watchOptions
+func (_m *INoSQLDocument) Watch(ctx context.Context, databaseName string, collectionName string, pipeline interface{}, watchOptions *storage.WatchOptions) (<-chan storage.ChangeEvent, error) {
+	ret := _m.Called(ctx, databaseName, collectionName, pipeline, watchOptions)
+
+	var r0 <-chan storage.ChangeEvent
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, interface{}, *storage.WatchOptions) <-chan storage.ChangeEvent); ok {
+		r0 = rf(ctx, databaseName, collectionName, pipeline, watchOptions)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan storage.ChangeEvent)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, interface{}, *storage.WatchOptions) error); ok {
+		r1 = rf(ctx, databaseName, collectionName, pipeline, watchOptions)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WithTransaction provides a mock function with given fields: ctx, fn
+func (_m *INoSQLDocument) WithTransaction(ctx context.Context, fn func(tx storage.IDocumentTx) error) error {
+	ret := _m.Called(ctx, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(tx storage.IDocumentTx) error) error); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/allegro/bigcache/v2"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
 	"google.golang.org/api/drive/v3"
 )
 
@@ -11,13 +12,16 @@ import (
 
 // Config model for database config
 type Config struct {
-	LIKE           LIKE            `json:"like,omitempty"`
-	MongoDB        MongoDB         `json:"mongodb,omitempty"`
-	Redis          Redis           `json:"redis,omitempty"`
-	CustomKeyValue CustomKeyValue  `json:"customKeyValue,omitempty"`
-	BigCache       bigcache.Config `json:"bigCache,omitempty"`
-	GoogleDrive    GoogleDrive     `json:"googleDrive,omitempty"`
-	CustomFile     CustomFile      `json:"customFile,omitempty"`
+	LIKE             LIKE             `json:"like,omitempty"`
+	MongoDB          MongoDB          `json:"mongodb,omitempty"`
+	InMemoryDocument InMemoryDocument `json:"inMemoryDocument,omitempty"`
+	EmbeddedDocument EmbeddedDocument `json:"embeddedDocument,omitempty"`
+	Redis            Redis            `json:"redis,omitempty"`
+	CustomKeyValue   CustomKeyValue   `json:"customKeyValue,omitempty"`
+	BigCache         bigcache.Config  `json:"bigCache,omitempty"`
+	GoogleDrive      GoogleDrive      `json:"googleDrive,omitempty"`
+	CustomFile       CustomFile       `json:"customFile,omitempty"`
+	GridFS           GridFS           `json:"gridFS,omitempty"`
 }
 
 // LIKE model for SQL-LIKE connection config
@@ -30,20 +34,106 @@ type LIKE struct {
 }
 
 // MongoDB model for MongoDB connection config
+// The connection string is built from the fields, unless URI is set
 type MongoDB struct {
 	User     string   `json:"user"`
 	Password string   `json:"password"`
 	Hosts    []string `json:"hosts"`
 	DB       string   `json:"db"`
-	Options  []string `json:"options"`
+	Options  []string `json:"options"` // extra key=value connection string options
+
+	// URI is used as the connection string as is, every other field is then ignored
+	URI string `json:"uri,omitempty"`
+	// SRV resolves the deployment from the DNS seed list of the single host name in Hosts (mongodb+srv://)
+	SRV bool `json:"srv,omitempty"`
+
+	// AuthSource is the database holding the user, DB then admin by default
+	AuthSource string `json:"authSource,omitempty"`
+	ReplicaSet string `json:"replicaSet,omitempty"`
+	// ReadPreference is one of primary, primaryPreferred, secondary, secondaryPreferred and nearest
+	ReadPreference string `json:"readPreference,omitempty"`
+	// WriteConcern is "majority", a number of nodes or a tag set name
+	WriteConcern string `json:"writeConcern,omitempty"`
+
+	TLS MongoDBTLS `json:"tls,omitempty"`
+
+	MaxPoolSize            int           `json:"maxPoolSize,omitempty"`
+	MinPoolSize            int           `json:"minPoolSize,omitempty"`
+	ConnectTimeout         time.Duration `json:"connectTimeout,omitempty"`         // nanosecond
+	ServerSelectionTimeout time.Duration `json:"serverSelectionTimeout,omitempty"` // nanosecond
+}
+
+// MongoDBTLS model for MongoDB TLS config
+type MongoDBTLS struct {
+	Enable             bool   `json:"enable"`
+	CAFile             string `json:"caFile,omitempty"`
+	CertFile           string `json:"certFile,omitempty"`
+	KeyFile            string `json:"keyFile,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+}
+
+// InMemoryDocument model for in-process document store config
+// Clients created with the same config share their documents, use a distinct Name to isolate them
+type InMemoryDocument struct {
+	Name string `json:"name"`
+	// ChangeLogSize is the number of changes kept for resuming watches, 1000 when zero
+	ChangeLogSize int `json:"changeLogSize"`
+}
+
+// EmbeddedDocument model for on-disk document store config
+// Each database is a directory under Directory, holding one file per collection.
+// A directory must only be used by one process at a time
+type EmbeddedDocument struct {
+	Directory string `json:"directory"`
+	// NoSync skips flushing writes to disk, faster but a system crash can lose the last writes
+	NoSync bool `json:"noSync"`
+	// ChangeLogSize is the number of changes kept for resuming watches, 1000 when zero
+	ChangeLogSize int `json:"changeLogSize"`
 }
 
 // Redis model for redis config
+// A sentinel-backed client is used when MasterName is set, a cluster client
+// when ClusterAddrs is set, and a single-node client on Host otherwise
 type Redis struct {
 	Password   string `json:"password"`
 	Host       string `json:"host"`
 	DB         int    `json:"db"`
 	MaxRetries int    `json:"maxRetries"`
+
+	// Username enables ACL authentication (redis 6+) together with Password
+	Username string `json:"username,omitempty"`
+
+	// MasterName is the sentinel master name
+	MasterName string `json:"masterName,omitempty"`
+	// SentinelAddrs is the list of sentinel host:port addresses, Host is used when empty
+	SentinelAddrs []string `json:"sentinelAddrs,omitempty"`
+	// ClusterAddrs is the seed list of cluster node host:port addresses
+	ClusterAddrs []string `json:"clusterAddrs,omitempty"`
+
+	TLS RedisTLS `json:"tls,omitempty"`
+
+	// EnableKeyspaceEvents lets the event handlers add the notification classes they need
+	// to the server notify-keyspace-events setting, which applies to every client of the server.
+	// When disabled, notifications must be enabled on the server side.
+	EnableKeyspaceEvents bool `json:"enableKeyspaceEvents,omitempty"`
+
+	PoolSize     int           `json:"poolSize,omitempty"`
+	MinIdleConns int           `json:"minIdleConns,omitempty"`
+	DialTimeout  time.Duration `json:"dialTimeout,omitempty"`  // nanosecond
+	ReadTimeout  time.Duration `json:"readTimeout,omitempty"`  // nanosecond
+	WriteTimeout time.Duration `json:"writeTimeout,omitempty"` // nanosecond
+	PoolTimeout  time.Duration `json:"poolTimeout,omitempty"`  // nanosecond
+	IdleTimeout  time.Duration `json:"idleTimeout,omitempty"`  // nanosecond
+}
+
+// RedisTLS model for redis TLS config
+type RedisTLS struct {
+	Enable             bool   `json:"enable"`
+	ServerName         string `json:"serverName,omitempty"`
+	CAFile             string `json:"caFile,omitempty"`
+	CertFile           string `json:"certFile,omitempty"`
+	KeyFile            string `json:"keyFile,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
 }
 
 // CustomKeyValue config model
@@ -67,6 +157,14 @@ type CustomFile struct {
 	RootServiceDirectory string `json:"rootDirectory"`
 }
 
+// GridFS config model
+// Files are stored in the GridFS bucket of the database, on the MongoDB connection of the config
+type GridFS struct {
+	DB       string `json:"db"`
+	Bucket   string `json:"bucket,omitempty"` // "fs" when empty
+	PoolSize int    `json:"poolSize"`
+}
+
 // End Database Connection Models //
 
 // -------------------------------------------------------------------------
@@ -95,4 +193,11 @@ type GoogleFileModel struct {
 	drive.File
 }
 
+// GridFSFileList model for a page of GridFS files
+type GridFSFileList struct {
+	Files []gridfs.File `json:"files"`
+	// NextPageToken is given to List for the next page, empty on the last page
+	NextPageToken string `json:"nextPageToken,omitempty"`
+}
+
 // End Caching Models //
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	// ErrInvalidCursor is returned when a page cursor cannot be decoded or does not match the sort
+	ErrInvalidCursor = errors.New("invalid page cursor")
+)
+
+// pageCursor private model for the keyset pagination cursor
+// It holds the sort key values of the last document of a page.
+type pageCursor struct {
+	Values bson.A `bson:"v"`
+}
+
+// sortFields returns the sort keys with _id appended as a tie-breaker, so every document has a unique position
+func (o *FindOptions) sortFields() []SortField {
+	fields := make([]SortField, 0, len(o.Sort)+1)
+	for _, field := range o.Sort {
+		if field.Field == "_id" {
+			return append(fields, field)
+		}
+		fields = append(fields, field)
+	}
+
+	return append(fields, SortField{Field: "_id"})
+}
+
+// sortDocument returns the sort keys as a mongo sort document
+func (o *FindOptions) sortDocument() bson.D {
+	sort := bson.D{}
+	for _, field := range o.sortFields() {
+		direction := 1
+		if field.Descending {
+			direction = -1
+		}
+		sort = append(sort, bson.E{Key: field.Field, Value: direction})
+	}
+
+	return sort
+}
+
+// projection returns the projected fields as a mongo projection document, or nil to return every field
+// The sort keys are always projected because the next cursor is built from them.
+func (o *FindOptions) projection() bson.D {
+	if len(o.Projection) == 0 {
+		return nil
+	}
+
+	projection := bson.D{}
+	projected := make(map[string]bool)
+	for _, field := range o.Projection {
+		if !projected[field] {
+			projection = append(projection, bson.E{Key: field, Value: 1})
+			projected[field] = true
+		}
+	}
+
+	for _, field := range o.sortFields() {
+		if !projected[field.Field] {
+			projection = append(projection, bson.E{Key: field.Field, Value: 1})
+			projected[field.Field] = true
+		}
+	}
+
+	return projection
+}
+
+// query returns the filter restricted to the documents after the cursor
+// For sort keys k1..kn, the documents after the cursor values v1..vn match
+// k1 > v1, or k1 = v1 and k2 > v2, ..., with < for descending keys.
+func (o *FindOptions) query(filter interface{}) (interface{}, error) {
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	if o.Cursor == "" {
+		return filter, nil
+	}
+
+	values, err := decodeCursor(o.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := o.sortFields()
+	if len(values) != len(fields) {
+		return nil, ErrInvalidCursor
+	}
+
+	after := bson.A{}
+	for i, field := range fields {
+		next, ok := afterValue(field, values[i])
+		if !ok {
+			continue
+		}
+
+		condition := bson.D{}
+		for j := 0; j < i; j++ {
+			condition = append(condition, bson.E{Key: fields[j].Field, Value: values[j]})
+		}
+		after = append(after, append(condition, next))
+	}
+
+	return bson.D{{Key: "$and", Value: bson.A{filter, bson.D{{Key: "$or", Value: after}}}}}, nil
+}
+
+// afterValue returns the condition matching the sort key values after the cursor value
+// Comparison operators do not match null nor missing fields, which sort before every
+// other value: they come after a null cursor value in ascending order, and after any
+// other value in descending order. Nothing comes after null in descending order, which
+// is reported by returning false.
+func afterValue(field SortField, value interface{}) (bson.E, bool) {
+	switch {
+	case value == nil && field.Descending:
+		return bson.E{}, false
+
+	case value == nil:
+		return bson.E{Key: field.Field, Value: bson.D{{Key: "$ne", Value: nil}}}, true
+
+	case field.Descending:
+		return bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: field.Field, Value: bson.D{{Key: "$lt", Value: value}}}},
+			bson.D{{Key: field.Field, Value: nil}},
+		}}, true
+	}
+
+	return bson.E{Key: field.Field, Value: bson.D{{Key: "$gt", Value: value}}}, true
+}
+
+// encodeCursor returns the cursor positioned after the document
+func encodeCursor(document bson.Raw, fields []SortField) (string, error) {
+	cursor := pageCursor{Values: make(bson.A, 0, len(fields))}
+	for _, field := range fields {
+		value, err := document.LookupErr(strings.Split(field.Field, ".")...)
+		if err != nil {
+			// Missing keys sort as null
+			cursor.Values = append(cursor.Values, nil)
+			continue
+		}
+		cursor.Values = append(cursor.Values, value)
+	}
+
+	data, err := bson.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor returns the sort key values held by the cursor
+func decodeCursor(token string) (bson.A, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor pageCursor
+	if err := bson.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return cursor.Values, nil
+}
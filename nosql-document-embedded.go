@@ -0,0 +1,312 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/golang-common-packages/hash"
+)
+
+const (
+	// embeddedCollectionExtension is the extension of collection files
+	embeddedCollectionExtension = ".bson"
+	// embeddedTemporaryExtension is the extension of collection files being written
+	embeddedTemporaryExtension = ".tmp"
+)
+
+// EmbeddedDocumentClient manage all on-disk document actions
+// It runs the in-memory document store on the documents loaded from its directory,
+// and writes the modified collections to disk before each write returns.
+type EmbeddedDocumentClient struct {
+	*MemoryDocumentClient
+	config *EmbeddedDocument
+}
+
+// embeddedCollectionHeader private model for the first document of a collection file
+type embeddedCollectionHeader struct {
+	Indexes   []IndexSpec `bson:"indexes"`
+	Validator bson.D      `bson:"validator,omitempty"`
+}
+
+// embeddedDocumentSessionMapping singleton pattern
+var embeddedDocumentSessionMapping = make(map[string]*EmbeddedDocumentClient)
+
+// newEmbeddedDocument init new instance
+func newEmbeddedDocument(config *EmbeddedDocument) INoSQLDocument {
+	hasher := &hash.Client{}
+	configAsJSON, err := json.Marshal(config)
+	if err != nil {
+		log.Fatalln("Unable to marshal embedded document configuration: ", err)
+	}
+	configAsString := hasher.SHA1(string(configAsJSON))
+
+	currentEmbeddedSession := embeddedDocumentSessionMapping[configAsString]
+	if currentEmbeddedSession == nil {
+		currentEmbeddedSession = &EmbeddedDocumentClient{config: config}
+
+		state, err := currentEmbeddedSession.load()
+		if err != nil {
+			log.Fatalln("Unable to load embedded documents: ", err)
+		}
+
+		currentEmbeddedSession.MemoryDocumentClient = &MemoryDocumentClient{
+			config:  &InMemoryDocument{Name: config.Directory, ChangeLogSize: config.ChangeLogSize},
+			state:   state,
+			persist: currentEmbeddedSession.persist,
+		}
+		currentEmbeddedSession.changes.init(config.ChangeLogSize)
+		embeddedDocumentSessionMapping[configAsString] = currentEmbeddedSession
+		log.Println("Document embedded is ready")
+	}
+
+	return currentEmbeddedSession
+}
+
+// load reads the collections of every database directory
+func (e *EmbeddedDocumentClient) load() (memoryDocumentState, error) {
+	if e.config.Directory == "" {
+		return nil, fmt.Errorf("directory cannot be empty")
+	}
+
+	if err := os.MkdirAll(e.config.Directory, 0755); err != nil {
+		return nil, err
+	}
+
+	databases, err := ioutil.ReadDir(e.config.Directory)
+	if err != nil {
+		return nil, err
+	}
+
+	state := make(memoryDocumentState)
+	for _, database := range databases {
+		if !database.IsDir() {
+			continue
+		}
+
+		databaseName, err := url.PathUnescape(database.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		files, err := ioutil.ReadDir(filepath.Join(e.config.Directory, database.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			if file.IsDir() || !strings.HasSuffix(file.Name(), embeddedCollectionExtension) {
+				continue
+			}
+
+			collectionName, err := url.PathUnescape(strings.TrimSuffix(file.Name(), embeddedCollectionExtension))
+			if err != nil {
+				return nil, err
+			}
+
+			collection, err := readCollectionFile(filepath.Join(e.config.Directory, database.Name(), file.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("unable to read collection %s.%s: %w", databaseName, collectionName, err)
+			}
+			state[namespace(databaseName, collectionName)] = collection
+		}
+	}
+
+	return state, nil
+}
+
+// persist writes the modified collections of the state
+// Every file is written aside first then renamed over the previous one, so a crash leaves each
+// collection either before or after the write. Collections are renamed one by one, so a
+// transaction spanning several collections can be partially persisted by a crash.
+func (e *EmbeddedDocumentClient) persist(state memoryDocumentState, modified map[string]bool) error {
+	written := make(map[string]string, len(modified))
+	defer func() {
+		for temporary := range written {
+			os.Remove(temporary)
+		}
+	}()
+
+	directories := make(map[string]bool)
+	for key := range modified {
+		collection := state[key]
+		if collection == nil {
+			continue
+		}
+
+		path, err := e.collectionPath(key)
+		if err != nil {
+			return err
+		}
+
+		if _, err := os.Stat(filepath.Dir(path)); os.IsNotExist(err) {
+			// The new database directory is durable once the parent directory is synced
+			directories[e.config.Directory] = true
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+
+		temporary := path + embeddedTemporaryExtension
+		written[temporary] = path
+		if err := e.writeCollectionFile(temporary, collection); err != nil {
+			return err
+		}
+		directories[filepath.Dir(path)] = true
+	}
+
+	for temporary, path := range written {
+		if err := os.Rename(temporary, path); err != nil {
+			return err
+		}
+		delete(written, temporary)
+	}
+
+	if e.config.NoSync {
+		return nil
+	}
+
+	// The renames are durable once their directories are synced
+	for directory := range directories {
+		if err := syncPath(directory); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// collectionPath returns the file of the collection with this namespace
+func (e *EmbeddedDocumentClient) collectionPath(key string) (string, error) {
+	separator := strings.Index(key, ".")
+	if separator <= 0 || separator == len(key)-1 {
+		return "", fmt.Errorf("invalid namespace [%s]", key)
+	}
+
+	databaseName, collectionName := key[:separator], key[separator+1:]
+	if strings.ContainsAny(databaseName, "/\\. \"$") {
+		return "", fmt.Errorf("invalid database name [%s]", databaseName)
+	}
+
+	return filepath.Join(e.config.Directory, url.PathEscape(databaseName), url.PathEscape(collectionName)+embeddedCollectionExtension), nil
+}
+
+// writeCollectionFile writes the indexes then the documents of the collection, as consecutive BSON documents
+func (e *EmbeddedDocumentClient) writeCollectionFile(path string, collection *memoryCollection) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header, err := bson.Marshal(embeddedCollectionHeader{Indexes: collection.indexes, Validator: collection.validator})
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	if _, err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, document := range collection.documents {
+		data, err := bson.Marshal(document)
+		if err != nil {
+			return err
+		}
+
+		if _, err := writer.Write(data); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	if !e.config.NoSync {
+		if err := file.Sync(); err != nil {
+			return err
+		}
+	}
+
+	return file.Close()
+}
+
+// readCollectionFile reads a collection written by writeCollectionFile
+func readCollectionFile(path string) (*memoryCollection, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	collection := &memoryCollection{}
+	for offset := 0; offset < len(data); {
+		if len(data)-offset < 4 {
+			return nil, fmt.Errorf("truncated document at offset %d", offset)
+		}
+
+		length := int(binary.LittleEndian.Uint32(data[offset:]))
+		if length < 5 || offset+length > len(data) {
+			return nil, fmt.Errorf("truncated document at offset %d", offset)
+		}
+		raw := bson.Raw(data[offset : offset+length])
+
+		if offset == 0 {
+			var header embeddedCollectionHeader
+			if err := bson.Unmarshal(raw, &header); err != nil {
+				return nil, err
+			}
+
+			for _, index := range header.Indexes {
+				if index.PartialFilter != nil {
+					if index.PartialFilter, err = toDocument(index.PartialFilter); err != nil {
+						return nil, err
+					}
+				}
+				collection.indexes = append(collection.indexes, index)
+			}
+
+			if header.Validator != nil {
+				collection.validator = header.Validator
+				if collection.schema, err = compileSchemaDocument(header.Validator, "$jsonSchema"); err != nil {
+					return nil, err
+				}
+			}
+		} else {
+			var document bson.D
+			if err := bson.Unmarshal(raw, &document); err != nil {
+				return nil, err
+			}
+			collection.documents = append(collection.documents, document)
+		}
+
+		offset += length
+	}
+
+	if len(collection.indexes) == 0 {
+		return nil, fmt.Errorf("missing collection header")
+	}
+
+	return collection, nil
+}
+
+// syncPath flushes the file or directory to disk
+func syncPath(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return file.Sync()
+}
@@ -0,0 +1,594 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// documentLookup returns the documents of another collection of the same database, for $lookup
+type documentLookup func(collectionName string) ([]bson.D, error)
+
+// memoryGroup private model for a $group stage group
+type memoryGroup struct {
+	id     interface{}
+	fields bson.D
+	counts map[string]int64 // number of values averaged per $avg field
+}
+
+// toPipeline converts an aggregation pipeline, e.g. a Pipeline, mongo.Pipeline or bson.A, to its stages
+func toPipeline(pipeline interface{}) ([]bson.D, error) {
+	data, err := bson.Marshal(bson.D{{Key: "pipeline", Value: pipeline}})
+	if err != nil {
+		return nil, err
+	}
+
+	var wrapper struct {
+		Pipeline []bson.D `bson:"pipeline"`
+	}
+	if err := bson.Unmarshal(data, &wrapper); err != nil {
+		return nil, fmt.Errorf("pipeline must be an array of stages: %w", err)
+	}
+
+	return wrapper.Pipeline, nil
+}
+
+// aggregateDocuments runs the pipeline stages on the documents
+// Supported stages are $match, $sort, $skip, $limit, $project, $addFields, $set, $unset,
+// $count, $unwind, $group, $facet and the localField form of $lookup.
+func aggregateDocuments(documents []bson.D, stages []bson.D, lookup documentLookup) ([]bson.D, error) {
+	for _, stage := range stages {
+		if len(stage) != 1 {
+			return nil, fmt.Errorf("a pipeline stage must have exactly one field")
+		}
+
+		var err error
+		operator, argument := stage[0].Key, stage[0].Value
+		switch operator {
+		case "$match":
+			filter, ok := argument.(bson.D)
+			if !ok {
+				return nil, fmt.Errorf("$match needs a document")
+			}
+
+			matched := documents[:0:0]
+			for _, document := range documents {
+				match, err := matchFilter(document, filter)
+				if err != nil {
+					return nil, err
+				}
+				if match {
+					matched = append(matched, document)
+				}
+			}
+			documents = matched
+
+		case "$sort":
+			keys, ok := argument.(bson.D)
+			if !ok || len(keys) == 0 {
+				return nil, fmt.Errorf("$sort needs a nonempty document")
+			}
+
+			fields := make([]SortField, 0, len(keys))
+			for _, key := range keys {
+				fields = append(fields, SortField{Field: key.Key, Descending: toFloat64(key.Value) < 0})
+			}
+			documents = append(documents[:0:0], documents...)
+			sortDocuments(documents, fields)
+
+		case "$skip", "$limit":
+			if !isNumber(argument) || toInt64(argument) < 0 {
+				return nil, fmt.Errorf("%s needs a positive number", operator)
+			}
+
+			count := toInt64(argument)
+			if count > int64(len(documents)) {
+				count = int64(len(documents))
+			}
+			if operator == "$skip" {
+				documents = documents[count:]
+			} else {
+				documents = documents[:count]
+			}
+
+		case "$project":
+			documents, err = projectStage(documents, argument)
+
+		case "$addFields", "$set":
+			fields, ok := argument.(bson.D)
+			if !ok {
+				return nil, fmt.Errorf("%s needs a document", operator)
+			}
+
+			result := make([]bson.D, 0, len(documents))
+			for _, document := range documents {
+				var updated interface{} = copyDocument(document)
+				for _, field := range fields {
+					value, _, err := evalExpression(document, field.Value)
+					if err != nil {
+						return nil, err
+					}
+					if updated, err = setValue(updated, strings.Split(field.Key, "."), value); err != nil {
+						return nil, err
+					}
+				}
+				result = append(result, updated.(bson.D))
+			}
+			documents = result
+
+		case "$unset":
+			var fields []string
+			switch value := argument.(type) {
+			case string:
+				fields = []string{value}
+			case bson.A:
+				for _, field := range value {
+					name, ok := field.(string)
+					if !ok {
+						return nil, fmt.Errorf("$unset needs field names")
+					}
+					fields = append(fields, name)
+				}
+			default:
+				return nil, fmt.Errorf("$unset needs field names")
+			}
+
+			result := make([]bson.D, 0, len(documents))
+			for _, document := range documents {
+				var updated interface{} = copyDocument(document)
+				for _, field := range fields {
+					updated = unsetValue(updated, strings.Split(field, "."))
+				}
+				result = append(result, updated.(bson.D))
+			}
+			documents = result
+
+		case "$count":
+			field, ok := argument.(string)
+			if !ok || field == "" {
+				return nil, fmt.Errorf("$count needs a field name")
+			}
+
+			if len(documents) == 0 {
+				return []bson.D{}, nil
+			}
+			documents = []bson.D{{{Key: field, Value: int32(len(documents))}}}
+
+		case "$unwind":
+			documents, err = unwindStage(documents, argument)
+
+		case "$group":
+			documents, err = groupStage(documents, argument)
+
+		case "$facet":
+			facets, ok := argument.(bson.D)
+			if !ok {
+				return nil, fmt.Errorf("$facet needs a document")
+			}
+
+			result := bson.D{}
+			for _, facet := range facets {
+				facetStages, err := toPipeline(facet.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				facetDocuments, err := aggregateDocuments(documents, facetStages, lookup)
+				if err != nil {
+					return nil, err
+				}
+
+				array := make(bson.A, 0, len(facetDocuments))
+				for _, document := range facetDocuments {
+					array = append(array, document)
+				}
+				result = append(result, bson.E{Key: facet.Key, Value: array})
+			}
+			documents = []bson.D{result}
+
+		case "$lookup":
+			documents, err = lookupStage(documents, argument, lookup)
+
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedOperator, operator)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return documents, nil
+}
+
+// evalExpression evaluates an aggregation expression against the document
+// Supported expressions are field paths ("$field"), $$ROOT, literals and documents or arrays of them.
+// It also reports whether the value is defined, as a missing field path is not.
+func evalExpression(document bson.D, expression interface{}) (interface{}, bool, error) {
+	switch value := expression.(type) {
+	case string:
+		if value == "$$ROOT" {
+			return copyDocument(document), true, nil
+		}
+
+		if !strings.HasPrefix(value, "$") {
+			return value, true, nil
+		}
+
+		values, found := lookupValues(document, strings.Split(value[1:], "."))
+		switch {
+		case !found:
+			return nil, false, nil
+		case len(values) == 1:
+			return copyValue(values[0]), true, nil
+		}
+
+		array := make(bson.A, 0, len(values))
+		for _, element := range values {
+			array = append(array, copyValue(element))
+		}
+		return array, true, nil
+
+	case bson.D:
+		if len(value) > 0 && strings.HasPrefix(value[0].Key, "$") {
+			return nil, false, fmt.Errorf("%w: %s", ErrUnsupportedOperator, value[0].Key)
+		}
+
+		result := bson.D{}
+		for _, field := range value {
+			fieldValue, found, err := evalExpression(document, field.Value)
+			if err != nil {
+				return nil, false, err
+			}
+			if found {
+				result = append(result, bson.E{Key: field.Key, Value: fieldValue})
+			}
+		}
+		return result, true, nil
+
+	case bson.A:
+		result := make(bson.A, 0, len(value))
+		for _, element := range value {
+			elementValue, _, err := evalExpression(document, element)
+			if err != nil {
+				return nil, false, err
+			}
+			result = append(result, elementValue)
+		}
+		return result, true, nil
+	}
+
+	return expression, true, nil
+}
+
+// projectStage runs a $project stage
+// Fields set to 1 or true are kept, fields set to 0 or false are removed and
+// the other values are computed from expressions.
+func projectStage(documents []bson.D, argument interface{}) ([]bson.D, error) {
+	specification, ok := argument.(bson.D)
+	if !ok || len(specification) == 0 {
+		return nil, fmt.Errorf("$project needs a nonempty document")
+	}
+
+	includeID := true
+	exclusion := false
+	var included, excluded []string
+	computed := bson.D{}
+	for _, field := range specification {
+		switch value := field.Value.(type) {
+		case bool, int32, int64, float64:
+			if field.Key == "_id" {
+				includeID = isTruthy(value)
+				continue
+			}
+
+			if isTruthy(value) {
+				included = append(included, field.Key)
+			} else {
+				excluded = append(excluded, field.Key)
+				exclusion = true
+			}
+		default:
+			computed = append(computed, field)
+		}
+	}
+
+	if exclusion && (len(included) > 0 || len(computed) > 0) {
+		return nil, fmt.Errorf("$project cannot mix exclusions and inclusions")
+	}
+
+	result := make([]bson.D, 0, len(documents))
+	for _, document := range documents {
+		var projected interface{}
+		if exclusion {
+			projected = copyDocument(document)
+			for _, field := range excluded {
+				projected = unsetValue(projected, strings.Split(field, "."))
+			}
+		} else {
+			projected = projectDocument(document, included)
+			for _, field := range computed {
+				value, found, err := evalExpression(document, field.Value)
+				if err != nil {
+					return nil, err
+				}
+				if !found {
+					continue
+				}
+
+				if projected, err = setValue(projected, strings.Split(field.Key, "."), value); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if !includeID {
+			projected = unsetValue(projected, []string{"_id"})
+		}
+		result = append(result, projected.(bson.D))
+	}
+
+	return result, nil
+}
+
+// unwindStage runs an $unwind stage
+func unwindStage(documents []bson.D, argument interface{}) ([]bson.D, error) {
+	path, preserve := "", false
+	switch value := argument.(type) {
+	case string:
+		path = value
+	case bson.D:
+		for _, field := range value {
+			switch field.Key {
+			case "path":
+				path, _ = field.Value.(string)
+			case "preserveNullAndEmptyArrays":
+				preserve = isTruthy(field.Value)
+			}
+		}
+	}
+
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("$unwind path must start with $")
+	}
+	fieldPath := strings.Split(path[1:], ".")
+
+	result := make([]bson.D, 0, len(documents))
+	for _, document := range documents {
+		value, found := getValue(document, fieldPath)
+		array, isArray := value.(bson.A)
+
+		switch {
+		case isArray && len(array) > 0:
+			for _, element := range array {
+				unwound, err := setValue(copyDocument(document), fieldPath, copyValue(element))
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, unwound.(bson.D))
+			}
+		case isArray || !found || value == nil:
+			if preserve {
+				result = append(result, document)
+			}
+		default:
+			// Like mongo, a non-array value is treated as a single element array
+			result = append(result, document)
+		}
+	}
+
+	return result, nil
+}
+
+// groupStage runs a $group stage, groups are output in the order of their first document
+func groupStage(documents []bson.D, argument interface{}) ([]bson.D, error) {
+	specification, ok := argument.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("$group needs a document")
+	}
+
+	var idExpression interface{}
+	hasID := false
+	accumulators := bson.D{}
+	for _, field := range specification {
+		if field.Key == "_id" {
+			idExpression, hasID = field.Value, true
+			continue
+		}
+
+		accumulator, ok := isOperatorDocument(field.Value)
+		if !ok || len(accumulator) != 1 {
+			return nil, fmt.Errorf("$group field %s must be an accumulator", field.Key)
+		}
+		accumulators = append(accumulators, bson.E{Key: field.Key, Value: accumulator[0]})
+	}
+	if !hasID {
+		return nil, fmt.Errorf("$group needs an _id")
+	}
+
+	var groups []*memoryGroup
+	for _, document := range documents {
+		id, _, err := evalExpression(document, idExpression)
+		if err != nil {
+			return nil, err
+		}
+
+		var group *memoryGroup
+		for _, existing := range groups {
+			if compareValues(existing.id, id) == 0 && typeRank(existing.id) == typeRank(id) {
+				group = existing
+				break
+			}
+		}
+		if group == nil {
+			group = &memoryGroup{id: id, counts: make(map[string]int64)}
+			groups = append(groups, group)
+		}
+
+		for _, field := range accumulators {
+			if err := group.accumulate(document, field.Key, field.Value.(bson.E)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result := make([]bson.D, 0, len(groups))
+	for _, group := range groups {
+		output := bson.D{{Key: "_id", Value: group.id}}
+		for _, field := range accumulators {
+			value, _ := getValue(group.fields, []string{field.Key})
+			if field.Value.(bson.E).Key == "$avg" {
+				if count := group.counts[field.Key]; count > 0 {
+					value = toFloat64(value) / float64(count)
+				}
+			}
+			output = append(output, bson.E{Key: field.Key, Value: value})
+		}
+		result = append(result, output)
+	}
+
+	return result, nil
+}
+
+// accumulate adds the document to the accumulated field of the group
+func (g *memoryGroup) accumulate(document bson.D, field string, accumulator bson.E) error {
+	value, found, err := evalExpression(document, accumulator.Value)
+	if err != nil {
+		return err
+	}
+
+	current, initialized := getValue(g.fields, []string{field})
+	var next interface{}
+	switch accumulator.Key {
+	case "$sum", "$avg":
+		if !initialized {
+			next = int32(0)
+			if accumulator.Key == "$avg" {
+				next = nil
+			}
+		} else {
+			next = current
+		}
+
+		if isNumber(value) {
+			if next == nil {
+				next = int32(0)
+			}
+			next = addNumbers(next, value)
+			g.counts[field]++
+		}
+
+	case "$min", "$max":
+		next = current
+		if found && value != nil {
+			comparison := compareValues(value, current)
+			if !initialized || current == nil || (accumulator.Key == "$min" && comparison < 0) || (accumulator.Key == "$max" && comparison > 0) {
+				next = value
+			}
+		}
+
+	case "$first":
+		if initialized {
+			return nil
+		}
+		next = value
+
+	case "$last":
+		next = value
+
+	case "$push", "$addToSet":
+		array, _ := current.(bson.A)
+		if array == nil {
+			array = bson.A{}
+		}
+
+		if found {
+			duplicate := false
+			if accumulator.Key == "$addToSet" {
+				for _, element := range array {
+					if typeRank(element) == typeRank(value) && compareValues(element, value) == 0 {
+						duplicate = true
+						break
+					}
+				}
+			}
+			if !duplicate {
+				array = append(array, value)
+			}
+		}
+		next = array
+
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedOperator, accumulator.Key)
+	}
+
+	fields, err := setValue(g.fields, []string{field}, next)
+	if err != nil {
+		return err
+	}
+	g.fields = fields.(bson.D)
+
+	return nil
+}
+
+// lookupStage runs a $lookup stage joining on localField and foreignField
+func lookupStage(documents []bson.D, argument interface{}, lookup documentLookup) ([]bson.D, error) {
+	specification, ok := argument.(bson.D)
+	if !ok || lookup == nil {
+		return nil, fmt.Errorf("$lookup needs a document")
+	}
+
+	var from, localField, foreignField, as string
+	for _, field := range specification {
+		value, _ := field.Value.(string)
+		switch field.Key {
+		case "from":
+			from = value
+		case "localField":
+			localField = value
+		case "foreignField":
+			foreignField = value
+		case "as":
+			as = value
+		default:
+			return nil, fmt.Errorf("%w: $lookup %s", ErrUnsupportedOperator, field.Key)
+		}
+	}
+
+	if from == "" || localField == "" || foreignField == "" || as == "" {
+		return nil, fmt.Errorf("$lookup needs from, localField, foreignField and as")
+	}
+
+	foreignDocuments, err := lookup(from)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]bson.D, 0, len(documents))
+	for _, document := range documents {
+		localValues, found := lookupValues(document, strings.Split(localField, "."))
+		if !found {
+			localValues = []interface{}{nil}
+		}
+
+		joined := bson.A{}
+		for _, foreignDocument := range foreignDocuments {
+			foreignValues, foreignFound := lookupValues(foreignDocument, strings.Split(foreignField, "."))
+			for _, localValue := range candidates(localValues) {
+				if _, isArray := localValue.(bson.A); !isArray && matchEqual(foreignValues, foreignFound, localValue) {
+					joined = append(joined, copyDocument(foreignDocument))
+					break
+				}
+			}
+		}
+
+		joinedDocument, err := setValue(copyDocument(document), strings.Split(as, "."), joined)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, joinedDocument.(bson.D))
+	}
+
+	return result, nil
+}
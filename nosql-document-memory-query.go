@@ -0,0 +1,841 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrUnsupportedOperator is returned when an in-process document store meets a query operator it does not implement
+	ErrUnsupportedOperator = errors.New("unsupported query operator")
+)
+
+// toDocument converts a filter, update or document to a bson.D with bson.D sub-documents and bson.A arrays
+// Values are normalized to their BSON types, e.g. time.Time to primitive.DateTime.
+func toDocument(value interface{}) (bson.D, error) {
+	if value == nil {
+		return bson.D{}, nil
+	}
+
+	data, err := bson.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var document bson.D
+	if err := bson.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+// fromDocument decodes the document into a pointer to a new dataModel value
+func fromDocument(document bson.D, dataModel reflect.Type) (interface{}, error) {
+	data, err := bson.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	result := reflect.New(dataModel).Interface()
+	if err := bson.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// copyValue returns a deep copy of the documents and arrays of value
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case bson.D:
+		return copyDocument(v)
+	case bson.A:
+		array := make(bson.A, len(v))
+		for i, element := range v {
+			array[i] = copyValue(element)
+		}
+		return array
+	}
+
+	return value
+}
+
+// copyDocument returns a deep copy of the document
+func copyDocument(document bson.D) bson.D {
+	copied := make(bson.D, len(document))
+	for i, element := range document {
+		copied[i] = bson.E{Key: element.Key, Value: copyValue(element.Value)}
+	}
+
+	return copied
+}
+
+// documentID returns the _id of the document
+func documentID(document bson.D) (interface{}, bool) {
+	return getValue(document, []string{"_id"})
+}
+
+// getValue returns the value at the dotted path, array elements are addressed by index
+func getValue(value interface{}, path []string) (interface{}, bool) {
+	if len(path) == 0 {
+		return value, true
+	}
+
+	switch v := value.(type) {
+	case bson.D:
+		for _, element := range v {
+			if element.Key == path[0] {
+				return getValue(element.Value, path[1:])
+			}
+		}
+	case bson.A:
+		if index, err := strconv.Atoi(path[0]); err == nil && index >= 0 && index < len(v) {
+			return getValue(v[index], path[1:])
+		}
+	}
+
+	return nil, false
+}
+
+// lookupValues returns the values at the dotted path
+// Like mongo, a path crossing an array continues into each of its sub-documents.
+func lookupValues(value interface{}, path []string) ([]interface{}, bool) {
+	if len(path) == 0 {
+		return []interface{}{value}, true
+	}
+
+	switch v := value.(type) {
+	case bson.D:
+		for _, element := range v {
+			if element.Key == path[0] {
+				return lookupValues(element.Value, path[1:])
+			}
+		}
+	case bson.A:
+		if index, err := strconv.Atoi(path[0]); err == nil {
+			if index >= 0 && index < len(v) {
+				return lookupValues(v[index], path[1:])
+			}
+			return nil, false
+		}
+
+		var values []interface{}
+		found := false
+		for _, element := range v {
+			if _, ok := element.(bson.D); !ok {
+				continue
+			}
+			if elementValues, ok := lookupValues(element, path); ok {
+				values = append(values, elementValues...)
+				found = true
+			}
+		}
+		return values, found
+	}
+
+	return nil, false
+}
+
+// setValue sets the value at the dotted path of container, creating the missing sub-documents
+// It returns the modified container.
+func setValue(container interface{}, path []string, value interface{}) (interface{}, error) {
+	switch c := container.(type) {
+	case bson.D:
+		for i := range c {
+			if c[i].Key != path[0] {
+				continue
+			}
+
+			if len(path) == 1 {
+				c[i].Value = value
+				return c, nil
+			}
+
+			nested, err := setValue(c[i].Value, path[1:], value)
+			if err != nil {
+				return nil, err
+			}
+			c[i].Value = nested
+			return c, nil
+		}
+
+		if len(path) == 1 {
+			return append(c, bson.E{Key: path[0], Value: value}), nil
+		}
+
+		nested, err := setValue(bson.D{}, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		return append(c, bson.E{Key: path[0], Value: nested}), nil
+
+	case bson.A:
+		index, err := strconv.Atoi(path[0])
+		if err != nil || index < 0 {
+			return nil, fmt.Errorf("cannot create field %s in an array", path[0])
+		}
+
+		// Like mongo, the array is padded with null values
+		for len(c) <= index {
+			c = append(c, nil)
+		}
+
+		if len(path) == 1 {
+			c[index] = value
+			return c, nil
+		}
+
+		nested, err := setValue(c[index], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		c[index] = nested
+		return c, nil
+	}
+
+	return nil, fmt.Errorf("cannot create field %s in a %T value", path[0], container)
+}
+
+// unsetValue removes the value at the dotted path of container and returns the modified container
+// Like mongo, array elements are set to null instead of being removed.
+func unsetValue(container interface{}, path []string) interface{} {
+	switch c := container.(type) {
+	case bson.D:
+		for i := range c {
+			if c[i].Key != path[0] {
+				continue
+			}
+
+			if len(path) == 1 {
+				return append(c[:i:i], c[i+1:]...)
+			}
+
+			c[i].Value = unsetValue(c[i].Value, path[1:])
+			return c
+		}
+
+	case bson.A:
+		if index, err := strconv.Atoi(path[0]); err == nil && index >= 0 && index < len(c) {
+			if len(path) == 1 {
+				c[index] = nil
+			} else {
+				c[index] = unsetValue(c[index], path[1:])
+			}
+		}
+	}
+
+	return container
+}
+
+// matchFilter reports whether the document matches the filter
+// Supported operators are $and, $or, $nor, $eq, $ne, $gt, $gte, $lt, $lte, $in, $nin,
+// $exists, $not, $size and $regex.
+func matchFilter(document, filter bson.D) (bool, error) {
+	for _, element := range filter {
+		switch element.Key {
+		case "$and", "$or", "$nor":
+			clauses, ok := element.Value.(bson.A)
+			if !ok || len(clauses) == 0 {
+				return false, fmt.Errorf("%s must be a nonempty array", element.Key)
+			}
+
+			matches := 0
+			for _, clause := range clauses {
+				clauseFilter, ok := clause.(bson.D)
+				if !ok {
+					return false, fmt.Errorf("%s entries must be documents", element.Key)
+				}
+
+				match, err := matchFilter(document, clauseFilter)
+				if err != nil {
+					return false, err
+				}
+				if match {
+					matches++
+				}
+			}
+
+			switch {
+			case element.Key == "$and" && matches < len(clauses):
+				return false, nil
+			case element.Key == "$or" && matches == 0:
+				return false, nil
+			case element.Key == "$nor" && matches > 0:
+				return false, nil
+			}
+
+		default:
+			if strings.HasPrefix(element.Key, "$") {
+				return false, fmt.Errorf("%w: %s", ErrUnsupportedOperator, element.Key)
+			}
+
+			values, found := lookupValues(document, strings.Split(element.Key, "."))
+			match, err := matchCondition(values, found, element.Value)
+			if err != nil || !match {
+				return false, err
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// isOperatorDocument reports whether the value is a document of operators, e.g. {$gt: 1}
+func isOperatorDocument(value interface{}) (bson.D, bool) {
+	document, ok := value.(bson.D)
+	if !ok || len(document) == 0 || !strings.HasPrefix(document[0].Key, "$") {
+		return nil, false
+	}
+
+	return document, true
+}
+
+// matchCondition reports whether the values found at a filter path match its condition
+func matchCondition(values []interface{}, found bool, condition interface{}) (bool, error) {
+	operators, ok := isOperatorDocument(condition)
+	if !ok {
+		return matchEqual(values, found, condition), nil
+	}
+
+	for _, operator := range operators {
+		match, err := matchOperator(values, found, operator, operators)
+		if err != nil || !match {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// matchOperator reports whether the values found at a filter path match the query operator
+func matchOperator(values []interface{}, found bool, operator bson.E, operators bson.D) (bool, error) {
+	switch operator.Key {
+	case "$eq":
+		return matchEqual(values, found, operator.Value), nil
+
+	case "$ne":
+		return !matchEqual(values, found, operator.Value), nil
+
+	case "$gt", "$gte", "$lt", "$lte":
+		for _, candidate := range candidates(values) {
+			if typeRank(candidate) != typeRank(operator.Value) {
+				continue
+			}
+
+			comparison := compareValues(candidate, operator.Value)
+			switch {
+			case operator.Key == "$gt" && comparison > 0,
+				operator.Key == "$gte" && comparison >= 0,
+				operator.Key == "$lt" && comparison < 0,
+				operator.Key == "$lte" && comparison <= 0:
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case "$in", "$nin":
+		list, ok := operator.Value.(bson.A)
+		if !ok {
+			return false, fmt.Errorf("%s needs an array", operator.Key)
+		}
+
+		in := false
+		for _, value := range list {
+			if matchEqual(values, found, value) {
+				in = true
+				break
+			}
+		}
+		return in == (operator.Key == "$in"), nil
+
+	case "$exists":
+		return found == isTruthy(operator.Value), nil
+
+	case "$not":
+		match, err := matchCondition(values, found, operator.Value)
+		return !match, err
+
+	case "$size":
+		for _, value := range values {
+			if array, ok := value.(bson.A); ok && compareValues(int64(len(array)), operator.Value) == 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case "$regex":
+		options := ""
+		for _, sibling := range operators {
+			if sibling.Key == "$options" {
+				options, _ = sibling.Value.(string)
+			}
+		}
+
+		pattern := primitive.Regex{Options: options}
+		switch value := operator.Value.(type) {
+		case string:
+			pattern.Pattern = value
+		case primitive.Regex:
+			pattern = value
+		default:
+			return false, fmt.Errorf("$regex needs a string")
+		}
+		return matchEqual(values, found, pattern), nil
+
+	case "$options":
+		return true, nil
+	}
+
+	return false, fmt.Errorf("%w: %s", ErrUnsupportedOperator, operator.Key)
+}
+
+// candidates returns the values and the elements of the array values, which mongo operators match individually
+func candidates(values []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		result = append(result, value)
+		if array, ok := value.(bson.A); ok {
+			result = append(result, array...)
+		}
+	}
+
+	return result
+}
+
+// matchEqual reports whether one of the values equals the target, or contains it when it is an array
+// A null target matches missing fields.
+func matchEqual(values []interface{}, found bool, target interface{}) bool {
+	if typeRank(target) == typeRank(nil) && !found {
+		return true
+	}
+
+	pattern, isPattern := target.(primitive.Regex)
+	var expression *regexp.Regexp
+	if isPattern {
+		flags := ""
+		for _, option := range pattern.Options {
+			// Go regular expressions support the i, m and s options of mongo
+			if strings.ContainsRune("ims", option) {
+				flags += string(option)
+			}
+		}
+		if flags != "" {
+			flags = "(?" + flags + ")"
+		}
+
+		var err error
+		if expression, err = regexp.Compile(flags + pattern.Pattern); err != nil {
+			return false
+		}
+	}
+
+	for _, candidate := range candidates(values) {
+		if isPattern {
+			if text, ok := candidate.(string); ok && expression.MatchString(text) {
+				return true
+			}
+		}
+
+		if typeRank(candidate) == typeRank(target) && compareValues(candidate, target) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isTruthy returns the boolean value of an operator argument
+func isTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case nil, primitive.Null:
+		return false
+	}
+
+	if isNumber(value) {
+		return toFloat64(value) != 0
+	}
+
+	return true
+}
+
+// typeRank returns the position of the value type in the mongo comparison order
+func typeRank(value interface{}) int {
+	switch value.(type) {
+	case primitive.MinKey:
+		return 0
+	case nil, primitive.Null, primitive.Undefined:
+		return 1
+	case int, int32, int64, float64:
+		return 2
+	case string, primitive.Symbol:
+		return 3
+	case bson.D:
+		return 4
+	case bson.A:
+		return 5
+	case primitive.Binary:
+		return 6
+	case primitive.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case primitive.DateTime:
+		return 9
+	case primitive.Timestamp:
+		return 10
+	case primitive.Regex:
+		return 11
+	case primitive.MaxKey:
+		return 13
+	}
+
+	return 12
+}
+
+// compareValues compares two values in the mongo comparison order
+// It returns a negative number when a < b, zero when a == b and a positive number when a > b.
+func compareValues(a, b interface{}) int {
+	rankA, rankB := typeRank(a), typeRank(b)
+	if rankA != rankB {
+		return rankA - rankB
+	}
+
+	switch x := a.(type) {
+	case int, int32, int64, float64:
+		if isInteger(a) && isInteger(b) {
+			return compareInt64(toInt64(a), toInt64(b))
+		}
+
+		fa, fb := toFloat64(a), toFloat64(b)
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+
+	case string:
+		y, _ := b.(string)
+		return strings.Compare(x, y)
+
+	case bson.D:
+		y := b.(bson.D)
+		for i := 0; i < len(x) && i < len(y); i++ {
+			if comparison := strings.Compare(x[i].Key, y[i].Key); comparison != 0 {
+				return comparison
+			}
+			if comparison := compareValues(x[i].Value, y[i].Value); comparison != 0 {
+				return comparison
+			}
+		}
+		return len(x) - len(y)
+
+	case bson.A:
+		y := b.(bson.A)
+		for i := 0; i < len(x) && i < len(y); i++ {
+			if comparison := compareValues(x[i], y[i]); comparison != 0 {
+				return comparison
+			}
+		}
+		return len(x) - len(y)
+
+	case primitive.Binary:
+		return bytes.Compare(x.Data, b.(primitive.Binary).Data)
+
+	case primitive.ObjectID:
+		y := b.(primitive.ObjectID)
+		return bytes.Compare(x[:], y[:])
+
+	case bool:
+		y := b.(bool)
+		switch {
+		case x == y:
+			return 0
+		case !x:
+			return -1
+		}
+		return 1
+
+	case primitive.DateTime:
+		return compareInt64(int64(x), int64(b.(primitive.DateTime)))
+
+	case primitive.Timestamp:
+		y := b.(primitive.Timestamp)
+		if x.T != y.T {
+			return compareInt64(int64(x.T), int64(y.T))
+		}
+		return compareInt64(int64(x.I), int64(y.I))
+	}
+
+	if rankA <= 1 || rankA == 13 {
+		return 0
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// compareInt64 compares two integers
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// isNumber reports whether the value is a BSON number
+func isNumber(value interface{}) bool {
+	switch value.(type) {
+	case int, int32, int64, float64:
+		return true
+	}
+
+	return false
+}
+
+// isInteger reports whether the value is a BSON integer
+func isInteger(value interface{}) bool {
+	switch value.(type) {
+	case int, int32, int64:
+		return true
+	}
+
+	return false
+}
+
+// toInt64 converts a BSON integer
+func toInt64(value interface{}) int64 {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+
+	return 0
+}
+
+// toFloat64 converts a BSON number
+func toFloat64(value interface{}) float64 {
+	if v, ok := value.(float64); ok {
+		return v
+	}
+
+	return float64(toInt64(value))
+}
+
+// addNumbers adds two BSON numbers, keeping the widest type like $inc
+func addNumbers(a, b interface{}) interface{} {
+	if _, ok := a.(float64); ok {
+		return toFloat64(a) + toFloat64(b)
+	}
+	if _, ok := b.(float64); ok {
+		return toFloat64(a) + toFloat64(b)
+	}
+
+	sum := toInt64(a) + toInt64(b)
+	_, wideA := a.(int64)
+	_, wideB := b.(int64)
+	if wideA || wideB || sum > math.MaxInt32 || sum < math.MinInt32 {
+		return sum
+	}
+
+	return int32(sum)
+}
+
+// isUpdateDocument reports whether the document holds update operators rather than a replacement
+func isUpdateDocument(document bson.D) (bool, error) {
+	operators := 0
+	for _, element := range document {
+		if strings.HasPrefix(element.Key, "$") {
+			operators++
+		}
+	}
+
+	if operators > 0 && operators < len(document) {
+		return false, fmt.Errorf("update document cannot mix update operators and fields")
+	}
+
+	return operators > 0, nil
+}
+
+// applyUpdate returns a copy of the document with the update operators applied
+// Supported operators are $set, $setOnInsert, $unset and $inc, $setOnInsert only applies when inserting.
+func applyUpdate(document, update bson.D, inserting bool) (bson.D, error) {
+	if len(update) == 0 {
+		return nil, fmt.Errorf("update document cannot be empty")
+	}
+
+	isUpdate, err := isUpdateDocument(update)
+	if err != nil {
+		return nil, err
+	}
+	if !isUpdate {
+		return nil, fmt.Errorf("update document must contain update operators")
+	}
+
+	id, hasID := documentID(document)
+	var result interface{} = copyDocument(document)
+	for _, operator := range update {
+		fields, ok := operator.Value.(bson.D)
+		if !ok {
+			return nil, fmt.Errorf("%s needs a document", operator.Key)
+		}
+
+		for _, field := range fields {
+			path := strings.Split(field.Key, ".")
+			switch operator.Key {
+			case "$set":
+				result, err = setValue(result, path, copyValue(field.Value))
+
+			case "$setOnInsert":
+				if inserting {
+					result, err = setValue(result, path, copyValue(field.Value))
+				}
+
+			case "$unset":
+				result = unsetValue(result, path)
+
+			case "$inc":
+				if !isNumber(field.Value) {
+					return nil, fmt.Errorf("cannot increment %s by a non-numeric value", field.Key)
+				}
+
+				value := field.Value
+				if current, found := getValue(result, path); found {
+					if !isNumber(current) {
+						return nil, fmt.Errorf("cannot increment the non-numeric field %s", field.Key)
+					}
+					value = addNumbers(current, field.Value)
+				}
+				result, err = setValue(result, path, value)
+
+			default:
+				return nil, fmt.Errorf("%w: %s", ErrUnsupportedOperator, operator.Key)
+			}
+
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	updated := result.(bson.D)
+	if newID, found := documentID(updated); hasID && (!found || compareValues(id, newID) != 0) {
+		return nil, fmt.Errorf("the _id field cannot be modified")
+	}
+
+	return updated, nil
+}
+
+// upsertDocument returns the document inserted by an upsert, built from the equality conditions of the filter
+func upsertDocument(filter bson.D) (bson.D, error) {
+	var document interface{} = bson.D{}
+	for _, element := range filter {
+		if element.Key == "$and" {
+			clauses, _ := element.Value.(bson.A)
+			for _, clause := range clauses {
+				clauseFilter, ok := clause.(bson.D)
+				if !ok {
+					continue
+				}
+
+				seed, err := upsertDocument(clauseFilter)
+				if err != nil {
+					return nil, err
+				}
+				for _, field := range seed {
+					var err error
+					if document, err = setValue(document, strings.Split(field.Key, "."), field.Value); err != nil {
+						return nil, err
+					}
+				}
+			}
+			continue
+		}
+
+		if strings.HasPrefix(element.Key, "$") {
+			continue
+		}
+
+		value := element.Value
+		if operators, ok := isOperatorDocument(element.Value); ok {
+			value, ok = nil, false
+			for _, operator := range operators {
+				if operator.Key == "$eq" {
+					value, ok = operator.Value, true
+				}
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		var err error
+		if document, err = setValue(document, strings.Split(element.Key, "."), copyValue(value)); err != nil {
+			return nil, err
+		}
+	}
+
+	return document.(bson.D), nil
+}
+
+// projectDocument returns a document holding only _id and the projected fields
+func projectDocument(document bson.D, fields []string) bson.D {
+	var projected interface{} = bson.D{}
+	for _, field := range append([]string{"_id"}, fields...) {
+		path := strings.Split(field, ".")
+		if _, exists := getValue(projected, path); exists {
+			continue
+		}
+
+		if value, found := getValue(document, path); found {
+			projected, _ = setValue(projected, path, copyValue(value))
+		}
+	}
+
+	return projected.(bson.D)
+}
+
+// sortDocuments sorts the documents by the sort fields, missing fields sort as null
+func sortDocuments(documents []bson.D, fields []SortField) {
+	sort.SliceStable(documents, func(i, j int) bool {
+		for _, field := range fields {
+			path := strings.Split(field.Field, ".")
+			a, _ := getValue(documents[i], path)
+			b, _ := getValue(documents[j], path)
+
+			comparison := compareValues(a, b)
+			if comparison == 0 {
+				continue
+			}
+			if field.Descending {
+				return comparison > 0
+			}
+			return comparison < 0
+		}
+
+		return false
+	})
+}
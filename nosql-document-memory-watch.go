@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// defaultChangeLogSize is the number of changes kept for resuming watches when the config does not set it
+const defaultChangeLogSize = 1000
+
+// memoryChange private model for a change of an in-process store
+type memoryChange struct {
+	sequence       int64
+	databaseName   string
+	collectionName string
+	operation      ChangeOperation
+	id             interface{}
+	document       bson.D
+	updatedFields  map[string]interface{}
+	removedFields  []string
+	time           time.Time
+}
+
+// memoryChangeLog private model for the last changes of an in-process store
+// notify is closed and replaced on each append, to wake the watchers up.
+type memoryChangeLog struct {
+	size    int
+	next    int64
+	changes []memoryChange
+	notify  chan struct{}
+}
+
+// init sets the number of changes kept
+func (l *memoryChangeLog) init(size int) {
+	if size <= 0 {
+		size = defaultChangeLogSize
+	}
+
+	l.size = size
+	l.next = 1
+	l.notify = make(chan struct{})
+}
+
+// append numbers the changes, drops the oldest ones beyond the log size and wakes the watchers up
+func (l *memoryChangeLog) append(changes []memoryChange) {
+	for _, change := range changes {
+		change.sequence = l.next
+		l.next++
+		l.changes = append(l.changes, change)
+	}
+
+	if overflow := len(l.changes) - l.size; overflow > 0 {
+		l.changes = append(l.changes[:0:0], l.changes[overflow:]...)
+	}
+
+	close(l.notify)
+	l.notify = make(chan struct{})
+}
+
+// since returns the changes from sequence on, and false when some of them were dropped
+func (l *memoryChangeLog) since(sequence int64) ([]memoryChange, bool) {
+	if len(l.changes) == 0 || sequence >= l.next {
+		return nil, sequence <= l.next
+	}
+
+	first := l.changes[0].sequence
+	if sequence < first {
+		return nil, false
+	}
+
+	return append([]memoryChange(nil), l.changes[sequence-first:]...), true
+}
+
+// changeDocument returns the change as a mongo change stream document
+func (c memoryChange) changeDocument(fullDocument bool) bson.D {
+	document := bson.D{
+		{Key: "_id", Value: bson.D{{Key: "_data", Value: c.sequence}}},
+		{Key: "operationType", Value: string(c.operation)},
+		{Key: "clusterTime", Value: primitive.Timestamp{T: uint32(c.time.Unix())}},
+		{Key: "ns", Value: bson.D{{Key: "db", Value: c.databaseName}, {Key: "coll", Value: c.collectionName}}},
+		{Key: "documentKey", Value: bson.D{{Key: "_id", Value: c.id}}},
+	}
+
+	if c.document != nil && (c.operation != ChangeUpdate || fullDocument) {
+		document = append(document, bson.E{Key: "fullDocument", Value: c.document})
+	}
+
+	if c.operation == ChangeUpdate {
+		removedFields := c.removedFields
+		if removedFields == nil {
+			removedFields = []string{}
+		}
+
+		document = append(document, bson.E{Key: "updateDescription", Value: bson.D{
+			{Key: "updatedFields", Value: c.updatedFields},
+			{Key: "removedFields", Value: removedFields},
+		}})
+	}
+
+	return document
+}
+
+// Watch streams the changes of the specified collection matching the $match stages of the pipeline
+// Changes are kept in a bounded log, ChangeLogSize in the config, so a resume token is only
+// valid as long as its change is in the log. Resume tokens are saved like the mongo watch does.
+func (m *MemoryDocumentClient) Watch(ctx context.Context, databaseName, collectionName string, pipeline interface{}, watchOptions *WatchOptions) (<-chan ChangeEvent, error) {
+	if watchOptions == nil {
+		watchOptions = &WatchOptions{}
+	}
+
+	var stages []bson.D
+	if pipeline != nil {
+		var err error
+		if stages, err = toPipeline(pipeline); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, stage := range stages {
+		if len(stage) != 1 || stage[0].Key != "$match" {
+			return nil, fmt.Errorf("%w: only $match stages can watch changes", ErrUnsupportedOperator)
+		}
+	}
+
+	token, err := watchOptions.resumeToken(databaseName, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	position := m.changes.next
+	if token != nil {
+		var resumeToken struct {
+			Data int64 `bson:"_data"`
+		}
+		if err := bson.Unmarshal(token, &resumeToken); err != nil {
+			m.mu.Unlock()
+			return nil, fmt.Errorf("invalid resume token: %w", err)
+		}
+
+		position = resumeToken.Data + 1
+		if _, ok := m.changes.since(position); !ok {
+			m.mu.Unlock()
+			return nil, fmt.Errorf("resume token is no longer in the change log")
+		}
+	}
+	m.mu.Unlock()
+
+	events := make(chan ChangeEvent)
+	go func() {
+		defer close(events)
+
+		processed := ""
+		for {
+			m.mu.Lock()
+			changes, ok := m.changes.since(position)
+			notify := m.changes.notify
+			m.mu.Unlock()
+
+			if !ok {
+				select {
+				case events <- ChangeEvent{Err: fmt.Errorf("resume token is no longer in the change log")}:
+					watchOptions.saveResumeToken(databaseName, collectionName, processed)
+				case <-ctx.Done():
+				}
+				return
+			}
+
+			for _, change := range changes {
+				position = change.sequence + 1
+				if change.databaseName != databaseName || change.collectionName != collectionName {
+					continue
+				}
+
+				event, match, err := m.changeEvent(change, stages, watchOptions)
+				if err != nil {
+					log.Printf("Unable to decode change event of %s.%s: %v", databaseName, collectionName, err)
+					event = ChangeEvent{Err: err}
+				} else if !match {
+					continue
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+
+				// The consumer is back for this event, so it is done with the previous one
+				watchOptions.saveResumeToken(databaseName, collectionName, processed)
+				if event.Err != nil {
+					return
+				}
+				processed = event.ResumeToken
+			}
+
+			select {
+			case <-notify:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// changeEvent converts the change when it matches the stages
+func (m *MemoryDocumentClient) changeEvent(change memoryChange, stages []bson.D, watchOptions *WatchOptions) (ChangeEvent, bool, error) {
+	document, err := toDocument(change.changeDocument(watchOptions.FullDocument))
+	if err != nil {
+		return ChangeEvent{}, false, err
+	}
+
+	for _, stage := range stages {
+		filter, err := toDocument(stage[0].Value)
+		if err != nil {
+			return ChangeEvent{}, false, err
+		}
+
+		match, err := matchFilter(document, filter)
+		if err != nil || !match {
+			return ChangeEvent{}, false, err
+		}
+	}
+
+	raw, err := bson.Marshal(document)
+	if err != nil {
+		return ChangeEvent{}, false, err
+	}
+
+	event, err := decodeMongoChangeEvent(raw, watchOptions.DataModel)
+	if err != nil {
+		return ChangeEvent{}, false, err
+	}
+	event.Time = change.time
+
+	return event, true, nil
+}
@@ -0,0 +1,1614 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/golang-common-packages/hash"
+)
+
+var (
+	// ErrTransactionConflict is returned when an in-process transaction keeps conflicting with concurrent writes
+	ErrTransactionConflict = errors.New("transaction aborted by conflicting writes")
+
+	// memoryDocumentSessionMapping singleton pattern
+	memoryDocumentSessionMapping = make(map[string]*MemoryDocumentClient)
+)
+
+const (
+	// memoryTransactionAttempts is the number of times a conflicting transaction is run
+	memoryTransactionAttempts = 10
+	// duplicateKeyErrorCode is the mongo error code of unique index violations
+	duplicateKeyErrorCode = 11000
+	// badValueErrorCode is the mongo error code of invalid operations
+	badValueErrorCode = 2
+)
+
+// MemoryDocumentClient manage all in-process document actions
+// It implements INoSQLDocument with a subset of the mongo query language, see matchFilter,
+// applyUpdate and aggregateDocuments, so document code can be tested without a server.
+type MemoryDocumentClient struct {
+	config  *InMemoryDocument
+	mu      sync.Mutex
+	state   memoryDocumentState
+	version uint64 // incremented on each committed write, to detect transaction conflicts
+	changes memoryChangeLog
+	persist memoryDocumentPersister
+}
+
+// memoryCollection private model for the documents and indexes of a collection
+type memoryCollection struct {
+	documents []bson.D
+	indexes   []IndexSpec
+	// validator is the $jsonSchema set by SetValidator, and schema its compiled form
+	validator bson.D
+	schema    *documentSchema
+	// lookups holds the positions of the documents by indexed value, by field, built on first use
+	lookups map[string]map[string][]int
+	// private is set once documents and indexes are copied for a session, so they can be modified
+	private bool
+}
+
+// memoryDocumentState private model for the collections of an in-process store, by namespace
+// Stored documents are never modified in place, and sessions copy a collection before writing it,
+// so copying the map is enough to snapshot the state.
+type memoryDocumentState map[string]*memoryCollection
+
+// memoryDocumentPersister writes the modified collections of a state before it is committed
+type memoryDocumentPersister func(state memoryDocumentState, modified map[string]bool) error
+
+// memoryDocumentSession runs document operations on a snapshot of a state and collects the resulting changes
+type memoryDocumentSession struct {
+	state    memoryDocumentState
+	changes  []memoryChange
+	modified map[string]bool
+}
+
+// memoryDocumentRunner runs fn with a session, holding whatever lock the session needs
+type memoryDocumentRunner func(fn func(s *memoryDocumentSession) error) error
+
+// memoryDocumentTx runs the document operations in an in-process transaction
+type memoryDocumentTx struct {
+	session *memoryDocumentSession
+	mu      sync.Mutex
+}
+
+// memoryDocumentCursor iterates over a snapshot of documents
+type memoryDocumentCursor struct {
+	documents []bson.D
+	position  int
+	err       error
+}
+
+// newMemoryDocument init new instance
+func newMemoryDocument(config *InMemoryDocument) INoSQLDocument {
+	hasher := &hash.Client{}
+	configAsJSON, err := json.Marshal(config)
+	if err != nil {
+		log.Fatalln("Unable to marshal in-memory document configuration: ", err)
+	}
+	configAsString := hasher.SHA1(string(configAsJSON))
+
+	currentMemorySession := memoryDocumentSessionMapping[configAsString]
+	if currentMemorySession == nil {
+		currentMemorySession = &MemoryDocumentClient{config: config, state: make(memoryDocumentState)}
+		currentMemorySession.changes.init(config.ChangeLogSize)
+		memoryDocumentSessionMapping[configAsString] = currentMemorySession
+		log.Println("Document in-memory is ready")
+	}
+
+	return currentMemorySession
+}
+
+// execute runs fn on the committed state
+func (m *MemoryDocumentClient) execute(fn func(s *memoryDocumentSession) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session := newMemoryDocumentSession(m.state)
+	err := fn(session)
+
+	// Like mongo, the writes done before an error are kept
+	if commitErr := m.commit(session); commitErr != nil {
+		return commitErr
+	}
+
+	return err
+}
+
+// commit persists the session state, makes it the committed state and publishes its changes
+// The caller must hold m.mu.
+func (m *MemoryDocumentClient) commit(session *memoryDocumentSession) error {
+	if len(session.modified) > 0 && m.persist != nil {
+		if err := m.persist(session.state, session.modified); err != nil {
+			log.Printf("Unable to persist documents: %v", err)
+			return err
+		}
+	}
+
+	// Reads commit too, to keep the lookups they built
+	m.state = session.state
+	if len(session.changes) == 0 {
+		return nil
+	}
+
+	m.version++
+	m.changes.append(session.changes)
+
+	return nil
+}
+
+// Create inserts a list of documents into the specified collection
+func (m *MemoryDocumentClient) Create(databaseName, collectionName string, documents []interface{}) (interface{}, error) {
+	return memoryDocumentRunner(m.execute).create(databaseName, collectionName, documents)
+}
+
+// Read retrieves documents from the specified collection based on filter, sorted by _id
+func (m *MemoryDocumentClient) Read(databaseName, collectionName string, filter interface{}, limit int64, dataModel reflect.Type) (interface{}, error) {
+	return memoryDocumentRunner(m.execute).read(databaseName, collectionName, filter, limit, dataModel)
+}
+
+// Update modifies documents in the specified collection based on filter
+func (m *MemoryDocumentClient) Update(databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	return memoryDocumentRunner(m.execute).update(databaseName, collectionName, filter, update, true, false)
+}
+
+// Delete removes documents from the specified collection based on filter
+func (m *MemoryDocumentClient) Delete(databaseName, collectionName string, filter interface{}) (interface{}, error) {
+	return memoryDocumentRunner(m.execute).delete(databaseName, collectionName, filter, true)
+}
+
+// ReadPage retrieves a page of documents from the specified collection based on filter
+func (m *MemoryDocumentClient) ReadPage(ctx context.Context, databaseName, collectionName string, filter interface{}, findOptions *FindOptions, dataModel reflect.Type) (*Page, error) {
+	if dataModel == nil {
+		return nil, fmt.Errorf("data model cannot be nil")
+	}
+
+	if findOptions == nil {
+		findOptions = &FindOptions{}
+	}
+
+	extra := int64(0)
+	if findOptions.Limit > 0 {
+		// One extra document tells whether there is a next page
+		extra = 1
+	}
+
+	var (
+		documents []bson.D
+		total     int
+	)
+	if err := m.execute(func(s *memoryDocumentSession) error {
+		var err error
+		if documents, err = s.findWithOptions(databaseName, collectionName, filter, findOptions, extra); err != nil {
+			return err
+		}
+
+		if findOptions.IncludeTotal {
+			matched, err := s.find(databaseName, collectionName, filter)
+			total = len(matched)
+			return err
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	hasMore := findOptions.Limit > 0 && int64(len(documents)) > findOptions.Limit
+	if hasMore {
+		documents = documents[:findOptions.Limit]
+	}
+
+	items, err := decodeDocuments(documents, dataModel)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{Items: items, Total: int64(total)}
+	if hasMore {
+		last, err := bson.Marshal(documents[len(documents)-1])
+		if err != nil {
+			return nil, err
+		}
+		if page.NextCursor, err = encodeCursor(last, findOptions.sortFields()); err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+// ReadStream returns a cursor over the documents of the specified collection matching filter
+// The cursor iterates over a snapshot taken when it is created.
+func (m *MemoryDocumentClient) ReadStream(ctx context.Context, databaseName, collectionName string, filter interface{}, findOptions *FindOptions) (IDocumentCursor, error) {
+	if findOptions == nil {
+		findOptions = &FindOptions{}
+	}
+
+	var documents []bson.D
+	if err := m.execute(func(s *memoryDocumentSession) error {
+		var err error
+		documents, err = s.findWithOptions(databaseName, collectionName, filter, findOptions, 0)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return &memoryDocumentCursor{documents: documents, position: -1}, nil
+}
+
+// FindOne retrieves the first document matching filter, sorted by _id
+func (m *MemoryDocumentClient) FindOne(ctx context.Context, databaseName, collectionName string, filter interface{}, dataModel reflect.Type) (interface{}, error) {
+	return memoryDocumentRunner(m.execute).findOne(databaseName, collectionName, filter, dataModel)
+}
+
+// UpdateOne modifies the first document matching filter
+func (m *MemoryDocumentClient) UpdateOne(ctx context.Context, databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	return memoryDocumentRunner(m.execute).update(databaseName, collectionName, filter, update, false, false)
+}
+
+// ReplaceOne replaces the first document matching filter, keeping its _id
+func (m *MemoryDocumentClient) ReplaceOne(ctx context.Context, databaseName, collectionName string, filter, replacement interface{}) (interface{}, error) {
+	return memoryDocumentRunner(m.execute).replace(databaseName, collectionName, filter, replacement, false)
+}
+
+// DeleteOne removes the first document matching filter
+func (m *MemoryDocumentClient) DeleteOne(ctx context.Context, databaseName, collectionName string, filter interface{}) (interface{}, error) {
+	return memoryDocumentRunner(m.execute).delete(databaseName, collectionName, filter, false)
+}
+
+// Upsert modifies the first document matching filter, or inserts one built from filter and update
+func (m *MemoryDocumentClient) Upsert(ctx context.Context, databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	return memoryDocumentRunner(m.execute).update(databaseName, collectionName, filter, update, false, true)
+}
+
+// FindOneAndUpdate atomically modifies the first document matching filter, sorted by _id
+func (m *MemoryDocumentClient) FindOneAndUpdate(ctx context.Context, databaseName, collectionName string, filter, update interface{}, returnDocument ReturnDocument, dataModel reflect.Type) (interface{}, error) {
+	return memoryDocumentRunner(m.execute).findOneAndUpdate(databaseName, collectionName, filter, update, returnDocument, dataModel)
+}
+
+// Count returns the number of documents matching filter, a nil filter counts every document
+func (m *MemoryDocumentClient) Count(ctx context.Context, databaseName, collectionName string, filter interface{}) (int64, error) {
+	var count int
+	err := m.execute(func(s *memoryDocumentSession) error {
+		documents, err := s.find(databaseName, collectionName, filter)
+		count = len(documents)
+		return err
+	})
+
+	return int64(count), err
+}
+
+// EstimatedCount returns the number of documents of the collection
+func (m *MemoryDocumentClient) EstimatedCount(ctx context.Context, databaseName, collectionName string) (int64, error) {
+	var count int
+	err := m.execute(func(s *memoryDocumentSession) error {
+		collection, err := s.collection(databaseName, collectionName, false)
+		if collection != nil {
+			count = len(collection.documents)
+		}
+		return err
+	})
+
+	return int64(count), err
+}
+
+// Distinct returns the distinct values of field in the documents matching filter, sorted
+// Array values are unwound, each element being a distinct value. A nil filter reads every document.
+func (m *MemoryDocumentClient) Distinct(ctx context.Context, databaseName, collectionName, field string, filter interface{}) ([]interface{}, error) {
+	if field == "" {
+		return nil, fmt.Errorf("field cannot be empty")
+	}
+
+	var documents []bson.D
+	if err := m.execute(func(s *memoryDocumentSession) error {
+		var err error
+		documents, err = s.find(databaseName, collectionName, filter)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	path := strings.Split(field, ".")
+	values := []interface{}{}
+	for _, document := range documents {
+		found, _ := lookupValues(document, path)
+		for _, value := range found {
+			elements := []interface{}{value}
+			if array, ok := value.(bson.A); ok {
+				elements = array
+			}
+
+			for _, element := range elements {
+				position := sort.Search(len(values), func(i int) bool {
+					return compareValues(values[i], element) >= 0
+				})
+				if position < len(values) && compareValues(values[position], element) == 0 {
+					continue
+				}
+
+				values = append(values, nil)
+				copy(values[position+1:], values[position:])
+				values[position] = copyValue(element)
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// Exists reports whether a document matches filter
+func (m *MemoryDocumentClient) Exists(ctx context.Context, databaseName, collectionName string, filter interface{}) (bool, error) {
+	if filter == nil {
+		return false, fmt.Errorf("filter cannot be nil")
+	}
+
+	exists := false
+	err := m.execute(func(s *memoryDocumentSession) error {
+		documents, err := s.findWithOptions(databaseName, collectionName, filter, &FindOptions{Limit: 1}, 0)
+		exists = len(documents) > 0
+		return err
+	})
+
+	return exists, err
+}
+
+// Aggregate runs the aggregation pipeline on the specified collection
+func (m *MemoryDocumentClient) Aggregate(ctx context.Context, databaseName, collectionName string, pipeline interface{}, dataModel reflect.Type, aggregateOptions *AggregateOptions) (interface{}, error) {
+	if dataModel == nil {
+		return nil, fmt.Errorf("data model cannot be nil")
+	}
+
+	documents, err := m.aggregate(databaseName, collectionName, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeDocuments(documents, dataModel)
+}
+
+// AggregateStream runs the aggregation pipeline on the specified collection and returns a cursor over the results
+func (m *MemoryDocumentClient) AggregateStream(ctx context.Context, databaseName, collectionName string, pipeline interface{}, aggregateOptions *AggregateOptions) (IDocumentCursor, error) {
+	documents, err := m.aggregate(databaseName, collectionName, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	return &memoryDocumentCursor{documents: documents, position: -1}, nil
+}
+
+// aggregate runs the aggregation pipeline on a snapshot of the collection
+func (m *MemoryDocumentClient) aggregate(databaseName, collectionName string, pipeline interface{}) ([]bson.D, error) {
+	if pipeline == nil {
+		return nil, fmt.Errorf("pipeline cannot be nil")
+	}
+
+	stages, err := toPipeline(pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []bson.D
+	err = m.execute(func(s *memoryDocumentSession) error {
+		documents, err := s.find(databaseName, collectionName, nil)
+		if err != nil {
+			return err
+		}
+
+		results, err = aggregateDocuments(documents, stages, func(from string) ([]bson.D, error) {
+			return s.find(databaseName, from, nil)
+		})
+		return err
+	})
+
+	return results, err
+}
+
+// EnsureIndexes creates the indexes missing from the specified collection and returns their names
+// Unique, sparse, partial and TTL options are enforced, the other indexes are only recorded.
+func (m *MemoryDocumentClient) EnsureIndexes(ctx context.Context, databaseName, collectionName string, indexes []IndexSpec) ([]string, error) {
+	var names []string
+	err := m.execute(func(s *memoryDocumentSession) error {
+		var err error
+		names, err = s.ensureIndexes(databaseName, collectionName, indexes)
+		return err
+	})
+
+	return names, err
+}
+
+// ListIndexes returns the indexes of the specified collection
+func (m *MemoryDocumentClient) ListIndexes(ctx context.Context, databaseName, collectionName string) ([]IndexSpec, error) {
+	indexes := []IndexSpec{}
+	err := m.execute(func(s *memoryDocumentSession) error {
+		collection, err := s.collection(databaseName, collectionName, false)
+		if collection != nil {
+			indexes = append(indexes, collection.indexes...)
+		}
+		return err
+	})
+
+	return indexes, err
+}
+
+// DropIndex removes the named index from the specified collection
+func (m *MemoryDocumentClient) DropIndex(ctx context.Context, databaseName, collectionName, indexName string) error {
+	return m.execute(func(s *memoryDocumentSession) error {
+		return s.dropIndex(databaseName, collectionName, indexName)
+	})
+}
+
+// SetValidator sets the $jsonSchema validator of the specified collection, creating the collection when missing
+// Inserts and updates breaking the schema then fail with a WriteError, existing documents are not checked.
+// A nil schema removes the validator.
+func (m *MemoryDocumentClient) SetValidator(ctx context.Context, databaseName, collectionName string, schema interface{}) error {
+	return m.execute(func(s *memoryDocumentSession) error {
+		return s.setValidator(databaseName, collectionName, schema)
+	})
+}
+
+// WithTransaction runs fn in a transaction, committed when fn returns nil and aborted otherwise
+// fn runs on a snapshot of the store, and runs again when a concurrent write commits first,
+// so it must not have side effects outside tx.
+func (m *MemoryDocumentClient) WithTransaction(ctx context.Context, fn func(tx IDocumentTx) error) error {
+	if fn == nil {
+		return fmt.Errorf("transaction function cannot be nil")
+	}
+
+	for attempt := 0; attempt < memoryTransactionAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		m.mu.Lock()
+		version := m.version
+		tx := &memoryDocumentTx{session: newMemoryDocumentSession(m.state)}
+		m.mu.Unlock()
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		m.mu.Lock()
+		if m.version == version {
+			err := m.commit(tx.session)
+			m.mu.Unlock()
+			return err
+		}
+		m.mu.Unlock()
+	}
+
+	log.Printf("Unable to commit in-memory transaction after %d attempts", memoryTransactionAttempts)
+	return ErrTransactionConflict
+}
+
+// BulkWrite runs the write operations on the specified collection
+// An ordered bulk write stops at the first failed operation, an unordered one runs them all.
+func (m *MemoryDocumentClient) BulkWrite(ctx context.Context, databaseName, collectionName string, models []WriteModel, ordered bool) (*BulkWriteResult, error) {
+	if len(models) == 0 {
+		return nil, fmt.Errorf("no write operations to run")
+	}
+
+	for i, model := range models {
+		if err := model.validate(); err != nil {
+			return nil, fmt.Errorf("write operation %d: %w", i, err)
+		}
+	}
+
+	result := &BulkWriteResult{Operations: make([]WriteModelResult, len(models))}
+	failed := 0
+	err := m.execute(func(s *memoryDocumentSession) error {
+		for i, model := range models {
+			err := s.write(databaseName, collectionName, model, result, i)
+			result.Operations[i].Executed = true
+			if err == nil {
+				continue
+			}
+
+			failed++
+			writeError, ok := err.(*WriteError)
+			if !ok {
+				writeError = &WriteError{Code: badValueErrorCode, Message: err.Error()}
+			}
+			result.Operations[i].Err = writeError
+
+			if ordered {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if failed > 0 {
+		return result, fmt.Errorf("%w: %d of %d operations failed", ErrBulkWrite, failed, len(models))
+	}
+
+	return result, nil
+}
+
+// Create inserts a list of documents into the specified collection
+func (tx *memoryDocumentTx) Create(databaseName, collectionName string, documents []interface{}) (interface{}, error) {
+	return memoryDocumentRunner(tx.execute).create(databaseName, collectionName, documents)
+}
+
+// Read retrieves documents from the specified collection based on filter
+func (tx *memoryDocumentTx) Read(databaseName, collectionName string, filter interface{}, limit int64, dataModel reflect.Type) (interface{}, error) {
+	return memoryDocumentRunner(tx.execute).read(databaseName, collectionName, filter, limit, dataModel)
+}
+
+// Update modifies documents in the specified collection based on filter
+func (tx *memoryDocumentTx) Update(databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	return memoryDocumentRunner(tx.execute).update(databaseName, collectionName, filter, update, true, false)
+}
+
+// Delete removes documents from the specified collection based on filter
+func (tx *memoryDocumentTx) Delete(databaseName, collectionName string, filter interface{}) (interface{}, error) {
+	return memoryDocumentRunner(tx.execute).delete(databaseName, collectionName, filter, true)
+}
+
+// FindOne retrieves the first document matching filter
+func (tx *memoryDocumentTx) FindOne(databaseName, collectionName string, filter interface{}, dataModel reflect.Type) (interface{}, error) {
+	return memoryDocumentRunner(tx.execute).findOne(databaseName, collectionName, filter, dataModel)
+}
+
+// UpdateOne modifies the first document matching filter
+func (tx *memoryDocumentTx) UpdateOne(databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	return memoryDocumentRunner(tx.execute).update(databaseName, collectionName, filter, update, false, false)
+}
+
+// ReplaceOne replaces the first document matching filter
+func (tx *memoryDocumentTx) ReplaceOne(databaseName, collectionName string, filter, replacement interface{}) (interface{}, error) {
+	return memoryDocumentRunner(tx.execute).replace(databaseName, collectionName, filter, replacement, false)
+}
+
+// DeleteOne removes the first document matching filter
+func (tx *memoryDocumentTx) DeleteOne(databaseName, collectionName string, filter interface{}) (interface{}, error) {
+	return memoryDocumentRunner(tx.execute).delete(databaseName, collectionName, filter, false)
+}
+
+// Upsert modifies the first document matching filter, or inserts one
+func (tx *memoryDocumentTx) Upsert(databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	return memoryDocumentRunner(tx.execute).update(databaseName, collectionName, filter, update, false, true)
+}
+
+// FindOneAndUpdate atomically modifies the first document matching filter and returns it
+func (tx *memoryDocumentTx) FindOneAndUpdate(databaseName, collectionName string, filter, update interface{}, returnDocument ReturnDocument, dataModel reflect.Type) (interface{}, error) {
+	return memoryDocumentRunner(tx.execute).findOneAndUpdate(databaseName, collectionName, filter, update, returnDocument, dataModel)
+}
+
+// execute runs fn on the transaction snapshot
+func (tx *memoryDocumentTx) execute(fn func(s *memoryDocumentSession) error) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	return fn(tx.session)
+}
+
+// create inserts a list of documents into the specified collection
+func (run memoryDocumentRunner) create(databaseName, collectionName string, documents []interface{}) (interface{}, error) {
+	if len(documents) == 0 {
+		return nil, fmt.Errorf("no documents to insert")
+	}
+
+	result := &mongo.InsertManyResult{}
+	err := run(func(s *memoryDocumentSession) error {
+		for _, document := range documents {
+			id, err := s.insert(databaseName, collectionName, document)
+			if err != nil {
+				return err
+			}
+			result.InsertedIDs = append(result.InsertedIDs, id)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Unable to create documents in %s.%s: %v", databaseName, collectionName, err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// read retrieves documents from the specified collection based on filter, sorted by _id
+func (run memoryDocumentRunner) read(databaseName, collectionName string, filter interface{}, limit int64, dataModel reflect.Type) (interface{}, error) {
+	if dataModel == nil {
+		return nil, fmt.Errorf("data model cannot be nil")
+	}
+
+	if limit < 0 {
+		limit = -limit
+	}
+
+	var documents []bson.D
+	if err := run(func(s *memoryDocumentSession) error {
+		var err error
+		documents, err = s.findWithOptions(databaseName, collectionName, filter, &FindOptions{Limit: limit}, 0)
+		return err
+	}); err != nil {
+		log.Printf("Unable to read documents from %s.%s: %v", databaseName, collectionName, err)
+		return nil, err
+	}
+
+	return decodeDocuments(documents, dataModel)
+}
+
+// findOne retrieves the first document matching filter, sorted by _id
+func (run memoryDocumentRunner) findOne(databaseName, collectionName string, filter interface{}, dataModel reflect.Type) (interface{}, error) {
+	if filter == nil || dataModel == nil {
+		return nil, fmt.Errorf("filter and data model cannot be nil")
+	}
+
+	var documents []bson.D
+	if err := run(func(s *memoryDocumentSession) error {
+		var err error
+		documents, err = s.findWithOptions(databaseName, collectionName, filter, &FindOptions{Limit: 1}, 0)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	if len(documents) == 0 {
+		return nil, ErrDocumentNotFound
+	}
+
+	return fromDocument(documents[0], dataModel)
+}
+
+// update modifies the first or every document matching filter, inserting one on upsert
+func (run memoryDocumentRunner) update(databaseName, collectionName string, filter, update interface{}, many, upsert bool) (interface{}, error) {
+	if filter == nil || update == nil {
+		return nil, fmt.Errorf("filter and update cannot be nil")
+	}
+
+	var result *mongo.UpdateResult
+	if err := run(func(s *memoryDocumentSession) error {
+		var err error
+		result, _, err = s.update(databaseName, collectionName, filter, update, many, upsert)
+		return err
+	}); err != nil {
+		log.Printf("Unable to update documents in %s.%s: %v", databaseName, collectionName, err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// replace replaces the first document matching filter, inserting the replacement on upsert
+func (run memoryDocumentRunner) replace(databaseName, collectionName string, filter, replacement interface{}, upsert bool) (interface{}, error) {
+	if filter == nil || replacement == nil {
+		return nil, fmt.Errorf("filter and replacement cannot be nil")
+	}
+
+	var result *mongo.UpdateResult
+	if err := run(func(s *memoryDocumentSession) error {
+		var err error
+		result, err = s.replace(databaseName, collectionName, filter, replacement, upsert)
+		return err
+	}); err != nil {
+		log.Printf("Unable to replace document in %s.%s: %v", databaseName, collectionName, err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// delete removes the first or every document matching filter
+func (run memoryDocumentRunner) delete(databaseName, collectionName string, filter interface{}, many bool) (interface{}, error) {
+	if filter == nil {
+		return nil, fmt.Errorf("filter cannot be nil")
+	}
+
+	var result *mongo.DeleteResult
+	if err := run(func(s *memoryDocumentSession) error {
+		var err error
+		result, err = s.remove(databaseName, collectionName, filter, many)
+		return err
+	}); err != nil {
+		log.Printf("Unable to delete documents from %s.%s: %v", databaseName, collectionName, err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// findOneAndUpdate modifies the first document matching filter, sorted by _id, and returns it
+func (run memoryDocumentRunner) findOneAndUpdate(databaseName, collectionName string, filter, update interface{}, returnDocument ReturnDocument, dataModel reflect.Type) (interface{}, error) {
+	if filter == nil || update == nil || dataModel == nil {
+		return nil, fmt.Errorf("filter, update and data model cannot be nil")
+	}
+
+	var before, after bson.D
+	if err := run(func(s *memoryDocumentSession) error {
+		documents, err := s.findWithOptions(databaseName, collectionName, filter, &FindOptions{Limit: 1}, 0)
+		if err != nil || len(documents) == 0 {
+			return err
+		}
+		before = documents[0]
+
+		id, _ := documentID(before)
+		_, updated, err := s.update(databaseName, collectionName, bson.D{{Key: "_id", Value: id}}, update, false, false)
+		if len(updated) > 0 {
+			after = updated[0]
+		}
+		return err
+	}); err != nil {
+		log.Printf("Unable to find and update document in %s.%s: %v", databaseName, collectionName, err)
+		return nil, err
+	}
+
+	if before == nil {
+		return nil, ErrDocumentNotFound
+	}
+
+	if returnDocument == ReturnAfter && after != nil {
+		return fromDocument(after, dataModel)
+	}
+
+	return fromDocument(before, dataModel)
+}
+
+// newMemoryDocumentSession returns a session on a snapshot of the state
+func newMemoryDocumentSession(state memoryDocumentState) *memoryDocumentSession {
+	snapshot := make(memoryDocumentState, len(state))
+	for namespace, collection := range state {
+		shared := *collection
+		shared.private = false
+		snapshot[namespace] = &shared
+	}
+
+	return &memoryDocumentSession{state: snapshot, modified: make(map[string]bool)}
+}
+
+// namespace returns the state key of a collection
+func namespace(databaseName, collectionName string) string {
+	return databaseName + "." + collectionName
+}
+
+// collection returns the collection, creating it when create is set, after removing its expired documents
+func (s *memoryDocumentSession) collection(databaseName, collectionName string, create bool) (*memoryCollection, error) {
+	if databaseName == "" || strings.ContainsAny(databaseName, "/\\. \"$") {
+		return nil, fmt.Errorf("invalid database name [%s]", databaseName)
+	}
+
+	if collectionName == "" || strings.HasPrefix(collectionName, "$") {
+		return nil, fmt.Errorf("invalid collection name [%s]", collectionName)
+	}
+
+	key := namespace(databaseName, collectionName)
+	collection := s.state[key]
+	if collection == nil {
+		if !create {
+			return nil, nil
+		}
+
+		collection = &memoryCollection{indexes: []IndexSpec{{Name: "_id_", Keys: []IndexKey{{Field: "_id"}}}}, private: true}
+		s.state[key] = collection
+		s.modified[key] = true
+	}
+
+	s.expire(databaseName, collectionName, collection)
+
+	return collection, nil
+}
+
+// modify marks the collection as modified by the session, copying it first when it is shared
+func (s *memoryDocumentSession) modify(databaseName, collectionName string, collection *memoryCollection) {
+	if !collection.private {
+		collection.documents = append([]bson.D(nil), collection.documents...)
+		collection.indexes = append([]IndexSpec(nil), collection.indexes...)
+		collection.private = true
+	}
+
+	collection.lookups = nil
+	s.modified[namespace(databaseName, collectionName)] = true
+}
+
+// expire removes the documents of the collection past the expiration of a TTL index
+func (s *memoryDocumentSession) expire(databaseName, collectionName string, collection *memoryCollection) {
+	now := time.Now()
+	for _, index := range collection.indexes {
+		if index.ExpireAfter <= 0 || len(index.Keys) != 1 {
+			continue
+		}
+
+		path := strings.Split(index.Keys[0].Field, ".")
+		var kept []bson.D
+		for i, document := range collection.documents {
+			value, _ := getValue(document, path)
+			if date, ok := value.(primitive.DateTime); ok && !date.Time().Add(index.ExpireAfter).After(now) {
+				if kept == nil {
+					kept = append(make([]bson.D, 0, len(collection.documents)), collection.documents[:i]...)
+				}
+				s.record(databaseName, collectionName, ChangeDelete, document, nil)
+				continue
+			}
+
+			if kept != nil {
+				kept = append(kept, document)
+			}
+		}
+
+		if kept != nil {
+			s.modify(databaseName, collectionName, collection)
+			collection.documents = kept
+		}
+	}
+}
+
+// record collects a change for the watchers
+func (s *memoryDocumentSession) record(databaseName, collectionName string, operation ChangeOperation, before, after bson.D) {
+	change := memoryChange{
+		databaseName:   databaseName,
+		collectionName: collectionName,
+		operation:      operation,
+		time:           time.Now(),
+		document:       after,
+	}
+
+	if after != nil {
+		change.id, _ = documentID(after)
+	} else {
+		change.id, _ = documentID(before)
+	}
+
+	if operation == ChangeUpdate {
+		change.updatedFields, change.removedFields = diffDocuments(before, after)
+	}
+
+	s.changes = append(s.changes, change)
+}
+
+// find returns the documents of the collection matching filter, in insertion order
+func (s *memoryDocumentSession) find(databaseName, collectionName string, filter interface{}) ([]bson.D, error) {
+	query, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	collection, err := s.collection(databaseName, collectionName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if collection == nil {
+		return []bson.D{}, nil
+	}
+
+	documents := collection.documents
+	if positions, ok := collection.lookup(query); ok {
+		documents = make([]bson.D, 0, len(positions))
+		for _, position := range positions {
+			documents = append(documents, collection.documents[position])
+		}
+	}
+
+	matched := []bson.D{}
+	for _, document := range documents {
+		match, err := matchFilter(document, query)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			matched = append(matched, document)
+		}
+	}
+
+	return matched, nil
+}
+
+// findWithOptions returns the documents matching filter, sorted, paginated and projected
+// extra documents are returned beyond the limit, to tell whether there is a next page.
+func (s *memoryDocumentSession) findWithOptions(databaseName, collectionName string, filter interface{}, findOptions *FindOptions, extra int64) ([]bson.D, error) {
+	query, err := findOptions.query(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := s.find(databaseName, collectionName, query)
+	if err != nil {
+		return nil, err
+	}
+
+	sortDocuments(documents, findOptions.sortFields())
+
+	if findOptions.Skip > 0 {
+		if findOptions.Skip >= int64(len(documents)) {
+			return []bson.D{}, nil
+		}
+		documents = documents[findOptions.Skip:]
+	}
+
+	if findOptions.Limit > 0 && findOptions.Limit+extra < int64(len(documents)) {
+		documents = documents[:findOptions.Limit+extra]
+	}
+
+	if projection := findOptions.projection(); projection != nil {
+		fields := make([]string, 0, len(projection))
+		for _, field := range projection {
+			fields = append(fields, field.Key)
+		}
+
+		projected := make([]bson.D, 0, len(documents))
+		for _, document := range documents {
+			projected = append(projected, projectDocument(document, fields))
+		}
+		documents = projected
+	}
+
+	return documents, nil
+}
+
+// insert adds the document to the collection and returns its _id, generated when missing
+func (s *memoryDocumentSession) insert(databaseName, collectionName string, value interface{}) (interface{}, error) {
+	document, err := toDocument(value)
+	if err != nil {
+		return nil, err
+	}
+
+	id, found := documentID(document)
+	if !found {
+		id = primitive.NewObjectID()
+		document = append(bson.D{{Key: "_id", Value: id}}, document...)
+	}
+
+	collection, err := s.collection(databaseName, collectionName, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := collection.checkUnique(namespace(databaseName, collectionName), document, -1); err != nil {
+		return nil, err
+	}
+
+	if err := collection.checkSchema(document); err != nil {
+		return nil, err
+	}
+
+	s.modify(databaseName, collectionName, collection)
+	collection.documents = append(collection.documents, document)
+	s.record(databaseName, collectionName, ChangeInsert, nil, document)
+
+	return id, nil
+}
+
+// update applies the update to the first or every document matching filter, inserting one on upsert
+// It returns the result and the updated documents.
+func (s *memoryDocumentSession) update(databaseName, collectionName string, filter, update interface{}, many, upsert bool) (*mongo.UpdateResult, []bson.D, error) {
+	query, err := toDocument(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updateDocument, err := toDocument(update)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	collection, err := s.collection(databaseName, collectionName, upsert)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := &mongo.UpdateResult{}
+	var updated []bson.D
+	if collection != nil {
+		for i, document := range collection.documents {
+			match, err := matchFilter(document, query)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !match {
+				continue
+			}
+
+			result.MatchedCount++
+			next, err := applyUpdate(document, updateDocument, false)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if compareValues(document, next) != 0 {
+				if err := collection.checkUnique(namespace(databaseName, collectionName), next, i); err != nil {
+					return nil, nil, err
+				}
+
+				if err := collection.checkSchema(next); err != nil {
+					return nil, nil, err
+				}
+
+				s.modify(databaseName, collectionName, collection)
+				collection.documents[i] = next
+				result.ModifiedCount++
+				s.record(databaseName, collectionName, ChangeUpdate, document, next)
+			}
+			updated = append(updated, next)
+
+			if !many {
+				break
+			}
+		}
+	}
+
+	if result.MatchedCount > 0 || !upsert {
+		return result, updated, nil
+	}
+
+	seed, err := upsertDocument(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	document, err := applyUpdate(seed, updateDocument, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if result.UpsertedID, err = s.insert(databaseName, collectionName, document); err != nil {
+		return nil, nil, err
+	}
+	result.UpsertedCount = 1
+
+	return result, updated, nil
+}
+
+// replace replaces the first document matching filter, inserting the replacement on upsert
+func (s *memoryDocumentSession) replace(databaseName, collectionName string, filter, replacement interface{}, upsert bool) (*mongo.UpdateResult, error) {
+	query, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := toDocument(replacement)
+	if err != nil {
+		return nil, err
+	}
+
+	if isUpdate, err := isUpdateDocument(document); err != nil || isUpdate {
+		return nil, fmt.Errorf("replacement document cannot contain update operators")
+	}
+
+	result := &mongo.UpdateResult{}
+	collection, err := s.collection(databaseName, collectionName, upsert)
+	if err != nil {
+		return nil, err
+	}
+
+	if collection != nil {
+		for i, current := range collection.documents {
+			match, err := matchFilter(current, query)
+			if err != nil {
+				return nil, err
+			}
+			if !match {
+				continue
+			}
+
+			result.MatchedCount = 1
+			id, _ := documentID(current)
+			next := bson.D{{Key: "_id", Value: id}}
+			for _, element := range document {
+				if element.Key != "_id" {
+					next = append(next, element)
+				} else if compareValues(element.Value, id) != 0 {
+					return nil, fmt.Errorf("the _id field cannot be modified")
+				}
+			}
+
+			if compareValues(current, next) != 0 {
+				if err := collection.checkUnique(namespace(databaseName, collectionName), next, i); err != nil {
+					return nil, err
+				}
+
+				if err := collection.checkSchema(next); err != nil {
+					return nil, err
+				}
+
+				s.modify(databaseName, collectionName, collection)
+				collection.documents[i] = next
+				result.ModifiedCount = 1
+				s.record(databaseName, collectionName, ChangeReplace, current, next)
+			}
+
+			return result, nil
+		}
+	}
+
+	if !upsert {
+		return result, nil
+	}
+
+	if _, found := documentID(document); !found {
+		if seed, err := upsertDocument(query); err == nil {
+			if id, found := documentID(seed); found {
+				document = append(bson.D{{Key: "_id", Value: id}}, document...)
+			}
+		}
+	}
+
+	if result.UpsertedID, err = s.insert(databaseName, collectionName, document); err != nil {
+		return nil, err
+	}
+	result.UpsertedCount = 1
+
+	return result, nil
+}
+
+// remove deletes the first or every document matching filter
+func (s *memoryDocumentSession) remove(databaseName, collectionName string, filter interface{}, many bool) (*mongo.DeleteResult, error) {
+	query, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &mongo.DeleteResult{}
+	collection, err := s.collection(databaseName, collectionName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if collection == nil {
+		return result, nil
+	}
+
+	kept := collection.documents[:0:0]
+	for _, document := range collection.documents {
+		if many || result.DeletedCount == 0 {
+			match, err := matchFilter(document, query)
+			if err != nil {
+				return nil, err
+			}
+
+			if match {
+				result.DeletedCount++
+				s.record(databaseName, collectionName, ChangeDelete, document, nil)
+				continue
+			}
+		}
+		kept = append(kept, document)
+	}
+
+	if result.DeletedCount > 0 {
+		s.modify(databaseName, collectionName, collection)
+		collection.documents = kept
+	}
+
+	return result, nil
+}
+
+// write runs a bulk write operation and adds its outcome to the result
+func (s *memoryDocumentSession) write(databaseName, collectionName string, model WriteModel, result *BulkWriteResult, index int) error {
+	switch model.Operation {
+	case WriteInsertOne:
+		if _, err := s.insert(databaseName, collectionName, model.Document); err != nil {
+			return err
+		}
+		result.InsertedCount++
+
+	case WriteUpdateOne, WriteUpdateMany, WriteReplaceOne:
+		var (
+			updateResult *mongo.UpdateResult
+			err          error
+		)
+		if model.Operation == WriteReplaceOne {
+			updateResult, err = s.replace(databaseName, collectionName, model.Filter, model.Document, model.Upsert)
+		} else {
+			updateResult, _, err = s.update(databaseName, collectionName, model.Filter, model.Update, model.Operation == WriteUpdateMany, model.Upsert)
+		}
+		if err != nil {
+			return err
+		}
+
+		result.MatchedCount += updateResult.MatchedCount
+		result.ModifiedCount += updateResult.ModifiedCount
+		result.UpsertedCount += updateResult.UpsertedCount
+		result.Operations[index].UpsertedID = updateResult.UpsertedID
+
+	case WriteDeleteOne, WriteDeleteMany:
+		deleteResult, err := s.remove(databaseName, collectionName, model.Filter, model.Operation == WriteDeleteMany)
+		if err != nil {
+			return err
+		}
+		result.DeletedCount += deleteResult.DeletedCount
+	}
+
+	return nil
+}
+
+// ensureIndexes records the indexes missing from the collection and returns their names
+func (s *memoryDocumentSession) ensureIndexes(databaseName, collectionName string, indexes []IndexSpec) ([]string, error) {
+	collection, err := s.collection(databaseName, collectionName, true)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		if err := index.validate(); err != nil {
+			return nil, err
+		}
+		index.Name = index.indexName()
+
+		exists := false
+		for _, existing := range collection.indexes {
+			if existing.Name != index.Name {
+				continue
+			}
+
+			if !reflect.DeepEqual(existing, index) {
+				return nil, fmt.Errorf("index %s already exists with different options", index.Name)
+			}
+			exists = true
+		}
+
+		if !exists {
+			if index.PartialFilter != nil {
+				partialFilter, err := toDocument(index.PartialFilter)
+				if err != nil {
+					return nil, err
+				}
+				index.PartialFilter = partialFilter
+			}
+
+			candidate := &memoryCollection{indexes: []IndexSpec{index}}
+			for _, document := range collection.documents {
+				if err := candidate.checkUnique(namespace(databaseName, collectionName), document, -1); err != nil {
+					return nil, err
+				}
+				candidate.documents = append(candidate.documents, document)
+			}
+
+			s.modify(databaseName, collectionName, collection)
+			collection.indexes = append(collection.indexes, index)
+		}
+		names = append(names, index.Name)
+	}
+
+	return names, nil
+}
+
+// dropIndex removes the named index from the collection
+func (s *memoryDocumentSession) dropIndex(databaseName, collectionName, indexName string) error {
+	if indexName == "" {
+		return fmt.Errorf("index name cannot be empty")
+	}
+
+	if indexName == "_id_" {
+		return fmt.Errorf("cannot drop _id index")
+	}
+
+	collection, err := s.collection(databaseName, collectionName, false)
+	if err != nil {
+		return err
+	}
+
+	if collection != nil {
+		for i, index := range collection.indexes {
+			if index.Name == indexName {
+				s.modify(databaseName, collectionName, collection)
+				collection.indexes = append(collection.indexes[:i:i], collection.indexes[i+1:]...)
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("index not found with name [%s]", indexName)
+}
+
+// setValidator sets the $jsonSchema validator of the collection, nil removing it
+func (s *memoryDocumentSession) setValidator(databaseName, collectionName string, schema interface{}) error {
+	var (
+		validator bson.D
+		compiled  *documentSchema
+	)
+	if schema != nil {
+		var err error
+		if validator, err = toDocument(schema); err != nil {
+			return err
+		}
+
+		if compiled, err = compileSchemaDocument(validator, "$jsonSchema"); err != nil {
+			return err
+		}
+	}
+
+	collection, err := s.collection(databaseName, collectionName, true)
+	if err != nil {
+		return err
+	}
+
+	s.modify(databaseName, collectionName, collection)
+	collection.validator = validator
+	collection.schema = compiled
+
+	return nil
+}
+
+// checkSchema returns a document validation error when the document breaks the validator of the collection
+func (c *memoryCollection) checkSchema(document bson.D) error {
+	if c.schema == nil {
+		return nil
+	}
+
+	if err := newSchemaValidationError(c.schema.validate(document, "", nil)); err != nil {
+		return &WriteError{Code: documentValidationErrorCode, Message: err.Error()}
+	}
+
+	return nil
+}
+
+// checkUnique returns a duplicate key error when the document breaks a unique index
+// The document at position skip, the one being replaced, is not compared.
+func (c *memoryCollection) checkUnique(namespace string, document bson.D, skip int) error {
+	for _, index := range c.indexes {
+		if !index.Unique && index.Name != "_id_" {
+			continue
+		}
+
+		key, indexed := index.key(document)
+		if !indexed {
+			continue
+		}
+
+		for i, other := range c.documents {
+			if i == skip {
+				continue
+			}
+
+			if otherKey, otherIndexed := index.key(other); otherIndexed && compareValues(key, otherKey) == 0 {
+				return &WriteError{
+					Code:    duplicateKeyErrorCode,
+					Message: fmt.Sprintf("E11000 duplicate key error collection: %s index: %s dup key: %v", namespace, index.Name, key),
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// lookup returns the positions, in order, of the documents that can match the query
+// It uses the first equality condition on the leading field of an index, and returns false when there is none.
+func (c *memoryCollection) lookup(query bson.D) ([]int, bool) {
+	for _, element := range query {
+		if strings.HasPrefix(element.Key, "$") || !c.indexed(element.Key) {
+			continue
+		}
+
+		targets, ok := equalityTargets(element.Value)
+		if !ok {
+			continue
+		}
+
+		lookups := c.fieldLookups(element.Key)
+		seen := make(map[int]bool)
+		positions := []int{}
+		for _, target := range targets {
+			for _, position := range lookups[lookupKey(target)] {
+				if !seen[position] {
+					seen[position] = true
+					positions = append(positions, position)
+				}
+			}
+		}
+		sort.Ints(positions)
+
+		return positions, true
+	}
+
+	return nil, false
+}
+
+// indexed reports whether the field leads an index of the collection
+func (c *memoryCollection) indexed(field string) bool {
+	for _, index := range c.indexes {
+		if len(index.Keys) > 0 && index.Keys[0].Field == field && !index.Keys[0].Text {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fieldLookups returns the positions of the documents by value of the field, building them on first use
+// Built lookups are never modified, since collections copied by sessions share them.
+func (c *memoryCollection) fieldLookups(field string) map[string][]int {
+	if lookups, ok := c.lookups[field]; ok {
+		return lookups
+	}
+
+	lookups := make(map[string][]int)
+	path := strings.Split(field, ".")
+	for position, document := range c.documents {
+		values, found := lookupValues(document, path)
+		keys := make(map[string]bool)
+		if !found {
+			// A null condition matches missing fields
+			keys[lookupKey(nil)] = true
+		}
+		for _, value := range candidates(values) {
+			keys[lookupKey(value)] = true
+		}
+
+		for key := range keys {
+			lookups[key] = append(lookups[key], position)
+		}
+	}
+
+	next := make(map[string]map[string][]int, len(c.lookups)+1)
+	for name, existing := range c.lookups {
+		next[name] = existing
+	}
+	next[field] = lookups
+	c.lookups = next
+
+	return lookups
+}
+
+// equalityTargets returns the values a condition requires the field to equal, and false for other conditions
+func equalityTargets(condition interface{}) ([]interface{}, bool) {
+	operators, isOperator := isOperatorDocument(condition)
+	if !isOperator {
+		if _, isPattern := condition.(primitive.Regex); isPattern {
+			return nil, false
+		}
+		return []interface{}{condition}, true
+	}
+
+	for _, operator := range operators {
+		var targets []interface{}
+		switch operator.Key {
+		case "$eq":
+			targets = []interface{}{operator.Value}
+		case "$in":
+			values, ok := operator.Value.(bson.A)
+			if !ok {
+				continue
+			}
+			targets = values
+		default:
+			continue
+		}
+
+		for _, target := range targets {
+			if _, isPattern := target.(primitive.Regex); isPattern {
+				return nil, false
+			}
+		}
+		return targets, true
+	}
+
+	return nil, false
+}
+
+// lookupKey returns the lookup key of a value, equal values having the same key
+// Documents and arrays share a key by type, the conditions on them are checked on each candidate.
+func lookupKey(value interface{}) string {
+	if isNumber(value) {
+		return "number:" + strconv.FormatFloat(toFloat64(value), 'g', -1, 64)
+	}
+
+	switch value.(type) {
+	case bson.D, bson.A:
+		return strconv.Itoa(typeRank(value))
+	}
+
+	return fmt.Sprintf("%d:%v", typeRank(value), value)
+}
+
+// key returns the values of the indexed fields and whether the document is indexed
+// Documents missing every field of a sparse index, or not matching its partial filter, are not indexed.
+func (i IndexSpec) key(document bson.D) (bson.A, bool) {
+	if partialFilter, ok := i.PartialFilter.(bson.D); ok {
+		if match, err := matchFilter(document, partialFilter); err != nil || !match {
+			return nil, false
+		}
+	}
+
+	key := make(bson.A, 0, len(i.Keys))
+	found := false
+	for _, indexKey := range i.Keys {
+		value, exists := getValue(document, strings.Split(indexKey.Field, "."))
+		found = found || exists
+		key = append(key, value)
+	}
+
+	if i.Sparse && !found {
+		return nil, false
+	}
+
+	return key, true
+}
+
+// diffDocuments returns the top-level fields updated and removed between two versions of a document
+func diffDocuments(before, after bson.D) (map[string]interface{}, []string) {
+	updated := make(map[string]interface{})
+	for _, element := range after {
+		previous, found := getValue(before, []string{element.Key})
+		if !found || typeRank(previous) != typeRank(element.Value) || compareValues(previous, element.Value) != 0 {
+			updated[element.Key] = element.Value
+		}
+	}
+
+	var removed []string
+	for _, element := range before {
+		if _, found := getValue(after, []string{element.Key}); !found {
+			removed = append(removed, element.Key)
+		}
+	}
+	sort.Strings(removed)
+
+	return updated, removed
+}
+
+// decodeDocuments decodes the documents into a pointer to a slice of dataModel
+func decodeDocuments(documents []bson.D, dataModel reflect.Type) (interface{}, error) {
+	items := reflect.New(reflect.SliceOf(dataModel))
+	slice := reflect.MakeSlice(reflect.SliceOf(dataModel), 0, len(documents))
+	for _, document := range documents {
+		item, err := fromDocument(document, dataModel)
+		if err != nil {
+			log.Printf("Unable to decode document: %v", err)
+			return nil, err
+		}
+		slice = reflect.Append(slice, reflect.ValueOf(item).Elem())
+	}
+	items.Elem().Set(slice)
+
+	return items.Interface(), nil
+}
+
+// Next moves to the next document and reports whether there is one
+func (c *memoryDocumentCursor) Next(ctx context.Context) bool {
+	if c.err != nil {
+		return false
+	}
+
+	if err := ctx.Err(); err != nil {
+		c.err = err
+		return false
+	}
+
+	c.position++
+	return c.position < len(c.documents)
+}
+
+// Decode decodes the current document into value, a pointer
+func (c *memoryDocumentCursor) Decode(value interface{}) error {
+	if c.position < 0 || c.position >= len(c.documents) {
+		return fmt.Errorf("cursor has no current document")
+	}
+
+	data, err := bson.Marshal(c.documents[c.position])
+	if err != nil {
+		return err
+	}
+
+	return bson.Unmarshal(data, value)
+}
+
+// Err returns the error that stopped the iteration, if any
+func (c *memoryDocumentCursor) Err() error {
+	return c.err
+}
+
+// Close releases the cursor
+func (c *memoryDocumentCursor) Close(ctx context.Context) error {
+	c.documents = nil
+	return nil
+}
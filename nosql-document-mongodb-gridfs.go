@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// contextReader stops reading once its context is done
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+// contextWriter stops writing once its context is done
+type contextWriter struct {
+	ctx    context.Context
+	writer io.Writer
+}
+
+// Read implements io.Reader
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return r.reader.Read(p)
+}
+
+// Write implements io.Writer
+func (w *contextWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return w.writer.Write(p)
+}
+
+// UploadFile streams content into a new GridFS file of the bucket and returns its _id
+// An empty bucketName selects the default "fs" bucket, metadata can be nil.
+func (m *MongoClient) UploadFile(ctx context.Context, databaseName, bucketName, fileName string, content io.Reader, metadata interface{}) (primitive.ObjectID, error) {
+	if content == nil {
+		return primitive.NilObjectID, fmt.Errorf("file content cannot be nil")
+	}
+
+	bucket, err := m.bucket(ctx, databaseName, bucketName)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	uploadOptions := options.GridFSUpload()
+	if metadata != nil {
+		uploadOptions.SetMetadata(metadata)
+	}
+
+	id, err := bucket.UploadFromStream(fileName, &contextReader{ctx: ctx, reader: content}, uploadOptions)
+	if err != nil {
+		log.Printf("Unable to upload file %s to %s.%s: %v", fileName, databaseName, bucketName, err)
+		return primitive.NilObjectID, err
+	}
+
+	return id, nil
+}
+
+// DownloadFile streams the content of the GridFS file into destination and returns its size
+// It returns gridfs.ErrFileNotFound when the bucket has no file with this _id.
+func (m *MongoClient) DownloadFile(ctx context.Context, databaseName, bucketName string, fileID interface{}, destination io.Writer) (int64, error) {
+	if destination == nil {
+		return 0, fmt.Errorf("destination cannot be nil")
+	}
+
+	bucket, err := m.bucket(ctx, databaseName, bucketName)
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := bucket.DownloadToStream(fileID, &contextWriter{ctx: ctx, writer: destination})
+	if err != nil {
+		log.Printf("Unable to download file %v from %s.%s: %v", fileID, databaseName, bucketName, err)
+		return size, err
+	}
+
+	return size, nil
+}
+
+// OpenFile returns a reader of the content of the GridFS file, which the caller must close
+// The deadline of ctx applies to the whole read. It returns gridfs.ErrFileNotFound when the
+// bucket has no file with this _id.
+func (m *MongoClient) OpenFile(ctx context.Context, databaseName, bucketName string, fileID interface{}) (*gridfs.DownloadStream, error) {
+	bucket, err := m.bucket(ctx, databaseName, bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := bucket.OpenDownloadStream(fileID)
+	if err != nil {
+		log.Printf("Unable to open file %v from %s.%s: %v", fileID, databaseName, bucketName, err)
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := stream.SetReadDeadline(deadline); err != nil {
+			stream.Close()
+			return nil, err
+		}
+	}
+
+	return stream, nil
+}
+
+// DeleteFile removes the GridFS file and its content
+// It returns gridfs.ErrFileNotFound when the bucket has no file with this _id.
+func (m *MongoClient) DeleteFile(ctx context.Context, databaseName, bucketName string, fileID interface{}) error {
+	bucket, err := m.bucket(ctx, databaseName, bucketName)
+	if err != nil {
+		return err
+	}
+
+	if err := bucket.Delete(fileID); err != nil {
+		log.Printf("Unable to delete file %v from %s.%s: %v", fileID, databaseName, bucketName, err)
+		return err
+	}
+
+	return nil
+}
+
+// ListFiles returns the GridFS files of the bucket matching filter, sorted by _id
+// The filter applies to the file documents, e.g. {"filename": name} or {"metadata.owner": id}.
+// A nil filter matches every file, and a limit of 0 returns them all.
+func (m *MongoClient) ListFiles(ctx context.Context, databaseName, bucketName string, filter interface{}, limit int64) ([]gridfs.File, error) {
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	bucket, err := m.bucket(ctx, databaseName, bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	findOptions := options.GridFSFind().SetSort(bson.D{{Key: "_id", Value: 1}})
+	if limit > 0 {
+		findOptions.SetLimit(int32(limit))
+	}
+
+	cursor, err := bucket.Find(filter, findOptions)
+	if err != nil {
+		log.Printf("Unable to list files of %s.%s: %v", databaseName, bucketName, err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	files := []gridfs.File{}
+	if err := cursor.All(ctx, &files); err != nil {
+		log.Printf("Unable to decode files of %s.%s: %v", databaseName, bucketName, err)
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// bucket returns the GridFS bucket, bounded by the deadline of ctx
+func (m *MongoClient) bucket(ctx context.Context, databaseName, bucketName string) (*gridfs.Bucket, error) {
+	if m.Client == nil {
+		return nil, fmt.Errorf("MongoDB client is not initialized")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	bucketOptions := options.GridFSBucket()
+	if bucketName != "" {
+		bucketOptions.SetName(bucketName)
+	}
+
+	bucket, err := gridfs.NewBucket(m.Client.Database(databaseName), bucketOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := bucket.SetWriteDeadline(deadline); err != nil {
+			return nil, err
+		}
+		if err := bucket.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	return bucket, nil
+}
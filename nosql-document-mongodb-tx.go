@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// mongoDocumentTx runs the document operations in the session context of a transaction
+type mongoDocumentTx struct {
+	client *MongoClient
+	ctx    mongo.SessionContext
+}
+
+// WithTransaction runs fn in a transaction, committed when fn returns nil and aborted otherwise
+// The whole transaction is retried on transient transaction errors and the commit on unknown
+// commit results, so fn may run several times and must not have side effects outside tx.
+// Transactions require a replica set or a sharded cluster.
+func (m *MongoClient) WithTransaction(ctx context.Context, fn func(tx IDocumentTx) error) error {
+	if m.Client == nil {
+		return fmt.Errorf("MongoDB client is not initialized")
+	}
+
+	if fn == nil {
+		return fmt.Errorf("transaction function cannot be nil")
+	}
+
+	session, err := m.Client.StartSession()
+	if err != nil {
+		log.Printf("Unable to init new MongoDB session: %v", err)
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	if _, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(&mongoDocumentTx{client: m, ctx: sc})
+	}); err != nil {
+		log.Printf("Unable to execute MongoDB transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// Create inserts a list of documents into the specified collection
+func (tx *mongoDocumentTx) Create(databaseName, collectionName string, documents []interface{}) (interface{}, error) {
+	return tx.client.create(tx.ctx, databaseName, collectionName, documents)
+}
+
+// Read retrieves documents from the specified collection based on filter
+func (tx *mongoDocumentTx) Read(databaseName, collectionName string, filter interface{}, limit int64, dataModel reflect.Type) (interface{}, error) {
+	return tx.client.read(tx.ctx, databaseName, collectionName, filter, limit, dataModel)
+}
+
+// Update modifies documents in the specified collection based on filter
+func (tx *mongoDocumentTx) Update(databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	return tx.client.update(tx.ctx, databaseName, collectionName, filter, update)
+}
+
+// Delete removes documents from the specified collection based on filter
+func (tx *mongoDocumentTx) Delete(databaseName, collectionName string, filter interface{}) (interface{}, error) {
+	return tx.client.delete(tx.ctx, databaseName, collectionName, filter)
+}
+
+// FindOne retrieves the first document matching filter
+func (tx *mongoDocumentTx) FindOne(databaseName, collectionName string, filter interface{}, dataModel reflect.Type) (interface{}, error) {
+	return tx.client.FindOne(tx.ctx, databaseName, collectionName, filter, dataModel)
+}
+
+// UpdateOne modifies the first document matching filter
+func (tx *mongoDocumentTx) UpdateOne(databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	return tx.client.UpdateOne(tx.ctx, databaseName, collectionName, filter, update)
+}
+
+// ReplaceOne replaces the first document matching filter
+func (tx *mongoDocumentTx) ReplaceOne(databaseName, collectionName string, filter, replacement interface{}) (interface{}, error) {
+	return tx.client.ReplaceOne(tx.ctx, databaseName, collectionName, filter, replacement)
+}
+
+// DeleteOne removes the first document matching filter
+func (tx *mongoDocumentTx) DeleteOne(databaseName, collectionName string, filter interface{}) (interface{}, error) {
+	return tx.client.DeleteOne(tx.ctx, databaseName, collectionName, filter)
+}
+
+// Upsert modifies the first document matching filter, or inserts one
+func (tx *mongoDocumentTx) Upsert(databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	return tx.client.Upsert(tx.ctx, databaseName, collectionName, filter, update)
+}
+
+// FindOneAndUpdate atomically modifies the first document matching filter and returns it
+func (tx *mongoDocumentTx) FindOneAndUpdate(databaseName, collectionName string, filter, update interface{}, returnDocument ReturnDocument, dataModel reflect.Type) (interface{}, error) {
+	return tx.client.FindOneAndUpdate(tx.ctx, databaseName, collectionName, filter, update, returnDocument, dataModel)
+}
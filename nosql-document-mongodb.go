@@ -3,9 +3,12 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
@@ -30,6 +33,9 @@ var (
 	mongoClientSessionMapping = make(map[string]*MongoClient)
 )
 
+// namespaceNotFoundErrorCode is the mongo error code of commands on a missing collection
+const namespaceNotFoundErrorCode = 26
+
 // newMongoDB init new instance
 func newMongoDB(config *MongoDB) INoSQLDocument {
 	hasher := &hash.Client{}
@@ -43,9 +49,14 @@ func newMongoDB(config *MongoDB) INoSQLDocument {
 	if currentMongoSession == nil {
 		currentMongoSession = &MongoClient{nil, nil, nil}
 
+		uri, err := config.ConnectionURI()
+		if err != nil {
+			log.Fatalln("Unable to build MongoDB connection URI: ", err)
+		}
+
 		// Establish MongoDB connection
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		client, err := mongo.Connect(ctx, options.Client().ApplyURI(getConnectionURI(config)))
+		client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
 		if err != nil {
 			cancel()
 			log.Fatalln("Unable to connect to MongoDB: ", err)
@@ -67,194 +78,971 @@ func newMongoDB(config *MongoDB) INoSQLDocument {
 	return currentMongoSession
 }
 
-// getConnectionURI returns mongo connection URI
-// It properly formats the connection string based on authentication requirements
-func getConnectionURI(config *MongoDB) string {
-	if config == nil {
-		log.Println("Warning: MongoDB config is nil")
-		return ""
-	}
-	
-	host := strings.Join(config.Hosts, ",")
-	opt := strings.Join(config.Options, "&")
-	
-	// Handle connection without authentication
-	if config.User == "" && config.Password == "" {
-		if opt != "" {
-			return fmt.Sprintf("mongodb://%v?%v", host, opt)
-		}
-		return fmt.Sprintf("mongodb://%v", host)
-	}
-	
-	// Connection with authentication
-	if opt != "" {
-		return fmt.Sprintf("mongodb+srv://%v:%v@%v/%v?%v", 
-			config.User, 
-			config.Password, 
-			host, 
-			config.DB, 
-			opt)
-	}
-	
-	return fmt.Sprintf("mongodb+srv://%v:%v@%v/%v", 
-		config.User, 
-		config.Password, 
-		host, 
-		config.DB)
-}
-
-// createSession returns a new mongo session & transaction
-// It handles session creation and transaction initialization
-func (m *MongoClient) createSession() (mongo.Session, error) {
+// ConnectionURI returns the mongo connection string of the config
+// User and Password are escaped. The standard scheme lists the hosts with their optional port,
+// while SRV takes a single host name without port.
+func (config *MongoDB) ConnectionURI() (string, error) {
+	if config.URI != "" {
+		return config.URI, nil
+	}
+
+	if len(config.Hosts) == 0 {
+		return "", fmt.Errorf("MongoDB hosts cannot be empty")
+	}
+
+	scheme := "mongodb"
+	if config.SRV {
+		if len(config.Hosts) != 1 || strings.Contains(config.Hosts[0], ":") {
+			return "", fmt.Errorf("MongoDB SRV connection takes a single host name without port, got %v", config.Hosts)
+		}
+		scheme = "mongodb+srv"
+	}
+
+	credentials := ""
+	if config.User != "" {
+		credentials = url.QueryEscape(config.User)
+		if config.Password != "" {
+			credentials += ":" + url.QueryEscape(config.Password)
+		}
+		credentials += "@"
+	} else if config.Password != "" {
+		return "", fmt.Errorf("MongoDB password is set without user")
+	}
+
+	var parameters []string
+	addParameter := func(key, value string) {
+		if value != "" {
+			parameters = append(parameters, key+"="+url.QueryEscape(value))
+		}
+	}
+
+	addParameter("authSource", config.AuthSource)
+	addParameter("replicaSet", config.ReplicaSet)
+	addParameter("readPreference", config.ReadPreference)
+	addParameter("w", config.WriteConcern)
+	if config.TLS.Enable {
+		addParameter("tls", "true")
+		addParameter("tlsCAFile", config.TLS.CAFile)
+		addParameter("tlsCertificateFile", config.TLS.CertFile)
+		addParameter("tlsPrivateKeyFile", config.TLS.KeyFile)
+		if config.TLS.InsecureSkipVerify {
+			addParameter("tlsInsecure", "true")
+		}
+	}
+	if config.MaxPoolSize > 0 {
+		addParameter("maxPoolSize", strconv.Itoa(config.MaxPoolSize))
+	}
+	if config.MinPoolSize > 0 {
+		addParameter("minPoolSize", strconv.Itoa(config.MinPoolSize))
+	}
+	if config.ConnectTimeout > 0 {
+		addParameter("connectTimeoutMS", strconv.FormatInt(config.ConnectTimeout.Milliseconds(), 10))
+	}
+	if config.ServerSelectionTimeout > 0 {
+		addParameter("serverSelectionTimeoutMS", strconv.FormatInt(config.ServerSelectionTimeout.Milliseconds(), 10))
+	}
+	parameters = append(parameters, config.Options...)
+
+	uri := fmt.Sprintf("%s://%s%s/%s", scheme, credentials, strings.Join(config.Hosts, ","), config.DB)
+	if len(parameters) > 0 {
+		uri += "?" + strings.Join(parameters, "&")
+	}
+
+	return uri, nil
+}
+
+// Create inserts a list of documents into the specified collection
+func (m *MongoClient) Create(databaseName, collectionName string, documents []interface{}) (interface{}, error) {
+	return m.create(ctx, databaseName, collectionName, documents)
+}
+
+// create inserts a list of documents into the specified collection
+func (m *MongoClient) create(ctx context.Context, databaseName, collectionName string, documents []interface{}) (interface{}, error) {
 	if m.Client == nil {
 		return nil, fmt.Errorf("MongoDB client is not initialized")
 	}
-	
-	session, err := m.Client.StartSession()
+
+	if len(documents) == 0 {
+		return nil, fmt.Errorf("no documents to insert")
+	}
+
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	result, err := collection.InsertMany(ctx, documents)
 	if err != nil {
-		log.Printf("Unable to init new MongoDB session: %v", err)
+		log.Printf("Unable to create documents in %s.%s: %v", databaseName, collectionName, err)
 		return nil, err
 	}
 
-	if err := session.StartTransaction(); err != nil {
-		log.Printf("Unable to start MongoDB transaction: %v", err)
-		session.EndSession(ctx)
+	return result, nil
+}
+
+// Read retrieves documents from the specified collection based on filter
+func (m *MongoClient) Read(databaseName, collectionName string, filter interface{}, limit int64, dataModel reflect.Type) (interface{}, error) {
+	return m.read(ctx, databaseName, collectionName, filter, limit, dataModel)
+}
+
+// read retrieves documents from the specified collection based on filter
+func (m *MongoClient) read(ctx context.Context, databaseName, collectionName string, filter interface{}, limit int64, dataModel reflect.Type) (interface{}, error) {
+	if m.Client == nil {
+		return nil, fmt.Errorf("MongoDB client is not initialized")
+	}
+
+	if dataModel == nil {
+		return nil, fmt.Errorf("data model cannot be nil")
+	}
+
+	findOptions := options.Find()
+	findOptions.SetLimit(limit)
+	findOptions.SetSort(bson.D{primitive.E{Key: "_id", Value: 1}})
+
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	cur, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		log.Printf("Unable to read documents from %s.%s: %v", databaseName, collectionName, err)
 		return nil, err
 	}
+	defer cur.Close(ctx)
 
-	return session, nil
+	// Decode cursor
+	sliceType := reflect.Zero(reflect.SliceOf(dataModel)).Type()
+	results := reflect.New(sliceType).Interface()
+	if err := cur.All(ctx, results); err != nil {
+		log.Printf("Unable to decode cursor: %v", err)
+		return nil, err
+	}
+
+	return results, nil
 }
 
-// Create inserts a list of documents into the specified collection
-func (m *MongoClient) Create(databaseName, collectionName string, documents []interface{}) (interface{}, error) {
-	if len(documents) == 0 {
-		return nil, fmt.Errorf("no documents to insert")
+// Update modifies documents in the specified collection based on filter
+func (m *MongoClient) Update(databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	return m.update(ctx, databaseName, collectionName, filter, update)
+}
+
+// update modifies documents in the specified collection based on filter
+func (m *MongoClient) update(ctx context.Context, databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	if m.Client == nil {
+		return nil, fmt.Errorf("MongoDB client is not initialized")
+	}
+
+	if filter == nil || update == nil {
+		return nil, fmt.Errorf("filter and update cannot be nil")
 	}
 
-	var result interface{}
-	session, err := m.createSession()
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	result, err := collection.UpdateMany(ctx, filter, update)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create MongoDB session: %w", err)
+		log.Printf("Unable to update documents in %s.%s: %v", databaseName, collectionName, err)
+		return nil, err
 	}
-	defer session.EndSession(ctx)
 
-	if err := mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
-		collection := m.Client.Database(databaseName).Collection(collectionName)
-		result, err = collection.InsertMany(ctx, documents)
-		if err != nil {
-			log.Printf("Unable to create documents in %s.%s: %v", databaseName, collectionName, err)
-			return err
-		}
+	return result, nil
+}
 
-		return nil
-	}); err != nil {
-		log.Printf("Unable to execute mongo session for Create: %v", err)
+// Delete removes documents from the specified collection based on filter
+func (m *MongoClient) Delete(databaseName, collectionName string, filter interface{}) (interface{}, error) {
+	return m.delete(ctx, databaseName, collectionName, filter)
+}
+
+// delete removes documents from the specified collection based on filter
+func (m *MongoClient) delete(ctx context.Context, databaseName, collectionName string, filter interface{}) (interface{}, error) {
+	if m.Client == nil {
+		return nil, fmt.Errorf("MongoDB client is not initialized")
+	}
+
+	if filter == nil {
+		return nil, fmt.Errorf("filter cannot be nil")
+	}
+
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	result, err := collection.DeleteMany(ctx, filter)
+	if err != nil {
+		log.Printf("Unable to delete documents from %s.%s: %v", databaseName, collectionName, err)
 		return nil, err
 	}
 
 	return result, nil
 }
 
-// Read retrieves documents from the specified collection based on filter
-func (m *MongoClient) Read(databaseName, collectionName string, filter interface{}, limit int64, dataModel reflect.Type) (interface{}, error) {
+// ReadPage retrieves a page of documents from the specified collection based on filter
+// Documents are sorted by the sort keys then _id, and NextCursor resumes the read after the last one.
+func (m *MongoClient) ReadPage(ctx context.Context, databaseName, collectionName string, filter interface{}, findOptions *FindOptions, dataModel reflect.Type) (*Page, error) {
+	if m.Client == nil {
+		return nil, fmt.Errorf("MongoDB client is not initialized")
+	}
+
 	if dataModel == nil {
 		return nil, fmt.Errorf("data model cannot be nil")
 	}
 
-	var results interface{}
-	session, err := m.createSession()
+	if findOptions == nil {
+		findOptions = &FindOptions{}
+	}
+
+	query, err := findOptions.query(filter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create MongoDB session: %w", err)
+		return nil, err
 	}
-	defer session.EndSession(ctx)
 
-	if err := mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
-		findOptions := options.Find()
-		findOptions.SetLimit(limit)
-		findOptions.SetSort(bson.D{primitive.E{Key: "_id", Value: 1}})
+	opts := options.Find()
+	opts.SetSort(findOptions.sortDocument())
+	if findOptions.Skip > 0 {
+		opts.SetSkip(findOptions.Skip)
+	}
+	if findOptions.Limit > 0 {
+		// One extra document tells whether there is a next page
+		opts.SetLimit(findOptions.Limit + 1)
+	}
+	if projection := findOptions.projection(); projection != nil {
+		opts.SetProjection(projection)
+	}
 
-		collection := m.Client.Database(databaseName).Collection(collectionName)
-		cur, err := collection.Find(ctx, filter, findOptions)
-		if err != nil {
-			log.Printf("Unable to read documents from %s.%s: %v", databaseName, collectionName, err)
-			return err
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	cur, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		log.Printf("Unable to read documents from %s.%s: %v", databaseName, collectionName, err)
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	items := reflect.New(reflect.SliceOf(dataModel))
+	slice := reflect.MakeSlice(reflect.SliceOf(dataModel), 0, 0)
+	var last bson.Raw
+	hasMore := false
+	for cur.Next(ctx) {
+		if findOptions.Limit > 0 && int64(slice.Len()) == findOptions.Limit {
+			hasMore = true
+			break
 		}
-		defer cur.Close(ctx)
 
-		// Decode cursor
-		sliceType := reflect.Zero(reflect.SliceOf(dataModel)).Type()
-		results = reflect.New(sliceType).Interface()
-		err = cur.All(ctx, results)
-		if err != nil {
+		item := reflect.New(dataModel)
+		if err := cur.Decode(item.Interface()); err != nil {
 			log.Printf("Unable to decode cursor: %v", err)
-			return err
+			return nil, err
+		}
+		slice = reflect.Append(slice, item.Elem())
+		last = append(bson.Raw(nil), cur.Current...)
+	}
+	if err := cur.Err(); err != nil {
+		log.Printf("Unable to read documents from %s.%s: %v", databaseName, collectionName, err)
+		return nil, err
+	}
+	items.Elem().Set(slice)
+
+	page := &Page{Items: items.Interface()}
+	if hasMore {
+		if page.NextCursor, err = encodeCursor(last, findOptions.sortFields()); err != nil {
+			return nil, err
+		}
+	}
+
+	if findOptions.IncludeTotal {
+		if filter == nil {
+			filter = bson.D{}
 		}
 
-		return nil
-	}); err != nil {
-		log.Printf("Unable to execute mongo session for Read: %v", err)
+		if page.Total, err = collection.CountDocuments(ctx, filter); err != nil {
+			log.Printf("Unable to count documents in %s.%s: %v", databaseName, collectionName, err)
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+// ReadStream returns a cursor over the documents of the specified collection matching filter
+// Documents are fetched in batches, so large collections can be processed with bounded memory.
+// IncludeTotal is ignored and the caller must close the cursor.
+func (m *MongoClient) ReadStream(ctx context.Context, databaseName, collectionName string, filter interface{}, findOptions *FindOptions) (IDocumentCursor, error) {
+	if m.Client == nil {
+		return nil, fmt.Errorf("MongoDB client is not initialized")
+	}
+
+	if findOptions == nil {
+		findOptions = &FindOptions{}
+	}
+
+	query, err := findOptions.query(filter)
+	if err != nil {
 		return nil, err
 	}
 
-	return results, nil
+	opts := options.Find()
+	opts.SetSort(findOptions.sortDocument())
+	if findOptions.Skip > 0 {
+		opts.SetSkip(findOptions.Skip)
+	}
+	if findOptions.Limit > 0 {
+		opts.SetLimit(findOptions.Limit)
+	}
+	if findOptions.BatchSize > 0 {
+		opts.SetBatchSize(findOptions.BatchSize)
+	}
+	if projection := findOptions.projection(); projection != nil {
+		opts.SetProjection(projection)
+	}
+
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	cur, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		log.Printf("Unable to read documents from %s.%s: %v", databaseName, collectionName, err)
+		return nil, err
+	}
+
+	return cur, nil
 }
 
-// Update modifies documents in the specified collection based on filter
-func (m *MongoClient) Update(databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+// FindOne retrieves the first document matching filter, sorted by _id
+// It returns a pointer to a new dataModel value, or ErrDocumentNotFound.
+func (m *MongoClient) FindOne(ctx context.Context, databaseName, collectionName string, filter interface{}, dataModel reflect.Type) (interface{}, error) {
+	if m.Client == nil {
+		return nil, fmt.Errorf("MongoDB client is not initialized")
+	}
+
+	if filter == nil || dataModel == nil {
+		return nil, fmt.Errorf("filter and data model cannot be nil")
+	}
+
+	opts := options.FindOne().SetSort(bson.D{primitive.E{Key: "_id", Value: 1}})
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	result := reflect.New(dataModel).Interface()
+	if err := collection.FindOne(ctx, filter, opts).Decode(result); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrDocumentNotFound
+		}
+		log.Printf("Unable to find document in %s.%s: %v", databaseName, collectionName, err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UpdateOne modifies the first document matching filter
+func (m *MongoClient) UpdateOne(ctx context.Context, databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	return m.updateOne(ctx, databaseName, collectionName, filter, update, false)
+}
+
+// Upsert modifies the first document matching filter, or inserts one built from filter and update
+func (m *MongoClient) Upsert(ctx context.Context, databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	return m.updateOne(ctx, databaseName, collectionName, filter, update, true)
+}
+
+// updateOne modifies the first document matching filter
+func (m *MongoClient) updateOne(ctx context.Context, databaseName, collectionName string, filter, update interface{}, upsert bool) (interface{}, error) {
+	if m.Client == nil {
+		return nil, fmt.Errorf("MongoDB client is not initialized")
+	}
+
 	if filter == nil || update == nil {
 		return nil, fmt.Errorf("filter and update cannot be nil")
 	}
 
-	var result interface{}
-	session, err := m.createSession()
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	result, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(upsert))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create MongoDB session: %w", err)
+		log.Printf("Unable to update document in %s.%s: %v", databaseName, collectionName, err)
+		return nil, err
 	}
-	defer session.EndSession(ctx)
 
-	if err := mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
-		collection := m.Client.Database(databaseName).Collection(collectionName)
-		result, err = collection.UpdateMany(ctx, filter, update)
-		if err != nil {
-			log.Printf("Unable to update documents in %s.%s: %v", databaseName, collectionName, err)
-			return err
-		}
+	return result, nil
+}
+
+// ReplaceOne replaces the first document matching filter, keeping its _id
+func (m *MongoClient) ReplaceOne(ctx context.Context, databaseName, collectionName string, filter, replacement interface{}) (interface{}, error) {
+	if m.Client == nil {
+		return nil, fmt.Errorf("MongoDB client is not initialized")
+	}
+
+	if filter == nil || replacement == nil {
+		return nil, fmt.Errorf("filter and replacement cannot be nil")
+	}
 
-		return nil
-	}); err != nil {
-		log.Printf("Unable to execute mongo session for Update: %v", err)
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	result, err := collection.ReplaceOne(ctx, filter, replacement)
+	if err != nil {
+		log.Printf("Unable to replace document in %s.%s: %v", databaseName, collectionName, err)
 		return nil, err
 	}
 
 	return result, nil
 }
 
-// Delete removes documents from the specified collection based on filter
-func (m *MongoClient) Delete(databaseName, collectionName string, filter interface{}) (interface{}, error) {
+// DeleteOne removes the first document matching filter
+func (m *MongoClient) DeleteOne(ctx context.Context, databaseName, collectionName string, filter interface{}) (interface{}, error) {
+	if m.Client == nil {
+		return nil, fmt.Errorf("MongoDB client is not initialized")
+	}
+
 	if filter == nil {
 		return nil, fmt.Errorf("filter cannot be nil")
 	}
 
-	var result interface{}
-	session, err := m.createSession()
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	result, err := collection.DeleteOne(ctx, filter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create MongoDB session: %w", err)
+		log.Printf("Unable to delete document from %s.%s: %v", databaseName, collectionName, err)
+		return nil, err
 	}
-	defer session.EndSession(ctx)
 
-	if err := mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
-		collection := m.Client.Database(databaseName).Collection(collectionName)
-		result, err = collection.DeleteMany(ctx, filter)
-		if err != nil {
-			log.Printf("Unable to delete documents from %s.%s: %v", databaseName, collectionName, err)
-			return err
+	return result, nil
+}
+
+// FindOneAndUpdate atomically modifies the first document matching filter, sorted by _id
+// It returns a pointer to a new dataModel value holding the document before or after the update, or ErrDocumentNotFound.
+func (m *MongoClient) FindOneAndUpdate(ctx context.Context, databaseName, collectionName string, filter, update interface{}, returnDocument ReturnDocument, dataModel reflect.Type) (interface{}, error) {
+	if m.Client == nil {
+		return nil, fmt.Errorf("MongoDB client is not initialized")
+	}
+
+	if filter == nil || update == nil || dataModel == nil {
+		return nil, fmt.Errorf("filter, update and data model cannot be nil")
+	}
+
+	opts := options.FindOneAndUpdate().SetSort(bson.D{primitive.E{Key: "_id", Value: 1}})
+	if returnDocument == ReturnAfter {
+		opts.SetReturnDocument(options.After)
+	}
+
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	result := reflect.New(dataModel).Interface()
+	if err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(result); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrDocumentNotFound
+		}
+		log.Printf("Unable to find and update document in %s.%s: %v", databaseName, collectionName, err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Count returns the number of documents matching filter, a nil filter counts every document
+func (m *MongoClient) Count(ctx context.Context, databaseName, collectionName string, filter interface{}) (int64, error) {
+	if m.Client == nil {
+		return 0, fmt.Errorf("MongoDB client is not initialized")
+	}
+
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	count, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		log.Printf("Unable to count documents in %s.%s: %v", databaseName, collectionName, err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// EstimatedCount returns the number of documents of the collection from its metadata, without scanning it
+// The estimate can be off after an unclean shutdown or with orphaned documents in a sharded cluster.
+func (m *MongoClient) EstimatedCount(ctx context.Context, databaseName, collectionName string) (int64, error) {
+	if m.Client == nil {
+		return 0, fmt.Errorf("MongoDB client is not initialized")
+	}
+
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	count, err := collection.EstimatedDocumentCount(ctx)
+	if err != nil {
+		log.Printf("Unable to estimate document count of %s.%s: %v", databaseName, collectionName, err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Distinct returns the distinct values of field in the documents matching filter
+// Array values are unwound, each element being a distinct value. A nil filter reads every document.
+func (m *MongoClient) Distinct(ctx context.Context, databaseName, collectionName, field string, filter interface{}) ([]interface{}, error) {
+	if m.Client == nil {
+		return nil, fmt.Errorf("MongoDB client is not initialized")
+	}
+
+	if field == "" {
+		return nil, fmt.Errorf("field cannot be empty")
+	}
+
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	values, err := collection.Distinct(ctx, field, filter)
+	if err != nil {
+		log.Printf("Unable to read distinct values of %s in %s.%s: %v", field, databaseName, collectionName, err)
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// Exists reports whether a document matches filter, stopping at the first match
+func (m *MongoClient) Exists(ctx context.Context, databaseName, collectionName string, filter interface{}) (bool, error) {
+	if m.Client == nil {
+		return false, fmt.Errorf("MongoDB client is not initialized")
+	}
+
+	if filter == nil {
+		return false, fmt.Errorf("filter cannot be nil")
+	}
+
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	count, err := collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		log.Printf("Unable to check documents in %s.%s: %v", databaseName, collectionName, err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// Aggregate runs the aggregation pipeline on the specified collection
+// pipeline is a Pipeline or any value the driver accepts, e.g. mongo.Pipeline or bson.A.
+// It returns a pointer to a slice of dataModel holding every result, use AggregateStream for large results.
+func (m *MongoClient) Aggregate(ctx context.Context, databaseName, collectionName string, pipeline interface{}, dataModel reflect.Type, aggregateOptions *AggregateOptions) (interface{}, error) {
+	if dataModel == nil {
+		return nil, fmt.Errorf("data model cannot be nil")
+	}
+
+	cur, err := m.aggregate(ctx, databaseName, collectionName, pipeline, aggregateOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	results := reflect.New(reflect.SliceOf(dataModel)).Interface()
+	if err := cur.All(ctx, results); err != nil {
+		log.Printf("Unable to decode cursor: %v", err)
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// AggregateStream runs the aggregation pipeline on the specified collection and returns a cursor over the results
+// The caller must close the cursor.
+func (m *MongoClient) AggregateStream(ctx context.Context, databaseName, collectionName string, pipeline interface{}, aggregateOptions *AggregateOptions) (IDocumentCursor, error) {
+	cur, err := m.aggregate(ctx, databaseName, collectionName, pipeline, aggregateOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return cur, nil
+}
+
+// aggregate runs the aggregation pipeline on the specified collection
+func (m *MongoClient) aggregate(ctx context.Context, databaseName, collectionName string, pipeline interface{}, aggregateOptions *AggregateOptions) (*mongo.Cursor, error) {
+	if m.Client == nil {
+		return nil, fmt.Errorf("MongoDB client is not initialized")
+	}
+
+	if pipeline == nil {
+		return nil, fmt.Errorf("pipeline cannot be nil")
+	}
+
+	opts := options.Aggregate()
+	if aggregateOptions != nil {
+		opts.SetAllowDiskUse(aggregateOptions.AllowDiskUse)
+		if aggregateOptions.BatchSize > 0 {
+			opts.SetBatchSize(aggregateOptions.BatchSize)
+		}
+		if aggregateOptions.MaxTime > 0 {
+			opts.SetMaxTime(aggregateOptions.MaxTime)
+		}
+	}
+
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	cur, err := collection.Aggregate(ctx, pipeline, opts)
+	if err != nil {
+		log.Printf("Unable to aggregate documents of %s.%s: %v", databaseName, collectionName, err)
+		return nil, err
+	}
+
+	return cur, nil
+}
+
+// EnsureIndexes creates the indexes missing from the specified collection and returns their names
+// Declaring an existing index again is a no-op, so services can ensure their indexes at startup.
+// An index with the same name but different keys or options is reported as an error, not replaced.
+func (m *MongoClient) EnsureIndexes(ctx context.Context, databaseName, collectionName string, indexes []IndexSpec) ([]string, error) {
+	if m.Client == nil {
+		return nil, fmt.Errorf("MongoDB client is not initialized")
+	}
+
+	if len(indexes) == 0 {
+		return []string{}, nil
+	}
+
+	models := make([]mongo.IndexModel, 0, len(indexes))
+	for _, index := range indexes {
+		if err := index.validate(); err != nil {
+			return nil, err
+		}
+
+		keys := bson.D{}
+		for _, key := range index.Keys {
+			var value interface{} = 1
+			switch {
+			case key.Text:
+				value = "text"
+			case key.Descending:
+				value = -1
+			}
+			keys = append(keys, bson.E{Key: key.Field, Value: value})
+		}
+
+		opts := options.Index().SetName(index.indexName())
+		if index.Unique {
+			opts.SetUnique(true)
+		}
+		if index.Sparse {
+			opts.SetSparse(true)
+		}
+		if index.ExpireAfter > 0 {
+			// Round up, so documents are never removed before ExpireAfter
+			opts.SetExpireAfterSeconds(int32((index.ExpireAfter + time.Second - 1) / time.Second))
+		}
+		if index.PartialFilter != nil {
+			opts.SetPartialFilterExpression(index.PartialFilter)
+		}
+
+		models = append(models, mongo.IndexModel{Keys: keys, Options: opts})
+	}
+
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	names, err := collection.Indexes().CreateMany(ctx, models)
+	if err != nil {
+		log.Printf("Unable to create indexes on %s.%s: %v", databaseName, collectionName, err)
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// ListIndexes returns the indexes of the specified collection
+func (m *MongoClient) ListIndexes(ctx context.Context, databaseName, collectionName string) ([]IndexSpec, error) {
+	if m.Client == nil {
+		return nil, fmt.Errorf("MongoDB client is not initialized")
+	}
+
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	cur, err := collection.Indexes().List(ctx)
+	if err != nil {
+		log.Printf("Unable to list indexes of %s.%s: %v", databaseName, collectionName, err)
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	indexes := []IndexSpec{}
+	for cur.Next(ctx) {
+		var index struct {
+			Name               string   `bson:"name"`
+			Key                bson.D   `bson:"key"`
+			Unique             bool     `bson:"unique"`
+			Sparse             bool     `bson:"sparse"`
+			ExpireAfterSeconds *float64 `bson:"expireAfterSeconds"`
+			PartialFilter      bson.D   `bson:"partialFilterExpression"`
+			Weights            bson.D   `bson:"weights"`
+		}
+		if err := cur.Decode(&index); err != nil {
+			log.Printf("Unable to decode index: %v", err)
+			return nil, err
+		}
+
+		spec := IndexSpec{Name: index.Name, Unique: index.Unique, Sparse: index.Sparse}
+		if index.ExpireAfterSeconds != nil {
+			spec.ExpireAfter = time.Duration(*index.ExpireAfterSeconds * float64(time.Second))
+		}
+		if len(index.PartialFilter) > 0 {
+			spec.PartialFilter = index.PartialFilter
+		}
+
+		for _, key := range index.Key {
+			// Text indexes are keyed by _fts and _ftsx, the indexed fields are the weights
+			switch key.Key {
+			case "_fts":
+				for _, weight := range index.Weights {
+					spec.Keys = append(spec.Keys, IndexKey{Field: weight.Key, Text: true})
+				}
+			case "_ftsx":
+			default:
+				spec.Keys = append(spec.Keys, IndexKey{Field: key.Key, Descending: isDescendingIndexKey(key.Value)})
+			}
+		}
+
+		indexes = append(indexes, spec)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return indexes, nil
+}
+
+// DropIndex removes the named index from the specified collection
+func (m *MongoClient) DropIndex(ctx context.Context, databaseName, collectionName, indexName string) error {
+	if m.Client == nil {
+		return fmt.Errorf("MongoDB client is not initialized")
+	}
+
+	if indexName == "" {
+		return fmt.Errorf("index name cannot be empty")
+	}
+
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	if _, err := collection.Indexes().DropOne(ctx, indexName); err != nil {
+		log.Printf("Unable to drop index %s of %s.%s: %v", indexName, databaseName, collectionName, err)
+		return err
+	}
+
+	return nil
+}
+
+// SetValidator sets the $jsonSchema validator of the specified collection, creating the collection when missing
+// Writes breaking the schema then fail with a document validation error. A nil schema removes the validator.
+func (m *MongoClient) SetValidator(ctx context.Context, databaseName, collectionName string, schema interface{}) error {
+	if m.Client == nil {
+		return fmt.Errorf("MongoDB client is not initialized")
+	}
+
+	validator := bson.D{}
+	if schema != nil {
+		validator = bson.D{{Key: "$jsonSchema", Value: schema}}
+	}
+
+	database := m.Client.Database(databaseName)
+	err := database.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: collectionName},
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: "strict"},
+		{Key: "validationAction", Value: "error"},
+	}).Err()
+
+	var commandError mongo.CommandError
+	if errors.As(err, &commandError) && commandError.Code == namespaceNotFoundErrorCode {
+		err = database.CreateCollection(ctx, collectionName, options.CreateCollection().SetValidator(validator))
+	}
+
+	if err != nil {
+		log.Printf("Unable to set the validator of %s.%s: %v", databaseName, collectionName, err)
+		return err
+	}
+
+	return nil
+}
+
+// isDescendingIndexKey reports whether the index key direction is descending
+// The server returns the direction as an int32, int64 or double.
+func isDescendingIndexKey(direction interface{}) bool {
+	switch value := direction.(type) {
+	case int32:
+		return value < 0
+	case int64:
+		return value < 0
+	case float64:
+		return value < 0
+	}
+
+	return false
+}
+
+// BulkWrite runs the write operations on the specified collection in a single batch
+// An ordered bulk write stops at the first failed operation, an unordered one runs them all.
+// When operations fail, the result is returned with an error wrapping ErrBulkWrite.
+func (m *MongoClient) BulkWrite(ctx context.Context, databaseName, collectionName string, models []WriteModel, ordered bool) (*BulkWriteResult, error) {
+	if m.Client == nil {
+		return nil, fmt.Errorf("MongoDB client is not initialized")
+	}
+
+	if len(models) == 0 {
+		return nil, fmt.Errorf("no write operations to run")
+	}
+
+	writeModels := make([]mongo.WriteModel, 0, len(models))
+	for i, model := range models {
+		if err := model.validate(); err != nil {
+			return nil, fmt.Errorf("write operation %d: %w", i, err)
+		}
+
+		switch model.Operation {
+		case WriteInsertOne:
+			writeModels = append(writeModels, mongo.NewInsertOneModel().SetDocument(model.Document))
+		case WriteUpdateOne:
+			writeModels = append(writeModels, mongo.NewUpdateOneModel().SetFilter(model.Filter).SetUpdate(model.Update).SetUpsert(model.Upsert))
+		case WriteUpdateMany:
+			writeModels = append(writeModels, mongo.NewUpdateManyModel().SetFilter(model.Filter).SetUpdate(model.Update).SetUpsert(model.Upsert))
+		case WriteReplaceOne:
+			writeModels = append(writeModels, mongo.NewReplaceOneModel().SetFilter(model.Filter).SetReplacement(model.Document).SetUpsert(model.Upsert))
+		case WriteDeleteOne:
+			writeModels = append(writeModels, mongo.NewDeleteOneModel().SetFilter(model.Filter))
+		case WriteDeleteMany:
+			writeModels = append(writeModels, mongo.NewDeleteManyModel().SetFilter(model.Filter))
 		}
+	}
+
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	bulkResult, err := collection.BulkWrite(ctx, writeModels, options.BulkWrite().SetOrdered(ordered))
 
-		return nil
-	}); err != nil {
-		log.Printf("Unable to execute mongo session for Delete: %v", err)
+	bulkException, isBulkException := err.(mongo.BulkWriteException)
+	if err != nil && (!isBulkException || len(bulkException.WriteErrors) == 0) {
+		log.Printf("Unable to bulk write documents in %s.%s: %v", databaseName, collectionName, err)
 		return nil, err
 	}
 
+	result := &BulkWriteResult{Operations: make([]WriteModelResult, len(models))}
+	if bulkResult != nil {
+		result.InsertedCount = bulkResult.InsertedCount
+		result.MatchedCount = bulkResult.MatchedCount
+		result.ModifiedCount = bulkResult.ModifiedCount
+		result.DeletedCount = bulkResult.DeletedCount
+		result.UpsertedCount = bulkResult.UpsertedCount
+		for index, id := range bulkResult.UpsertedIDs {
+			result.Operations[index].UpsertedID = id
+		}
+	}
+
+	executed := len(models)
+	for _, writeError := range bulkException.WriteErrors {
+		result.Operations[writeError.Index].Err = &WriteError{Code: writeError.Code, Message: writeError.Message}
+		if ordered && writeError.Index+1 < executed {
+			// An ordered bulk write stops at its first failure
+			executed = writeError.Index + 1
+		}
+	}
+	for i := 0; i < executed; i++ {
+		result.Operations[i].Executed = true
+	}
+
+	if len(bulkException.WriteErrors) > 0 {
+		log.Printf("Unable to bulk write documents in %s.%s: %v", databaseName, collectionName, err)
+		return result, fmt.Errorf("%w: %d of %d operations failed", ErrBulkWrite, len(bulkException.WriteErrors), len(models))
+	}
+
 	return result, nil
 }
+
+// Watch streams the changes of the specified collection matching the pipeline stages
+// The channel is closed when ctx is done or the watch stops, in which case the last event holds the error.
+// With a ResumeTokenStore, the token of an event is saved once the next event is received,
+// so a restarted watch delivers at least once the change that was being processed.
+func (m *MongoClient) Watch(ctx context.Context, databaseName, collectionName string, pipeline interface{}, watchOptions *WatchOptions) (<-chan ChangeEvent, error) {
+	if m.Client == nil {
+		return nil, fmt.Errorf("MongoDB client is not initialized")
+	}
+
+	if watchOptions == nil {
+		watchOptions = &WatchOptions{}
+	}
+
+	if pipeline == nil {
+		pipeline = mongo.Pipeline{}
+	}
+
+	opts := options.ChangeStream()
+	if watchOptions.FullDocument {
+		opts.SetFullDocument(options.UpdateLookup)
+	}
+	if watchOptions.BatchSize > 0 {
+		opts.SetBatchSize(watchOptions.BatchSize)
+	}
+
+	token, err := watchOptions.resumeToken(databaseName, collectionName)
+	if err != nil {
+		return nil, err
+	}
+	if token != nil {
+		opts.SetResumeAfter(bson.Raw(token))
+	}
+
+	collection := m.Client.Database(databaseName).Collection(collectionName)
+	stream, err := collection.Watch(ctx, pipeline, opts)
+	if err != nil {
+		log.Printf("Unable to watch %s.%s: %v", databaseName, collectionName, err)
+		return nil, err
+	}
+
+	events := make(chan ChangeEvent)
+	go func() {
+		defer close(events)
+		defer stream.Close(context.Background())
+
+		processed := ""
+		for stream.Next(ctx) {
+			event, err := decodeMongoChangeEvent(stream.Current, watchOptions.DataModel)
+			if err != nil {
+				log.Printf("Unable to decode change event of %s.%s: %v", databaseName, collectionName, err)
+				event = ChangeEvent{Err: err}
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+
+			// The consumer is back for this event, so it is done with the previous one
+			watchOptions.saveResumeToken(databaseName, collectionName, processed)
+			if event.Err != nil || event.Operation == ChangeInvalidate {
+				return
+			}
+			processed = event.ResumeToken
+		}
+
+		if err := stream.Err(); err != nil && ctx.Err() == nil {
+			log.Printf("Unable to watch %s.%s: %v", databaseName, collectionName, err)
+			select {
+			case events <- ChangeEvent{Err: err}:
+				watchOptions.saveResumeToken(databaseName, collectionName, processed)
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// decodeMongoChangeEvent converts a change stream document
+func decodeMongoChangeEvent(raw bson.Raw, dataModel reflect.Type) (ChangeEvent, error) {
+	var change struct {
+		ID            bson.Raw            `bson:"_id"`
+		OperationType string              `bson:"operationType"`
+		DocumentKey   bson.M              `bson:"documentKey"`
+		FullDocument  bson.Raw            `bson:"fullDocument"`
+		ClusterTime   primitive.Timestamp `bson:"clusterTime"`
+		Update        struct {
+			UpdatedFields bson.M   `bson:"updatedFields"`
+			RemovedFields []string `bson:"removedFields"`
+		} `bson:"updateDescription"`
+	}
+	if err := bson.Unmarshal(raw, &change); err != nil {
+		return ChangeEvent{}, err
+	}
+
+	event := ChangeEvent{
+		Operation:     ChangeOperation(change.OperationType),
+		DocumentID:    change.DocumentKey["_id"],
+		UpdatedFields: change.Update.UpdatedFields,
+		RemovedFields: change.Update.RemovedFields,
+		ResumeToken:   encodeResumeToken(change.ID),
+		Time:          time.Unix(int64(change.ClusterTime.T), 0),
+	}
+
+	if len(change.FullDocument) > 0 {
+		var document interface{} = &bson.M{}
+		if dataModel != nil {
+			document = reflect.New(dataModel).Interface()
+		}
+		if err := bson.Unmarshal(change.FullDocument, document); err != nil {
+			return ChangeEvent{}, err
+		}
+
+		if dataModel == nil {
+			document = *document.(*bson.M)
+		}
+		event.FullDocument = document
+	}
+
+	return event, nil
+}
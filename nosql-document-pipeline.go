@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Pipeline builds an aggregation pipeline one stage at a time
+// Builder methods return a new pipeline, so a common prefix can be shared between queries.
+type Pipeline []bson.D
+
+// Accumulator model for a computed field of a $group stage
+type Accumulator struct {
+	// Field is the output field name
+	Field string `json:"field"`
+	// Operator is the accumulator operator, e.g. $sum, $avg, $min, $max, $first, $push or $addToSet
+	Operator string `json:"operator"`
+	// Expression is the accumulated expression, e.g. "$amount" or 1
+	Expression interface{} `json:"expression"`
+}
+
+// NewPipeline returns an empty aggregation pipeline
+func NewPipeline() Pipeline {
+	return Pipeline{}
+}
+
+// Stage appends a raw stage, for operators the builder does not cover
+func (p Pipeline) Stage(stage bson.D) Pipeline {
+	// The full slice expression makes append copy, so p is never modified
+	return append(p[:len(p):len(p)], stage)
+}
+
+// Match appends a $match stage keeping the documents matching filter
+func (p Pipeline) Match(filter interface{}) Pipeline {
+	return p.Stage(bson.D{{Key: "$match", Value: filter}})
+}
+
+// Group appends a $group stage grouping the documents by the id expression
+func (p Pipeline) Group(id interface{}, accumulators ...Accumulator) Pipeline {
+	group := bson.D{{Key: "_id", Value: id}}
+	for _, accumulator := range accumulators {
+		group = append(group, bson.E{Key: accumulator.Field, Value: bson.D{{Key: accumulator.Operator, Value: accumulator.Expression}}})
+	}
+
+	return p.Stage(bson.D{{Key: "$group", Value: group}})
+}
+
+// Lookup appends a $lookup stage joining the documents of another collection of the same database
+func (p Pipeline) Lookup(from, localField, foreignField, as string) Pipeline {
+	return p.Stage(bson.D{{Key: "$lookup", Value: bson.D{
+		{Key: "from", Value: from},
+		{Key: "localField", Value: localField},
+		{Key: "foreignField", Value: foreignField},
+		{Key: "as", Value: as},
+	}}})
+}
+
+// Unwind appends an $unwind stage outputting one document per element of the array field
+func (p Pipeline) Unwind(field string, preserveNullAndEmptyArrays bool) Pipeline {
+	return p.Stage(bson.D{{Key: "$unwind", Value: bson.D{
+		{Key: "path", Value: "$" + field},
+		{Key: "preserveNullAndEmptyArrays", Value: preserveNullAndEmptyArrays},
+	}}})
+}
+
+// Facet appends a $facet stage running each sub-pipeline on the same input documents
+func (p Pipeline) Facet(facets map[string]Pipeline) Pipeline {
+	names := make([]string, 0, len(facets))
+	for name := range facets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	facet := bson.D{}
+	for _, name := range names {
+		facet = append(facet, bson.E{Key: name, Value: facets[name]})
+	}
+
+	return p.Stage(bson.D{{Key: "$facet", Value: facet}})
+}
+
+// Sort appends a $sort stage
+func (p Pipeline) Sort(fields ...SortField) Pipeline {
+	sort := bson.D{}
+	for _, field := range fields {
+		direction := 1
+		if field.Descending {
+			direction = -1
+		}
+		sort = append(sort, bson.E{Key: field.Field, Value: direction})
+	}
+
+	return p.Stage(bson.D{{Key: "$sort", Value: sort}})
+}
+
+// Project appends a $project stage keeping only the fields listed and _id
+func (p Pipeline) Project(fields ...string) Pipeline {
+	projection := bson.D{}
+	for _, field := range fields {
+		projection = append(projection, bson.E{Key: field, Value: 1})
+	}
+
+	return p.Stage(bson.D{{Key: "$project", Value: projection}})
+}
+
+// AddFields appends an $addFields stage
+func (p Pipeline) AddFields(fields bson.D) Pipeline {
+	return p.Stage(bson.D{{Key: "$addFields", Value: fields}})
+}
+
+// Skip appends a $skip stage
+func (p Pipeline) Skip(count int64) Pipeline {
+	return p.Stage(bson.D{{Key: "$skip", Value: count}})
+}
+
+// Limit appends a $limit stage
+func (p Pipeline) Limit(count int64) Pipeline {
+	return p.Stage(bson.D{{Key: "$limit", Value: count}})
+}
+
+// Count appends a $count stage outputting the number of documents in field
+func (p Pipeline) Count(field string) Pipeline {
+	return p.Stage(bson.D{{Key: "$count", Value: field}})
+}
@@ -0,0 +1,328 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// objectIDType is the type of generated _id values
+var objectIDType = reflect.TypeOf(primitive.ObjectID{})
+
+// RepositoryOptions model for repository behaviour
+type RepositoryOptions struct {
+	// NoTimestamps disables setting CreatedAtField on insert and UpdatedAtField on insert and update
+	NoTimestamps bool `json:"noTimestamps,omitempty"`
+	// CreatedAtField defaults to "createdAt"
+	CreatedAtField string `json:"createdAtField,omitempty"`
+	// UpdatedAtField defaults to "updatedAt"
+	UpdatedAtField string `json:"updatedAtField,omitempty"`
+	// VersionField enables optimistic locking when set: inserts start the field at 1, and each update
+	// increments it, failing with ErrVersionConflict when the stored version differs from the document one
+	VersionField string `json:"versionField,omitempty"`
+}
+
+// Repository typed access to the documents of a collection, on top of any INoSQLDocument
+// T is a struct, or a map, whose _id is held by the field tagged `bson:"_id"`.
+// Missing _id values are generated on insert: a hex string for string fields and an ObjectID otherwise.
+type Repository[T any] struct {
+	client         INoSQLDocument
+	databaseName   string
+	collectionName string
+	options        RepositoryOptions
+	dataModel      reflect.Type
+	idType         reflect.Type // type of the _id field of T, nil when T has none
+}
+
+// NewRepository returns a repository of the collection, options can be nil
+func NewRepository[T any](client INoSQLDocument, databaseName, collectionName string, options *RepositoryOptions) (*Repository[T], error) {
+	if client == nil {
+		return nil, fmt.Errorf("document client cannot be nil")
+	}
+
+	if databaseName == "" || collectionName == "" {
+		return nil, fmt.Errorf("database and collection names cannot be empty")
+	}
+
+	dataModel := reflect.TypeOf((*T)(nil)).Elem()
+	if dataModel.Kind() != reflect.Struct && dataModel.Kind() != reflect.Map {
+		return nil, fmt.Errorf("repository type must be a struct or a map, got %s", dataModel)
+	}
+
+	repository := &Repository[T]{
+		client:         client,
+		databaseName:   databaseName,
+		collectionName: collectionName,
+		dataModel:      dataModel,
+	}
+
+	if options != nil {
+		repository.options = *options
+	}
+	if repository.options.CreatedAtField == "" {
+		repository.options.CreatedAtField = "createdAt"
+	}
+	if repository.options.UpdatedAtField == "" {
+		repository.options.UpdatedAtField = "updatedAt"
+	}
+
+	if dataModel.Kind() == reflect.Struct {
+		for i := 0; i < dataModel.NumField(); i++ {
+			field := dataModel.Field(i)
+			if strings.Split(field.Tag.Get("bson"), ",")[0] == "_id" {
+				repository.idType = field.Type
+			}
+		}
+	}
+
+	return repository, nil
+}
+
+// FindByID returns the document with this _id, or ErrDocumentNotFound
+// A hex string matches ObjectID values unless the _id field of T is a string.
+func (r *Repository[T]) FindByID(ctx context.Context, id interface{}) (T, error) {
+	var document T
+	item, err := r.client.FindOne(ctx, r.databaseName, r.collectionName, r.idFilter(id), r.dataModel)
+	if err != nil {
+		return document, err
+	}
+
+	return *item.(*T), nil
+}
+
+// Find returns the documents matching filter, sorted by _id unless findOptions sets the sort
+// A nil filter matches every document.
+func (r *Repository[T]) Find(ctx context.Context, filter interface{}, findOptions *FindOptions) ([]T, error) {
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	page, err := r.client.ReadPage(ctx, r.databaseName, r.collectionName, filter, findOptions, r.dataModel)
+	if err != nil {
+		return nil, err
+	}
+
+	return *page.Items.(*[]T), nil
+}
+
+// Insert inserts the documents, setting their missing _id and their timestamps
+// The documents are updated with the generated values.
+func (r *Repository[T]) Insert(ctx context.Context, documents ...*T) error {
+	if len(documents) == 0 {
+		return fmt.Errorf("no documents to insert")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	now := r.now()
+	prepared := make([]bson.D, 0, len(documents))
+	values := make([]interface{}, 0, len(documents))
+	for _, document := range documents {
+		if document == nil {
+			return fmt.Errorf("document cannot be nil")
+		}
+
+		value, err := toDocument(document)
+		if err != nil {
+			return err
+		}
+
+		if value, err = r.ensureID(value); err != nil {
+			return err
+		}
+
+		if r.options.VersionField != "" {
+			if version, _ := getValue(value, strings.Split(r.options.VersionField, ".")); toInt64(version) == 0 {
+				if value, err = setDocumentValue(value, r.options.VersionField, int64(1)); err != nil {
+					return err
+				}
+			}
+		}
+
+		if !r.options.NoTimestamps {
+			if current, _ := getValue(value, strings.Split(r.options.CreatedAtField, ".")); isZeroTime(current) {
+				if value, err = setDocumentValue(value, r.options.CreatedAtField, now); err != nil {
+					return err
+				}
+			}
+			if value, err = setDocumentValue(value, r.options.UpdatedAtField, now); err != nil {
+				return err
+			}
+		}
+
+		prepared = append(prepared, value)
+		values = append(values, value)
+	}
+
+	if _, err := r.client.Create(r.databaseName, r.collectionName, values); err != nil {
+		return err
+	}
+
+	for i, document := range documents {
+		if err := decodeInto(prepared[i], document); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Update replaces the stored document having the same _id, setting its update timestamp
+// It returns ErrDocumentNotFound when no document has this _id. With a VersionField, the stored
+// document must have the version of document, which is incremented, or ErrVersionConflict is returned.
+func (r *Repository[T]) Update(ctx context.Context, document *T) error {
+	if document == nil {
+		return fmt.Errorf("document cannot be nil")
+	}
+
+	value, err := toDocument(document)
+	if err != nil {
+		return err
+	}
+
+	id, found := documentID(value)
+	if !found || isZeroID(id) {
+		return fmt.Errorf("document has no _id")
+	}
+
+	if !r.options.NoTimestamps {
+		if value, err = setDocumentValue(value, r.options.UpdatedAtField, r.now()); err != nil {
+			return err
+		}
+	}
+
+	filter := bson.D{{Key: "_id", Value: id}}
+	var expected int64
+	if r.options.VersionField != "" {
+		version, _ := getValue(value, strings.Split(r.options.VersionField, "."))
+		expected = toInt64(version)
+		if value, err = setDocumentValue(value, r.options.VersionField, expected+1); err != nil {
+			return err
+		}
+		filter = append(filter, versionCondition(r.options.VersionField, expected)...)
+	}
+
+	result, err := r.client.ReplaceOne(ctx, r.databaseName, r.collectionName, filter, value)
+	if err != nil {
+		return err
+	}
+
+	if updateResult, ok := result.(*mongo.UpdateResult); ok && updateResult.MatchedCount == 0 {
+		if r.options.VersionField != "" {
+			return versionError(ctx, r.client, r.databaseName, r.collectionName, bson.D{{Key: "_id", Value: id}}, expected)
+		}
+		return ErrDocumentNotFound
+	}
+
+	return decodeInto(value, document)
+}
+
+// Delete removes the document with this _id, or returns ErrDocumentNotFound
+func (r *Repository[T]) Delete(ctx context.Context, id interface{}) error {
+	result, err := r.client.DeleteOne(ctx, r.databaseName, r.collectionName, r.idFilter(id))
+	if err != nil {
+		return err
+	}
+
+	if deleteResult, ok := result.(*mongo.DeleteResult); ok && deleteResult.DeletedCount == 0 {
+		return ErrDocumentNotFound
+	}
+
+	return nil
+}
+
+// idFilter returns the filter matching the _id
+func (r *Repository[T]) idFilter(id interface{}) bson.D {
+	if hex, ok := id.(string); ok && (r.idType == nil || r.idType == objectIDType || r.idType.Kind() == reflect.Interface) {
+		if objectID, err := primitive.ObjectIDFromHex(hex); err == nil {
+			if r.idType == objectIDType {
+				return bson.D{{Key: "_id", Value: objectID}}
+			}
+
+			// The stored _id can be either
+			return bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: bson.A{hex, objectID}}}}}
+		}
+	}
+
+	return bson.D{{Key: "_id", Value: id}}
+}
+
+// ensureID sets a generated _id when the document has none
+func (r *Repository[T]) ensureID(document bson.D) (bson.D, error) {
+	id, found := documentID(document)
+	if found && !isZeroID(id) {
+		return document, nil
+	}
+
+	var generated interface{} = primitive.NewObjectID()
+	if r.idType != nil && r.idType.Kind() == reflect.String {
+		generated = primitive.NewObjectID().Hex()
+	} else if r.idType != nil && r.idType != objectIDType && r.idType.Kind() != reflect.Interface {
+		return nil, fmt.Errorf("_id of type %s must be set", r.idType)
+	}
+
+	if !found {
+		return append(bson.D{{Key: "_id", Value: generated}}, document...), nil
+	}
+
+	return setDocumentValue(document, "_id", generated)
+}
+
+// now returns the current time at the millisecond precision of BSON dates
+func (r *Repository[T]) now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+// setDocumentValue sets the value at the dotted path of the document
+func setDocumentValue(document bson.D, field string, value interface{}) (bson.D, error) {
+	result, err := setValue(document, strings.Split(field, "."), value)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.(bson.D), nil
+}
+
+// decodeInto decodes the document into value, a pointer
+func decodeInto(document bson.D, value interface{}) error {
+	data, err := bson.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	return bson.Unmarshal(data, value)
+}
+
+// isZeroID reports whether the _id value is unset
+func isZeroID(id interface{}) bool {
+	switch v := id.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case primitive.ObjectID:
+		return v.IsZero()
+	}
+
+	return false
+}
+
+// isZeroTime reports whether the date value is unset
+func isZeroTime(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case primitive.DateTime:
+		return v.Time().Equal(time.Time{})
+	}
+
+	return false
+}
@@ -0,0 +1,879 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// documentValidationErrorCode is the mongo error code of writes rejected by a collection validator
+const documentValidationErrorCode = 121
+
+// FieldError model for a field of a document breaking its schema
+type FieldError struct {
+	// Field is the dotted path of the field, with array positions, empty for the document itself
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// SchemaValidationError is returned when a document does not match the schema of its collection
+// It wraps ErrSchemaValidation.
+type SchemaValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+// Error implements the error interface
+func (e *SchemaValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldError := range e.Errors {
+		if fieldError.Field == "" {
+			messages = append(messages, fieldError.Message)
+		} else {
+			messages = append(messages, fieldError.Field+": "+fieldError.Message)
+		}
+	}
+
+	return fmt.Sprintf("%v: %s", ErrSchemaValidation, strings.Join(messages, "; "))
+}
+
+// Unwrap returns ErrSchemaValidation
+func (e *SchemaValidationError) Unwrap() error {
+	return ErrSchemaValidation
+}
+
+// SchemaOptions model for schema registration
+type SchemaOptions struct {
+	// Install also sets the schema as the $jsonSchema validator of the collection, so the store enforces it
+	Install bool `json:"install,omitempty"`
+}
+
+// SchemaDocument INoSQLDocument validating the documents written to collections with a registered schema
+// Create, ReplaceOne and insert or replace bulk operations validate whole documents. Updates validate
+// the values of $set and $setOnInsert, and check $unset keeps the required fields; the other update
+// operators, and the documents inserted by upserts, are only checked by an installed validator.
+type SchemaDocument struct {
+	INoSQLDocument
+	mu      sync.RWMutex
+	schemas map[string]*documentSchema
+}
+
+// schemaTx transaction operations of a SchemaDocument
+type schemaTx struct {
+	IDocumentTx
+	schemas *SchemaDocument
+}
+
+// documentSchema private model for a compiled $jsonSchema
+// It supports the mongo $jsonSchema keywords except patternProperties, dependencies and additionalItems.
+type documentSchema struct {
+	types                []string
+	enum                 bson.A
+	minimum              *float64
+	maximum              *float64
+	exclusiveMinimum     bool
+	exclusiveMaximum     bool
+	minLength            *int64
+	maxLength            *int64
+	pattern              *regexp.Regexp
+	required             []string
+	properties           []schemaProperty
+	additionalProperties *bool
+	additionalSchema     *documentSchema
+	minProperties        *int64
+	maxProperties        *int64
+	items                *documentSchema
+	minItems             *int64
+	maxItems             *int64
+	uniqueItems          bool
+	allOf                []*documentSchema
+	anyOf                []*documentSchema
+	oneOf                []*documentSchema
+	not                  *documentSchema
+}
+
+// schemaProperty private model for the schema of a named property
+type schemaProperty struct {
+	name   string
+	schema *documentSchema
+}
+
+// jsonSchemaTypes maps the JSON types of the type keyword to BSON types
+var jsonSchemaTypes = map[string][]string{
+	"object":  {"object"},
+	"array":   {"array"},
+	"number":  {"int", "long", "double", "decimal"},
+	"boolean": {"bool"},
+	"string":  {"string"},
+	"null":    {"null"},
+}
+
+// NewSchemaValidation returns the schema validating mode of the document client
+func NewSchemaValidation(client INoSQLDocument) (*SchemaDocument, error) {
+	if client == nil {
+		return nil, fmt.Errorf("document client cannot be nil")
+	}
+
+	return &SchemaDocument{INoSQLDocument: client, schemas: make(map[string]*documentSchema)}, nil
+}
+
+// RegisterSchema validates the writes to the collection with the $jsonSchema document, replacing its previous schema
+// A nil schema stops validating the collection. schemaOptions can be nil.
+func (s *SchemaDocument) RegisterSchema(ctx context.Context, databaseName, collectionName string, schema interface{}, schemaOptions *SchemaOptions) error {
+	var compiled *documentSchema
+	if schema != nil {
+		var err error
+		if compiled, err = compileSchema(schema); err != nil {
+			return err
+		}
+	}
+
+	if schemaOptions != nil && schemaOptions.Install {
+		if err := s.INoSQLDocument.SetValidator(ctx, databaseName, collectionName, schema); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if compiled == nil {
+		delete(s.schemas, namespace(databaseName, collectionName))
+	} else {
+		s.schemas[namespace(databaseName, collectionName)] = compiled
+	}
+
+	return nil
+}
+
+// Validate returns a SchemaValidationError when the document does not match the schema of the collection
+func (s *SchemaDocument) Validate(databaseName, collectionName string, document interface{}) error {
+	schema := s.schema(databaseName, collectionName)
+	if schema == nil {
+		return nil
+	}
+
+	return schema.validateDocument(document)
+}
+
+// Create validates then inserts a list of documents into the specified collection
+func (s *SchemaDocument) Create(databaseName, collectionName string, documents []interface{}) (interface{}, error) {
+	if err := s.validateDocuments(databaseName, collectionName, documents); err != nil {
+		return nil, err
+	}
+
+	return s.INoSQLDocument.Create(databaseName, collectionName, documents)
+}
+
+// Update validates the update then modifies the documents matching filter
+func (s *SchemaDocument) Update(databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	if err := s.validateUpdate(databaseName, collectionName, update); err != nil {
+		return nil, err
+	}
+
+	return s.INoSQLDocument.Update(databaseName, collectionName, filter, update)
+}
+
+// UpdateOne validates the update then modifies the first document matching filter
+func (s *SchemaDocument) UpdateOne(ctx context.Context, databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	if err := s.validateUpdate(databaseName, collectionName, update); err != nil {
+		return nil, err
+	}
+
+	return s.INoSQLDocument.UpdateOne(ctx, databaseName, collectionName, filter, update)
+}
+
+// ReplaceOne validates the replacement then replaces the first document matching filter
+func (s *SchemaDocument) ReplaceOne(ctx context.Context, databaseName, collectionName string, filter, replacement interface{}) (interface{}, error) {
+	if err := s.Validate(databaseName, collectionName, replacement); err != nil {
+		return nil, err
+	}
+
+	return s.INoSQLDocument.ReplaceOne(ctx, databaseName, collectionName, filter, replacement)
+}
+
+// Upsert validates the update then modifies the first document matching filter, or inserts one
+func (s *SchemaDocument) Upsert(ctx context.Context, databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	if err := s.validateUpdate(databaseName, collectionName, update); err != nil {
+		return nil, err
+	}
+
+	return s.INoSQLDocument.Upsert(ctx, databaseName, collectionName, filter, update)
+}
+
+// FindOneAndUpdate validates the update then modifies the first document matching filter and returns it
+func (s *SchemaDocument) FindOneAndUpdate(ctx context.Context, databaseName, collectionName string, filter, update interface{}, returnDocument ReturnDocument, dataModel reflect.Type) (interface{}, error) {
+	if err := s.validateUpdate(databaseName, collectionName, update); err != nil {
+		return nil, err
+	}
+
+	return s.INoSQLDocument.FindOneAndUpdate(ctx, databaseName, collectionName, filter, update, returnDocument, dataModel)
+}
+
+// WithTransaction runs fn in a transaction validating its writes
+func (s *SchemaDocument) WithTransaction(ctx context.Context, fn func(tx IDocumentTx) error) error {
+	return s.INoSQLDocument.WithTransaction(ctx, func(tx IDocumentTx) error {
+		return fn(&schemaTx{IDocumentTx: tx, schemas: s})
+	})
+}
+
+// BulkWrite validates every operation, then runs them
+// Nothing is written when an operation is invalid.
+func (s *SchemaDocument) BulkWrite(ctx context.Context, databaseName, collectionName string, models []WriteModel, ordered bool) (*BulkWriteResult, error) {
+	for i, model := range models {
+		var err error
+		switch model.Operation {
+		case WriteInsertOne, WriteReplaceOne:
+			err = s.Validate(databaseName, collectionName, model.Document)
+		case WriteUpdateOne, WriteUpdateMany:
+			err = s.validateUpdate(databaseName, collectionName, model.Update)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	return s.INoSQLDocument.BulkWrite(ctx, databaseName, collectionName, models, ordered)
+}
+
+// schema returns the schema registered for the collection, nil when there is none
+func (s *SchemaDocument) schema(databaseName, collectionName string) *documentSchema {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.schemas[namespace(databaseName, collectionName)]
+}
+
+// validateDocuments validates the documents of an insert
+func (s *SchemaDocument) validateDocuments(databaseName, collectionName string, documents []interface{}) error {
+	schema := s.schema(databaseName, collectionName)
+	if schema == nil {
+		return nil
+	}
+
+	for i, document := range documents {
+		if err := schema.validateDocument(document); err != nil {
+			return fmt.Errorf("document %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// validateUpdate validates the update operators of an update
+func (s *SchemaDocument) validateUpdate(databaseName, collectionName string, update interface{}) error {
+	schema := s.schema(databaseName, collectionName)
+	if schema == nil || update == nil {
+		return nil
+	}
+
+	return schema.validateUpdate(update)
+}
+
+// Create validates then inserts a list of documents into the specified collection
+func (tx *schemaTx) Create(databaseName, collectionName string, documents []interface{}) (interface{}, error) {
+	if err := tx.schemas.validateDocuments(databaseName, collectionName, documents); err != nil {
+		return nil, err
+	}
+
+	return tx.IDocumentTx.Create(databaseName, collectionName, documents)
+}
+
+// Update validates the update then modifies the documents matching filter
+func (tx *schemaTx) Update(databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	if err := tx.schemas.validateUpdate(databaseName, collectionName, update); err != nil {
+		return nil, err
+	}
+
+	return tx.IDocumentTx.Update(databaseName, collectionName, filter, update)
+}
+
+// UpdateOne validates the update then modifies the first document matching filter
+func (tx *schemaTx) UpdateOne(databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	if err := tx.schemas.validateUpdate(databaseName, collectionName, update); err != nil {
+		return nil, err
+	}
+
+	return tx.IDocumentTx.UpdateOne(databaseName, collectionName, filter, update)
+}
+
+// ReplaceOne validates the replacement then replaces the first document matching filter
+func (tx *schemaTx) ReplaceOne(databaseName, collectionName string, filter, replacement interface{}) (interface{}, error) {
+	if err := tx.schemas.Validate(databaseName, collectionName, replacement); err != nil {
+		return nil, err
+	}
+
+	return tx.IDocumentTx.ReplaceOne(databaseName, collectionName, filter, replacement)
+}
+
+// Upsert validates the update then modifies the first document matching filter, or inserts one
+func (tx *schemaTx) Upsert(databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	if err := tx.schemas.validateUpdate(databaseName, collectionName, update); err != nil {
+		return nil, err
+	}
+
+	return tx.IDocumentTx.Upsert(databaseName, collectionName, filter, update)
+}
+
+// FindOneAndUpdate validates the update then modifies the first document matching filter and returns it
+func (tx *schemaTx) FindOneAndUpdate(databaseName, collectionName string, filter, update interface{}, returnDocument ReturnDocument, dataModel reflect.Type) (interface{}, error) {
+	if err := tx.schemas.validateUpdate(databaseName, collectionName, update); err != nil {
+		return nil, err
+	}
+
+	return tx.IDocumentTx.FindOneAndUpdate(databaseName, collectionName, filter, update, returnDocument, dataModel)
+}
+
+// compileSchema compiles a $jsonSchema document
+func compileSchema(schema interface{}) (*documentSchema, error) {
+	document, err := toDocument(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return compileSchemaDocument(document, "$jsonSchema")
+}
+
+// compileSchemaDocument compiles the schema found at path
+func compileSchemaDocument(document bson.D, path string) (*documentSchema, error) {
+	schema := &documentSchema{}
+	for _, element := range document {
+		keyword := path + "." + element.Key
+		var err error
+		switch element.Key {
+		case "bsonType", "type":
+			var names []string
+			if names, err = schemaStrings(element.Value, keyword); err != nil {
+				return nil, err
+			}
+
+			for _, name := range names {
+				if element.Key == "type" {
+					types, ok := jsonSchemaTypes[name]
+					if !ok {
+						return nil, fmt.Errorf("%s has unknown type %s", keyword, name)
+					}
+					schema.types = append(schema.types, types...)
+				} else if name == "number" {
+					schema.types = append(schema.types, jsonSchemaTypes["number"]...)
+				} else {
+					schema.types = append(schema.types, name)
+				}
+			}
+
+		case "enum":
+			values, ok := element.Value.(bson.A)
+			if !ok || len(values) == 0 {
+				return nil, fmt.Errorf("%s must be a nonempty array", keyword)
+			}
+			schema.enum = values
+
+		case "minimum", "maximum":
+			if !isNumber(element.Value) {
+				return nil, fmt.Errorf("%s must be a number", keyword)
+			}
+			number := toFloat64(element.Value)
+			if element.Key == "minimum" {
+				schema.minimum = &number
+			} else {
+				schema.maximum = &number
+			}
+
+		case "exclusiveMinimum", "exclusiveMaximum":
+			exclusive, ok := element.Value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a boolean", keyword)
+			}
+			if element.Key == "exclusiveMinimum" {
+				schema.exclusiveMinimum = exclusive
+			} else {
+				schema.exclusiveMaximum = exclusive
+			}
+
+		case "minLength":
+			schema.minLength, err = schemaCount(element.Value, keyword)
+		case "maxLength":
+			schema.maxLength, err = schemaCount(element.Value, keyword)
+		case "minProperties":
+			schema.minProperties, err = schemaCount(element.Value, keyword)
+		case "maxProperties":
+			schema.maxProperties, err = schemaCount(element.Value, keyword)
+		case "minItems":
+			schema.minItems, err = schemaCount(element.Value, keyword)
+		case "maxItems":
+			schema.maxItems, err = schemaCount(element.Value, keyword)
+
+		case "pattern":
+			pattern, ok := element.Value.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a string", keyword)
+			}
+			if schema.pattern, err = regexp.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("%s is invalid: %v", keyword, err)
+			}
+
+		case "required":
+			schema.required, err = schemaStrings(element.Value, keyword)
+
+		case "properties":
+			properties, ok := element.Value.(bson.D)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a document", keyword)
+			}
+
+			for _, property := range properties {
+				propertySchema, ok := property.Value.(bson.D)
+				if !ok {
+					return nil, fmt.Errorf("%s.%s must be a document", keyword, property.Key)
+				}
+
+				compiled, err := compileSchemaDocument(propertySchema, keyword+"."+property.Key)
+				if err != nil {
+					return nil, err
+				}
+				schema.properties = append(schema.properties, schemaProperty{name: property.Key, schema: compiled})
+			}
+
+		case "additionalProperties":
+			switch value := element.Value.(type) {
+			case bool:
+				schema.additionalProperties = &value
+			case bson.D:
+				schema.additionalSchema, err = compileSchemaDocument(value, keyword)
+			default:
+				return nil, fmt.Errorf("%s must be a boolean or a document", keyword)
+			}
+
+		case "items":
+			items, ok := element.Value.(bson.D)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s must be a document", ErrUnsupportedOperator, keyword)
+			}
+			schema.items, err = compileSchemaDocument(items, keyword)
+
+		case "uniqueItems":
+			unique, ok := element.Value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a boolean", keyword)
+			}
+			schema.uniqueItems = unique
+
+		case "allOf", "anyOf", "oneOf":
+			alternatives, ok := element.Value.(bson.A)
+			if !ok || len(alternatives) == 0 {
+				return nil, fmt.Errorf("%s must be a nonempty array", keyword)
+			}
+
+			compiled := make([]*documentSchema, 0, len(alternatives))
+			for i, alternative := range alternatives {
+				alternativeSchema, ok := alternative.(bson.D)
+				if !ok {
+					return nil, fmt.Errorf("%s entries must be documents", keyword)
+				}
+
+				alternativeCompiled, err := compileSchemaDocument(alternativeSchema, keyword+"."+strconv.Itoa(i))
+				if err != nil {
+					return nil, err
+				}
+				compiled = append(compiled, alternativeCompiled)
+			}
+
+			switch element.Key {
+			case "allOf":
+				schema.allOf = compiled
+			case "anyOf":
+				schema.anyOf = compiled
+			default:
+				schema.oneOf = compiled
+			}
+
+		case "not":
+			not, ok := element.Value.(bson.D)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a document", keyword)
+			}
+			schema.not, err = compileSchemaDocument(not, keyword)
+
+		case "title", "description":
+
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedOperator, keyword)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return schema, nil
+}
+
+// schemaStrings returns the string, or array of strings, of a keyword
+func schemaStrings(value interface{}, keyword string) ([]string, error) {
+	if name, ok := value.(string); ok {
+		return []string{name}, nil
+	}
+
+	values, ok := value.(bson.A)
+	if !ok || len(values) == 0 {
+		return nil, fmt.Errorf("%s must be a string or a nonempty array of strings", keyword)
+	}
+
+	names := make([]string, 0, len(values))
+	for _, value := range values {
+		name, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a string or a nonempty array of strings", keyword)
+		}
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// schemaCount returns the nonnegative integer of a keyword
+func schemaCount(value interface{}, keyword string) (*int64, error) {
+	if !isNumber(value) || toFloat64(value) < 0 || toFloat64(value) != math.Trunc(toFloat64(value)) {
+		return nil, fmt.Errorf("%s must be a nonnegative integer", keyword)
+	}
+
+	count := toInt64(value)
+	return &count, nil
+}
+
+// validateDocument returns a SchemaValidationError when the document does not match the schema
+func (d *documentSchema) validateDocument(value interface{}) error {
+	document, err := toDocument(value)
+	if err != nil {
+		return err
+	}
+
+	return newSchemaValidationError(d.validate(document, "", nil))
+}
+
+// validateUpdate returns a SchemaValidationError when the update breaks the schema
+// Replacement documents are validated as a whole.
+func (d *documentSchema) validateUpdate(value interface{}) error {
+	update, err := toDocument(value)
+	if err != nil {
+		return err
+	}
+
+	isUpdate, err := isUpdateDocument(update)
+	if err != nil {
+		return err
+	}
+	if !isUpdate {
+		return newSchemaValidationError(d.validate(update, "", nil))
+	}
+
+	var fieldErrors []FieldError
+	for _, operator := range update {
+		fields, ok := operator.Value.(bson.D)
+		if !ok {
+			continue
+		}
+
+		for _, field := range fields {
+			path := strings.Split(field.Key, ".")
+			switch operator.Key {
+			case "$set", "$setOnInsert":
+				schema, allowed := d.at(path)
+				if !allowed {
+					fieldErrors = append(fieldErrors, FieldError{Field: field.Key, Message: "is not allowed"})
+				} else if schema != nil {
+					fieldErrors = schema.validate(field.Value, field.Key, fieldErrors)
+				}
+
+			case "$unset":
+				parent, _ := d.at(path[:len(path)-1])
+				if parent != nil && parent.requires(path[len(path)-1]) {
+					fieldErrors = append(fieldErrors, FieldError{Field: field.Key, Message: "is required"})
+				}
+			}
+		}
+	}
+
+	return newSchemaValidationError(fieldErrors)
+}
+
+// newSchemaValidationError returns the error of the field errors, nil when there are none
+func newSchemaValidationError(fieldErrors []FieldError) error {
+	if len(fieldErrors) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(fieldErrors, func(i, j int) bool {
+		return fieldErrors[i].Field < fieldErrors[j].Field
+	})
+
+	return &SchemaValidationError{Errors: fieldErrors}
+}
+
+// at returns the schema of the value at the path, nil when the schema does not constrain it
+// allowed is false when additionalProperties forbids the path.
+func (d *documentSchema) at(path []string) (schema *documentSchema, allowed bool) {
+	schema = d
+	for _, segment := range path {
+		if schema == nil {
+			return nil, true
+		}
+
+		if _, err := strconv.Atoi(segment); (err == nil || strings.HasPrefix(segment, "$")) && schema.items != nil {
+			schema = schema.items
+			continue
+		}
+
+		next := schema.property(segment)
+		switch {
+		case next != nil:
+			schema = next
+		case schema.additionalSchema != nil:
+			schema = schema.additionalSchema
+		case schema.additionalProperties != nil && !*schema.additionalProperties:
+			return nil, false
+		default:
+			schema = nil
+		}
+	}
+
+	return schema, true
+}
+
+// property returns the schema of the named property, nil when there is none
+func (d *documentSchema) property(name string) *documentSchema {
+	for _, property := range d.properties {
+		if property.name == name {
+			return property.schema
+		}
+	}
+
+	return nil
+}
+
+// requires reports whether the field is required
+func (d *documentSchema) requires(name string) bool {
+	for _, required := range d.required {
+		if required == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validate appends the errors of the value at field to fieldErrors
+func (d *documentSchema) validate(value interface{}, field string, fieldErrors []FieldError) []FieldError {
+	fail := func(format string, args ...interface{}) {
+		fieldErrors = append(fieldErrors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(d.types) > 0 {
+		valueType := bsonTypeName(value)
+		matched := false
+		for _, name := range d.types {
+			matched = matched || name == valueType
+		}
+
+		if !matched {
+			fail("must be of type %s, got %s", strings.Join(d.types, " or "), valueType)
+			return fieldErrors
+		}
+	}
+
+	if d.enum != nil {
+		matched := false
+		for _, allowed := range d.enum {
+			matched = matched || compareValues(value, allowed) == 0
+		}
+
+		if !matched {
+			fail("must be one of %v", []interface{}(d.enum))
+		}
+	}
+
+	if isNumber(value) {
+		number := toFloat64(value)
+		if d.minimum != nil && (number < *d.minimum || d.exclusiveMinimum && number == *d.minimum) {
+			if d.exclusiveMinimum {
+				fail("must be greater than %v", *d.minimum)
+			} else {
+				fail("must be at least %v", *d.minimum)
+			}
+		}
+
+		if d.maximum != nil && (number > *d.maximum || d.exclusiveMaximum && number == *d.maximum) {
+			if d.exclusiveMaximum {
+				fail("must be less than %v", *d.maximum)
+			} else {
+				fail("must be at most %v", *d.maximum)
+			}
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := int64(utf8.RuneCountInString(v))
+		if d.minLength != nil && length < *d.minLength {
+			fail("must be at least %d characters long", *d.minLength)
+		}
+		if d.maxLength != nil && length > *d.maxLength {
+			fail("must be at most %d characters long", *d.maxLength)
+		}
+		if d.pattern != nil && !d.pattern.MatchString(v) {
+			fail("must match %s", d.pattern)
+		}
+
+	case bson.D:
+		for _, required := range d.required {
+			if _, found := getValue(v, []string{required}); !found {
+				fieldErrors = append(fieldErrors, FieldError{Field: schemaField(field, required), Message: "is required"})
+			}
+		}
+
+		for _, element := range v {
+			if schema := d.property(element.Key); schema != nil {
+				fieldErrors = schema.validate(element.Value, schemaField(field, element.Key), fieldErrors)
+			} else if d.additionalSchema != nil {
+				fieldErrors = d.additionalSchema.validate(element.Value, schemaField(field, element.Key), fieldErrors)
+			} else if d.additionalProperties != nil && !*d.additionalProperties {
+				fieldErrors = append(fieldErrors, FieldError{Field: schemaField(field, element.Key), Message: "is not allowed"})
+			}
+		}
+
+		if d.minProperties != nil && int64(len(v)) < *d.minProperties {
+			fail("must have at least %d fields", *d.minProperties)
+		}
+		if d.maxProperties != nil && int64(len(v)) > *d.maxProperties {
+			fail("must have at most %d fields", *d.maxProperties)
+		}
+
+	case bson.A:
+		if d.minItems != nil && int64(len(v)) < *d.minItems {
+			fail("must have at least %d items", *d.minItems)
+		}
+		if d.maxItems != nil && int64(len(v)) > *d.maxItems {
+			fail("must have at most %d items", *d.maxItems)
+		}
+
+		if d.uniqueItems {
+			for i := range v {
+				for j := 0; j < i; j++ {
+					if compareValues(v[i], v[j]) == 0 {
+						fieldErrors = append(fieldErrors, FieldError{Field: schemaField(field, strconv.Itoa(i)), Message: fmt.Sprintf("duplicates item %d", j)})
+						break
+					}
+				}
+			}
+		}
+
+		if d.items != nil {
+			for i, item := range v {
+				fieldErrors = d.items.validate(item, schemaField(field, strconv.Itoa(i)), fieldErrors)
+			}
+		}
+	}
+
+	for _, schema := range d.allOf {
+		fieldErrors = schema.validate(value, field, fieldErrors)
+	}
+
+	if d.anyOf != nil {
+		matched := false
+		for _, schema := range d.anyOf {
+			matched = matched || len(schema.validate(value, field, nil)) == 0
+		}
+
+		if !matched {
+			fail("must match at least one schema of anyOf")
+		}
+	}
+
+	if d.oneOf != nil {
+		matches := 0
+		for _, schema := range d.oneOf {
+			if len(schema.validate(value, field, nil)) == 0 {
+				matches++
+			}
+		}
+
+		if matches != 1 {
+			fail("must match exactly one schema of oneOf, matched %d", matches)
+		}
+	}
+
+	if d.not != nil && len(d.not.validate(value, field, nil)) == 0 {
+		fail("must not match the schema of not")
+	}
+
+	return fieldErrors
+}
+
+// schemaField returns the path of a field of the value at path
+func schemaField(path, field string) string {
+	if path == "" {
+		return field
+	}
+
+	return path + "." + field
+}
+
+// bsonTypeName returns the $jsonSchema bsonType of a value
+func bsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case float64:
+		return "double"
+	case string:
+		return "string"
+	case bson.D:
+		return "object"
+	case bson.A:
+		return "array"
+	case primitive.Binary:
+		return "binData"
+	case primitive.Undefined:
+		return "undefined"
+	case primitive.ObjectID:
+		return "objectId"
+	case bool:
+		return "bool"
+	case primitive.DateTime:
+		return "date"
+	case nil, primitive.Null:
+		return "null"
+	case primitive.Regex:
+		return "regex"
+	case primitive.DBPointer:
+		return "dbPointer"
+	case primitive.JavaScript:
+		return "javascript"
+	case primitive.Symbol:
+		return "symbol"
+	case primitive.CodeWithScope:
+		return "javascriptWithScope"
+	case int32:
+		return "int"
+	case primitive.Timestamp:
+		return "timestamp"
+	case int, int64:
+		return "long"
+	case primitive.Decimal128:
+		return "decimal"
+	case primitive.MinKey:
+		return "minKey"
+	case primitive.MaxKey:
+		return "maxKey"
+	}
+
+	return reflect.TypeOf(value).String()
+}
@@ -0,0 +1,347 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SoftDeleteOptions model for soft delete config
+type SoftDeleteOptions struct {
+	// Field holds the deletion date, defaults to "deletedAt"
+	Field string `json:"field,omitempty"`
+	// Retention is how long deleted documents are kept before Purge or the retention index removes them
+	Retention time.Duration `json:"retention,omitempty"` // nanosecond
+}
+
+// SoftDeleteDocument INoSQLDocument where deletes set a deletion date instead of removing documents
+// Reads, updates and aggregations only see the documents without a deletion date. The embedded
+// client sees every document, and serves the methods that do not filter documents: Create,
+// EstimatedCount, which counts deleted documents, the index methods and Watch, which reports
+// soft deletes as updates. Bulk writes count soft deletes as modified documents.
+type SoftDeleteDocument struct {
+	INoSQLDocument
+	options SoftDeleteOptions
+}
+
+// softDeleteTx transaction operations of a SoftDeleteDocument
+type softDeleteTx struct {
+	tx      IDocumentTx
+	scoping *SoftDeleteDocument
+}
+
+// NewSoftDelete returns the soft delete mode of the document client, options can be nil
+func NewSoftDelete(client INoSQLDocument, options *SoftDeleteOptions) (*SoftDeleteDocument, error) {
+	if client == nil {
+		return nil, fmt.Errorf("document client cannot be nil")
+	}
+
+	softDelete := &SoftDeleteDocument{INoSQLDocument: client}
+	if options != nil {
+		softDelete.options = *options
+	}
+	if softDelete.options.Field == "" {
+		softDelete.options.Field = "deletedAt"
+	}
+
+	return softDelete, nil
+}
+
+// Read retrieves the documents matching filter that are not deleted
+func (s *SoftDeleteDocument) Read(databaseName, collectionName string, filter interface{}, limit int64, dataModel reflect.Type) (interface{}, error) {
+	return s.INoSQLDocument.Read(databaseName, collectionName, s.scope(filter), limit, dataModel)
+}
+
+// Update modifies the documents matching filter that are not deleted
+func (s *SoftDeleteDocument) Update(databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	return s.INoSQLDocument.Update(databaseName, collectionName, s.scope(filter), update)
+}
+
+// Delete sets the deletion date of the documents matching filter
+// It returns a *mongo.DeleteResult counting the documents deleted by this call.
+func (s *SoftDeleteDocument) Delete(databaseName, collectionName string, filter interface{}) (interface{}, error) {
+	if filter == nil {
+		return nil, fmt.Errorf("filter cannot be nil")
+	}
+
+	result, err := s.INoSQLDocument.Update(databaseName, collectionName, s.scope(filter), s.deletion())
+	return deleteResult(result, err)
+}
+
+// ReadPage retrieves a page of the documents matching filter that are not deleted
+func (s *SoftDeleteDocument) ReadPage(ctx context.Context, databaseName, collectionName string, filter interface{}, findOptions *FindOptions, dataModel reflect.Type) (*Page, error) {
+	return s.INoSQLDocument.ReadPage(ctx, databaseName, collectionName, s.scopeAll(filter), findOptions, dataModel)
+}
+
+// ReadStream returns a cursor over the documents matching filter that are not deleted
+func (s *SoftDeleteDocument) ReadStream(ctx context.Context, databaseName, collectionName string, filter interface{}, findOptions *FindOptions) (IDocumentCursor, error) {
+	return s.INoSQLDocument.ReadStream(ctx, databaseName, collectionName, s.scopeAll(filter), findOptions)
+}
+
+// FindOne retrieves the first document matching filter that is not deleted
+func (s *SoftDeleteDocument) FindOne(ctx context.Context, databaseName, collectionName string, filter interface{}, dataModel reflect.Type) (interface{}, error) {
+	return s.INoSQLDocument.FindOne(ctx, databaseName, collectionName, s.scope(filter), dataModel)
+}
+
+// UpdateOne modifies the first document matching filter that is not deleted
+func (s *SoftDeleteDocument) UpdateOne(ctx context.Context, databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	return s.INoSQLDocument.UpdateOne(ctx, databaseName, collectionName, s.scope(filter), update)
+}
+
+// ReplaceOne replaces the first document matching filter that is not deleted
+func (s *SoftDeleteDocument) ReplaceOne(ctx context.Context, databaseName, collectionName string, filter, replacement interface{}) (interface{}, error) {
+	return s.INoSQLDocument.ReplaceOne(ctx, databaseName, collectionName, s.scope(filter), replacement)
+}
+
+// DeleteOne sets the deletion date of the first document matching filter that is not deleted
+// It returns a *mongo.DeleteResult counting the documents deleted by this call.
+func (s *SoftDeleteDocument) DeleteOne(ctx context.Context, databaseName, collectionName string, filter interface{}) (interface{}, error) {
+	if filter == nil {
+		return nil, fmt.Errorf("filter cannot be nil")
+	}
+
+	result, err := s.INoSQLDocument.UpdateOne(ctx, databaseName, collectionName, s.scope(filter), s.deletion())
+	return deleteResult(result, err)
+}
+
+// Upsert modifies the first document matching filter that is not deleted, or inserts one
+// Deleted documents are not revived: when filter selects a deleted document by _id, the
+// insert conflicts with it and a duplicate key WriteError is returned. Restore it first.
+func (s *SoftDeleteDocument) Upsert(ctx context.Context, databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	return s.INoSQLDocument.Upsert(ctx, databaseName, collectionName, s.scope(filter), update)
+}
+
+// FindOneAndUpdate modifies the first document matching filter that is not deleted and returns it
+func (s *SoftDeleteDocument) FindOneAndUpdate(ctx context.Context, databaseName, collectionName string, filter, update interface{}, returnDocument ReturnDocument, dataModel reflect.Type) (interface{}, error) {
+	return s.INoSQLDocument.FindOneAndUpdate(ctx, databaseName, collectionName, s.scope(filter), update, returnDocument, dataModel)
+}
+
+// Count returns the number of documents matching filter that are not deleted
+func (s *SoftDeleteDocument) Count(ctx context.Context, databaseName, collectionName string, filter interface{}) (int64, error) {
+	return s.INoSQLDocument.Count(ctx, databaseName, collectionName, s.scopeAll(filter))
+}
+
+// Distinct returns the distinct values of field in the documents matching filter that are not deleted
+func (s *SoftDeleteDocument) Distinct(ctx context.Context, databaseName, collectionName, field string, filter interface{}) ([]interface{}, error) {
+	return s.INoSQLDocument.Distinct(ctx, databaseName, collectionName, field, s.scopeAll(filter))
+}
+
+// Exists reports whether a document that is not deleted matches filter
+func (s *SoftDeleteDocument) Exists(ctx context.Context, databaseName, collectionName string, filter interface{}) (bool, error) {
+	return s.INoSQLDocument.Exists(ctx, databaseName, collectionName, s.scope(filter))
+}
+
+// Aggregate runs the aggregation pipeline on the documents that are not deleted
+// Documents joined by $lookup are not filtered.
+func (s *SoftDeleteDocument) Aggregate(ctx context.Context, databaseName, collectionName string, pipeline interface{}, dataModel reflect.Type, aggregateOptions *AggregateOptions) (interface{}, error) {
+	scoped, err := s.scopePipeline(pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.INoSQLDocument.Aggregate(ctx, databaseName, collectionName, scoped, dataModel, aggregateOptions)
+}
+
+// AggregateStream runs the aggregation pipeline on the documents that are not deleted and returns a cursor over the results
+func (s *SoftDeleteDocument) AggregateStream(ctx context.Context, databaseName, collectionName string, pipeline interface{}, aggregateOptions *AggregateOptions) (IDocumentCursor, error) {
+	scoped, err := s.scopePipeline(pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.INoSQLDocument.AggregateStream(ctx, databaseName, collectionName, scoped, aggregateOptions)
+}
+
+// WithTransaction runs fn in a transaction where deletes set the deletion date and reads skip deleted documents
+func (s *SoftDeleteDocument) WithTransaction(ctx context.Context, fn func(tx IDocumentTx) error) error {
+	if fn == nil {
+		return fmt.Errorf("transaction function cannot be nil")
+	}
+
+	return s.INoSQLDocument.WithTransaction(ctx, func(tx IDocumentTx) error {
+		return fn(&softDeleteTx{tx: tx, scoping: s})
+	})
+}
+
+// BulkWrite runs the write operations on the documents that are not deleted, deletes setting the deletion date
+func (s *SoftDeleteDocument) BulkWrite(ctx context.Context, databaseName, collectionName string, models []WriteModel, ordered bool) (*BulkWriteResult, error) {
+	scoped := make([]WriteModel, 0, len(models))
+	for _, model := range models {
+		switch model.Operation {
+		case WriteDeleteOne:
+			model = WriteModel{Operation: WriteUpdateOne, Filter: model.Filter, Update: s.deletion()}
+		case WriteDeleteMany:
+			model = WriteModel{Operation: WriteUpdateMany, Filter: model.Filter, Update: s.deletion()}
+		}
+
+		if model.Operation != WriteInsertOne {
+			model.Filter = s.scope(model.Filter)
+		}
+		scoped = append(scoped, model)
+	}
+
+	return s.INoSQLDocument.BulkWrite(ctx, databaseName, collectionName, scoped, ordered)
+}
+
+// Restore clears the deletion date of the deleted documents matching filter
+func (s *SoftDeleteDocument) Restore(ctx context.Context, databaseName, collectionName string, filter interface{}) (interface{}, error) {
+	if filter == nil {
+		return nil, fmt.Errorf("filter cannot be nil")
+	}
+
+	// The bulk write is the context aware way to update every matching document
+	deleted := bson.D{{Key: "$and", Value: bson.A{filter, bson.D{{Key: s.options.Field, Value: bson.D{{Key: "$ne", Value: nil}}}}}}}
+	result, err := s.INoSQLDocument.BulkWrite(ctx, databaseName, collectionName, []WriteModel{
+		{Operation: WriteUpdateMany, Filter: deleted, Update: bson.D{{Key: "$unset", Value: bson.D{{Key: s.options.Field, Value: ""}}}}},
+	}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mongo.UpdateResult{MatchedCount: result.MatchedCount, ModifiedCount: result.ModifiedCount}, nil
+}
+
+// Purge removes the documents deleted for longer than the retention and returns their number
+func (s *SoftDeleteDocument) Purge(ctx context.Context, databaseName, collectionName string) (int64, error) {
+	cutoff := time.Now().Add(-s.options.Retention)
+	filter := bson.D{{Key: s.options.Field, Value: bson.D{{Key: "$lte", Value: cutoff}}}}
+	result, err := s.INoSQLDocument.BulkWrite(ctx, databaseName, collectionName, []WriteModel{
+		{Operation: WriteDeleteMany, Filter: filter},
+	}, true)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+// EnsureRetentionIndex creates a TTL index on the deletion date, so the store removes deleted
+// documents after the retention without calling Purge
+func (s *SoftDeleteDocument) EnsureRetentionIndex(ctx context.Context, databaseName, collectionName string) (string, error) {
+	if s.options.Retention <= 0 {
+		return "", fmt.Errorf("retention must be positive")
+	}
+
+	names, err := s.INoSQLDocument.EnsureIndexes(ctx, databaseName, collectionName, []IndexSpec{
+		{Keys: []IndexKey{{Field: s.options.Field}}, ExpireAfter: s.options.Retention},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return names[0], nil
+}
+
+// scope restricts the filter to the documents that are not deleted, a nil filter stays nil
+func (s *SoftDeleteDocument) scope(filter interface{}) interface{} {
+	if filter == nil {
+		return nil
+	}
+
+	// A null condition matches documents without the field
+	return bson.D{{Key: "$and", Value: bson.A{filter, bson.D{{Key: s.options.Field, Value: nil}}}}}
+}
+
+// scopeAll restricts the filter to the documents that are not deleted, a nil filter matching every document
+func (s *SoftDeleteDocument) scopeAll(filter interface{}) interface{} {
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	return s.scope(filter)
+}
+
+// scopePipeline prepends the stage skipping deleted documents to the pipeline
+func (s *SoftDeleteDocument) scopePipeline(pipeline interface{}) (interface{}, error) {
+	if pipeline == nil {
+		return nil, fmt.Errorf("pipeline cannot be nil")
+	}
+
+	stages, err := toPipeline(pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(NewPipeline().Match(bson.D{{Key: s.options.Field, Value: nil}}), stages...), nil
+}
+
+// deletion returns the update setting the deletion date
+func (s *SoftDeleteDocument) deletion() bson.D {
+	return bson.D{{Key: "$set", Value: bson.D{{Key: s.options.Field, Value: time.Now()}}}}
+}
+
+// deleteResult converts the result of the update setting deletion dates
+func deleteResult(result interface{}, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	if updated, ok := result.(*mongo.UpdateResult); ok {
+		return &mongo.DeleteResult{DeletedCount: updated.ModifiedCount}, nil
+	}
+
+	return result, nil
+}
+
+// Create inserts a list of documents into the specified collection
+func (t *softDeleteTx) Create(databaseName, collectionName string, documents []interface{}) (interface{}, error) {
+	return t.tx.Create(databaseName, collectionName, documents)
+}
+
+// Read retrieves the documents matching filter that are not deleted
+func (t *softDeleteTx) Read(databaseName, collectionName string, filter interface{}, limit int64, dataModel reflect.Type) (interface{}, error) {
+	return t.tx.Read(databaseName, collectionName, t.scoping.scope(filter), limit, dataModel)
+}
+
+// Update modifies the documents matching filter that are not deleted
+func (t *softDeleteTx) Update(databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	return t.tx.Update(databaseName, collectionName, t.scoping.scope(filter), update)
+}
+
+// Delete sets the deletion date of the documents matching filter
+func (t *softDeleteTx) Delete(databaseName, collectionName string, filter interface{}) (interface{}, error) {
+	if filter == nil {
+		return nil, fmt.Errorf("filter cannot be nil")
+	}
+
+	return deleteResult(t.tx.Update(databaseName, collectionName, t.scoping.scope(filter), t.scoping.deletion()))
+}
+
+// FindOne retrieves the first document matching filter that is not deleted
+func (t *softDeleteTx) FindOne(databaseName, collectionName string, filter interface{}, dataModel reflect.Type) (interface{}, error) {
+	return t.tx.FindOne(databaseName, collectionName, t.scoping.scope(filter), dataModel)
+}
+
+// UpdateOne modifies the first document matching filter that is not deleted
+func (t *softDeleteTx) UpdateOne(databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	return t.tx.UpdateOne(databaseName, collectionName, t.scoping.scope(filter), update)
+}
+
+// ReplaceOne replaces the first document matching filter that is not deleted
+func (t *softDeleteTx) ReplaceOne(databaseName, collectionName string, filter, replacement interface{}) (interface{}, error) {
+	return t.tx.ReplaceOne(databaseName, collectionName, t.scoping.scope(filter), replacement)
+}
+
+// DeleteOne sets the deletion date of the first document matching filter that is not deleted
+func (t *softDeleteTx) DeleteOne(databaseName, collectionName string, filter interface{}) (interface{}, error) {
+	if filter == nil {
+		return nil, fmt.Errorf("filter cannot be nil")
+	}
+
+	return deleteResult(t.tx.UpdateOne(databaseName, collectionName, t.scoping.scope(filter), t.scoping.deletion()))
+}
+
+// Upsert modifies the first document matching filter that is not deleted, or inserts one
+// Like SoftDeleteDocument.Upsert, a deleted document selected by _id makes the insert fail.
+func (t *softDeleteTx) Upsert(databaseName, collectionName string, filter, update interface{}) (interface{}, error) {
+	return t.tx.Upsert(databaseName, collectionName, t.scoping.scope(filter), update)
+}
+
+// FindOneAndUpdate modifies the first document matching filter that is not deleted and returns it
+func (t *softDeleteTx) FindOneAndUpdate(databaseName, collectionName string, filter, update interface{}, returnDocument ReturnDocument, dataModel reflect.Type) (interface{}, error) {
+	return t.tx.FindOneAndUpdate(databaseName, collectionName, t.scoping.scope(filter), update, returnDocument, dataModel)
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UpdateWithVersion modifies the first document matching filter if its version field holds expected,
+// incrementing the version in the same write
+// update must use update operators. Documents without the version field are at version 0.
+// It returns ErrVersionConflict when a document matches filter with another version, and
+// ErrDocumentNotFound when none matches filter.
+func UpdateWithVersion(ctx context.Context, client INoSQLDocument, databaseName, collectionName string, filter, update interface{}, versionField string, expected int64) (interface{}, error) {
+	if client == nil {
+		return nil, fmt.Errorf("document client cannot be nil")
+	}
+
+	if filter == nil || update == nil {
+		return nil, fmt.Errorf("filter and update cannot be nil")
+	}
+
+	if versionField == "" {
+		return nil, fmt.Errorf("version field cannot be empty")
+	}
+
+	updateDocument, err := toDocument(update)
+	if err != nil {
+		return nil, err
+	}
+
+	if isUpdate, err := isUpdateDocument(updateDocument); err != nil || !isUpdate {
+		return nil, fmt.Errorf("update must use update operators")
+	}
+
+	if updateDocument, err = incrementVersion(updateDocument, versionField); err != nil {
+		return nil, err
+	}
+
+	versioned := bson.D{{Key: "$and", Value: bson.A{filter, versionCondition(versionField, expected)}}}
+	result, err := client.UpdateOne(ctx, databaseName, collectionName, versioned, updateDocument)
+	if err != nil {
+		return nil, err
+	}
+
+	if updateResult, ok := result.(*mongo.UpdateResult); ok && updateResult.MatchedCount == 0 {
+		return nil, versionError(ctx, client, databaseName, collectionName, filter, expected)
+	}
+
+	return result, nil
+}
+
+// versionCondition returns the filter matching documents at the expected version
+func versionCondition(versionField string, expected int64) bson.D {
+	if expected == 0 {
+		// A null condition also matches documents written before versioning
+		return bson.D{{Key: versionField, Value: bson.D{{Key: "$in", Value: bson.A{int64(0), nil}}}}}
+	}
+
+	return bson.D{{Key: versionField, Value: expected}}
+}
+
+// versionError tells a version conflict from a missing document, once a versioned write matched nothing
+func versionError(ctx context.Context, client INoSQLDocument, databaseName, collectionName string, filter interface{}, expected int64) error {
+	exists, err := client.Exists(ctx, databaseName, collectionName, filter)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return ErrDocumentNotFound
+	}
+
+	return fmt.Errorf("%w: expected version %d", ErrVersionConflict, expected)
+}
+
+// incrementVersion adds the increment of the version field to the update operators
+func incrementVersion(update bson.D, versionField string) (bson.D, error) {
+	for _, operator := range update {
+		fields, _ := operator.Value.(bson.D)
+		for _, field := range fields {
+			if field.Key == versionField {
+				return nil, fmt.Errorf("version field %s cannot be updated", versionField)
+			}
+		}
+	}
+
+	increment := bson.E{Key: versionField, Value: int64(1)}
+	for i, operator := range update {
+		if operator.Key == "$inc" {
+			fields, _ := operator.Value.(bson.D)
+			update[i].Value = append(fields, increment)
+			return update, nil
+		}
+	}
+
+	return append(update, bson.E{Key: "$inc", Value: bson.D{increment}}), nil
+}
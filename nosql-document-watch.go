@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"reflect"
+	"time"
+)
+
+// ChangeOperation is the kind of change reported by Watch
+type ChangeOperation string
+
+const (
+	// ChangeInsert is reported when a document is inserted
+	ChangeInsert ChangeOperation = "insert"
+	// ChangeUpdate is reported when a document is updated
+	ChangeUpdate ChangeOperation = "update"
+	// ChangeReplace is reported when a document is replaced
+	ChangeReplace ChangeOperation = "replace"
+	// ChangeDelete is reported when a document is deleted
+	ChangeDelete ChangeOperation = "delete"
+	// ChangeInvalidate is reported when the collection is dropped or renamed, it ends the watch
+	ChangeInvalidate ChangeOperation = "invalidate"
+)
+
+// WatchOptions model for collection change watching
+type WatchOptions struct {
+	// FullDocument looks up the current version of updated documents, inserted and
+	// replaced documents are always included
+	FullDocument bool `json:"fullDocument,omitempty"`
+	// DataModel is the type full documents are decoded into, bson.M is used when nil
+	DataModel reflect.Type `json:"-"`
+	// BatchSize is the maximum number of changes fetched per round trip, zero uses the server default
+	BatchSize int32 `json:"batchSize,omitempty"`
+
+	// ResumeAfter resumes the watch after the change with this token when no token is stored
+	ResumeAfter string `json:"resumeAfter,omitempty"`
+	// ResumeTokenStore persists the token of the last processed change, so a restarted watch resumes after it
+	ResumeTokenStore INoSQLKeyValue `json:"-"`
+	// ResumeTokenKey is the key of the token in ResumeTokenStore, defaults to "watch:<database>.<collection>"
+	ResumeTokenKey string `json:"resumeTokenKey,omitempty"`
+	// ResumeTokenTTL is the lifetime of the stored token, zero uses the store default
+	ResumeTokenTTL time.Duration `json:"resumeTokenTTL,omitempty"` // nanosecond
+}
+
+// ChangeEvent model for a collection change
+type ChangeEvent struct {
+	Operation ChangeOperation `json:"operation"`
+	// DocumentID is the _id of the changed document
+	DocumentID interface{} `json:"documentId,omitempty"`
+	// FullDocument holds a pointer to a new DataModel value, or bson.M when DataModel is nil
+	FullDocument  interface{}            `json:"fullDocument,omitempty"`
+	UpdatedFields map[string]interface{} `json:"updatedFields,omitempty"`
+	RemovedFields []string               `json:"removedFields,omitempty"`
+	// ResumeToken resumes a watch after this change
+	ResumeToken string    `json:"resumeToken"`
+	Time        time.Time `json:"time"`
+	// Err is set on the last event when the watch stops on an error
+	Err error `json:"-"`
+}
+
+// resumeTokenKey returns the key of the resume token in the store
+func (o *WatchOptions) resumeTokenKey(databaseName, collectionName string) string {
+	if o.ResumeTokenKey != "" {
+		return o.ResumeTokenKey
+	}
+
+	return fmt.Sprintf("watch:%s.%s", databaseName, collectionName)
+}
+
+// resumeToken returns the raw token the watch resumes after, or nil to start from now
+func (o *WatchOptions) resumeToken(databaseName, collectionName string) ([]byte, error) {
+	token := o.ResumeAfter
+	if o.ResumeTokenStore != nil {
+		if value, err := o.ResumeTokenStore.Get(o.resumeTokenKey(databaseName, collectionName)); err == nil && value != nil {
+			if stored, ok := value.(string); ok && stored != "" {
+				token = stored
+			}
+		}
+	}
+
+	if token == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resume token: %w", err)
+	}
+
+	return raw, nil
+}
+
+// saveResumeToken stores the token of the last processed change
+func (o *WatchOptions) saveResumeToken(databaseName, collectionName, token string) {
+	if o.ResumeTokenStore == nil || token == "" {
+		return
+	}
+
+	key := o.resumeTokenKey(databaseName, collectionName)
+
+	// The key-value stores only update existing keys and only set new ones,
+	// a missing key reads as nil or, with Redis, as an empty string
+	var err error
+	if value, getErr := o.ResumeTokenStore.Get(key); getErr == nil && value != nil && value != "" {
+		err = o.ResumeTokenStore.Update(key, token, o.ResumeTokenTTL)
+	} else {
+		err = o.ResumeTokenStore.Set(key, token, o.ResumeTokenTTL)
+	}
+	if err != nil {
+		log.Printf("Unable to save resume token of %s.%s: %v", databaseName, collectionName, err)
+	}
+}
+
+// encodeResumeToken returns the raw token as a string
+func encodeResumeToken(raw []byte) string {
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
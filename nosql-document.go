@@ -1,6 +1,13 @@
 package storage
 
-import "reflect"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
 
 // INoSQLDocument factory pattern CRUD interface
 type INoSQLDocument interface {
@@ -8,11 +15,283 @@ type INoSQLDocument interface {
 	Read(databaseName, collectionName string, filter interface{}, limit int64, dataModel reflect.Type) (interface{}, error)
 	Update(databaseName, collectionName string, filter, update interface{}) (interface{}, error)
 	Delete(databaseName, collectionName string, filter interface{}) (interface{}, error)
+	ReadPage(ctx context.Context, databaseName, collectionName string, filter interface{}, findOptions *FindOptions, dataModel reflect.Type) (*Page, error)
+	ReadStream(ctx context.Context, databaseName, collectionName string, filter interface{}, findOptions *FindOptions) (IDocumentCursor, error)
+	FindOne(ctx context.Context, databaseName, collectionName string, filter interface{}, dataModel reflect.Type) (interface{}, error)
+	UpdateOne(ctx context.Context, databaseName, collectionName string, filter, update interface{}) (interface{}, error)
+	ReplaceOne(ctx context.Context, databaseName, collectionName string, filter, replacement interface{}) (interface{}, error)
+	DeleteOne(ctx context.Context, databaseName, collectionName string, filter interface{}) (interface{}, error)
+	Upsert(ctx context.Context, databaseName, collectionName string, filter, update interface{}) (interface{}, error)
+	FindOneAndUpdate(ctx context.Context, databaseName, collectionName string, filter, update interface{}, returnDocument ReturnDocument, dataModel reflect.Type) (interface{}, error)
+	Count(ctx context.Context, databaseName, collectionName string, filter interface{}) (int64, error)
+	EstimatedCount(ctx context.Context, databaseName, collectionName string) (int64, error)
+	Distinct(ctx context.Context, databaseName, collectionName, field string, filter interface{}) ([]interface{}, error)
+	Exists(ctx context.Context, databaseName, collectionName string, filter interface{}) (bool, error)
+	Aggregate(ctx context.Context, databaseName, collectionName string, pipeline interface{}, dataModel reflect.Type, aggregateOptions *AggregateOptions) (interface{}, error)
+	AggregateStream(ctx context.Context, databaseName, collectionName string, pipeline interface{}, aggregateOptions *AggregateOptions) (IDocumentCursor, error)
+	EnsureIndexes(ctx context.Context, databaseName, collectionName string, indexes []IndexSpec) ([]string, error)
+	ListIndexes(ctx context.Context, databaseName, collectionName string) ([]IndexSpec, error)
+	DropIndex(ctx context.Context, databaseName, collectionName, indexName string) error
+	SetValidator(ctx context.Context, databaseName, collectionName string, schema interface{}) error
+	WithTransaction(ctx context.Context, fn func(tx IDocumentTx) error) error
+	BulkWrite(ctx context.Context, databaseName, collectionName string, models []WriteModel, ordered bool) (*BulkWriteResult, error)
+	Watch(ctx context.Context, databaseName, collectionName string, pipeline interface{}, watchOptions *WatchOptions) (<-chan ChangeEvent, error)
+}
+
+// IDocumentTx document operations bound to a transaction
+// The operations run in the context given to WithTransaction.
+type IDocumentTx interface {
+	Create(databaseName, collectionName string, documents []interface{}) (interface{}, error)
+	Read(databaseName, collectionName string, filter interface{}, limit int64, dataModel reflect.Type) (interface{}, error)
+	Update(databaseName, collectionName string, filter, update interface{}) (interface{}, error)
+	Delete(databaseName, collectionName string, filter interface{}) (interface{}, error)
+	FindOne(databaseName, collectionName string, filter interface{}, dataModel reflect.Type) (interface{}, error)
+	UpdateOne(databaseName, collectionName string, filter, update interface{}) (interface{}, error)
+	ReplaceOne(databaseName, collectionName string, filter, replacement interface{}) (interface{}, error)
+	DeleteOne(databaseName, collectionName string, filter interface{}) (interface{}, error)
+	Upsert(databaseName, collectionName string, filter, update interface{}) (interface{}, error)
+	FindOneAndUpdate(databaseName, collectionName string, filter, update interface{}, returnDocument ReturnDocument, dataModel reflect.Type) (interface{}, error)
+}
+
+var (
+	// ErrDocumentNotFound is returned when no document matches the filter of a single-document operation
+	ErrDocumentNotFound = errors.New("document not found")
+	// ErrBulkWrite is returned when operations of a bulk write fail, the result tells which ones
+	ErrBulkWrite = errors.New("bulk write operations failed")
+	// ErrVersionConflict is returned by versioned updates when the stored version differs from the expected one
+	ErrVersionConflict = errors.New("document version conflict")
+	// ErrSchemaValidation is returned when a document does not match the schema of its collection
+	ErrSchemaValidation = errors.New("document failed validation")
+)
+
+// ReturnDocument selects the version of the document returned by FindOneAndUpdate
+type ReturnDocument int
+
+const (
+	// ReturnBefore returns the document as it was before the update
+	ReturnBefore ReturnDocument = iota
+	// ReturnAfter returns the updated document
+	ReturnAfter
+)
+
+// IDocumentCursor iterates over documents one at a time, keeping only the current batch in memory
+type IDocumentCursor interface {
+	// Next moves to the next document and reports whether there is one
+	Next(ctx context.Context) bool
+	// Decode decodes the current document into value, a pointer
+	Decode(value interface{}) error
+	// Err returns the error that stopped the iteration, if any
+	Err() error
+	// Close releases the cursor
+	Close(ctx context.Context) error
+}
+
+// FindOptions model for paginated document reads
+type FindOptions struct {
+	// Skip is the number of documents skipped before the page
+	Skip int64 `json:"skip,omitempty"`
+	// Limit is the page size, zero returns every remaining document
+	Limit int64 `json:"limit,omitempty"`
+	// Sort lists the sort fields by priority, _id is always appended as a tie-breaker
+	Sort []SortField `json:"sort,omitempty"`
+	// Projection lists the fields returned, every field is returned when empty
+	Projection []string `json:"projection,omitempty"`
+	// Cursor is the NextCursor of the previous page, for keyset pagination
+	Cursor string `json:"cursor,omitempty"`
+	// IncludeTotal counts the documents matching the filter, ignoring Skip, Limit and Cursor
+	IncludeTotal bool `json:"includeTotal,omitempty"`
+	// BatchSize is the number of documents a stream fetches per round trip, zero uses the server default
+	BatchSize int32 `json:"batchSize,omitempty"`
+}
+
+// WriteOperation is the kind of a bulk write operation
+type WriteOperation int
+
+const (
+	// WriteInsertOne inserts Document
+	WriteInsertOne WriteOperation = iota
+	// WriteUpdateOne applies Update to the first document matching Filter
+	WriteUpdateOne
+	// WriteUpdateMany applies Update to every document matching Filter
+	WriteUpdateMany
+	// WriteReplaceOne replaces the first document matching Filter with Document
+	WriteReplaceOne
+	// WriteDeleteOne removes the first document matching Filter
+	WriteDeleteOne
+	// WriteDeleteMany removes every document matching Filter
+	WriteDeleteMany
+)
+
+// WriteModel model for a bulk write operation
+type WriteModel struct {
+	Operation WriteOperation `json:"operation"`
+	Filter    interface{}    `json:"filter,omitempty"`
+	// Document is the inserted document or the replacement
+	Document interface{} `json:"document,omitempty"`
+	Update   interface{} `json:"update,omitempty"`
+	// Upsert inserts a document when an update or a replacement matches none
+	Upsert bool `json:"upsert,omitempty"`
+}
+
+// BulkWriteResult model for the outcome of a bulk write
+type BulkWriteResult struct {
+	InsertedCount int64 `json:"insertedCount"`
+	MatchedCount  int64 `json:"matchedCount"`
+	ModifiedCount int64 `json:"modifiedCount"`
+	DeletedCount  int64 `json:"deletedCount"`
+	UpsertedCount int64 `json:"upsertedCount"`
+	// Operations holds the outcome of each write model, in order
+	Operations []WriteModelResult `json:"operations"`
+}
+
+// WriteModelResult model for the outcome of one bulk write operation
+type WriteModelResult struct {
+	// Executed is false for the operations skipped after a failure of an ordered bulk write
+	Executed bool `json:"executed"`
+	// UpsertedID is the _id of the document inserted by an upsert
+	UpsertedID interface{} `json:"upsertedId,omitempty"`
+	// Err is the error of a failed operation
+	Err *WriteError `json:"error,omitempty"`
+}
+
+// WriteError model for the error of a write operation
+type WriteError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("write error %d: %s", e.Code, e.Message)
+}
+
+// validate checks the write model has the fields its operation needs
+func (w WriteModel) validate() error {
+	switch w.Operation {
+	case WriteInsertOne:
+		if w.Document == nil {
+			return fmt.Errorf("insert document cannot be nil")
+		}
+	case WriteUpdateOne, WriteUpdateMany:
+		if w.Filter == nil || w.Update == nil {
+			return fmt.Errorf("filter and update cannot be nil")
+		}
+	case WriteReplaceOne:
+		if w.Filter == nil || w.Document == nil {
+			return fmt.Errorf("filter and replacement cannot be nil")
+		}
+	case WriteDeleteOne, WriteDeleteMany:
+		if w.Filter == nil {
+			return fmt.Errorf("filter cannot be nil")
+		}
+	default:
+		return fmt.Errorf("unknown write operation %d", w.Operation)
+	}
+
+	return nil
+}
+
+// AggregateOptions model for aggregation options
+type AggregateOptions struct {
+	// AllowDiskUse lets stages exceeding the server memory limit write temporary files
+	AllowDiskUse bool `json:"allowDiskUse,omitempty"`
+	// BatchSize is the number of documents a stream fetches per round trip, zero uses the server default
+	BatchSize int32 `json:"batchSize,omitempty"`
+	// MaxTime aborts the aggregation after this duration, zero means no limit
+	MaxTime time.Duration `json:"maxTime,omitempty"` // nanosecond
+}
+
+// IndexSpec model for a collection index
+type IndexSpec struct {
+	// Name defaults to the keys joined with their direction, e.g. "email_1" or "status_1_createdAt_-1"
+	Name string     `json:"name,omitempty"`
+	Keys []IndexKey `json:"keys"`
+	// Unique rejects documents with the same key values
+	Unique bool `json:"unique,omitempty"`
+	// Sparse skips the documents missing the indexed fields
+	Sparse bool `json:"sparse,omitempty"`
+	// ExpireAfter removes documents this long after the date held by the single indexed field, zero disables it
+	// MongoDB expires documents with a one second granularity, so shorter durations are rejected.
+	ExpireAfter time.Duration `json:"expireAfter,omitempty"` // nanosecond
+	// PartialFilter only indexes the documents matching the filter
+	PartialFilter interface{} `json:"partialFilter,omitempty"`
+}
+
+// IndexKey model for an indexed field
+type IndexKey struct {
+	Field      string `json:"field"`
+	Descending bool   `json:"descending,omitempty"`
+	// Text makes the field part of the collection text index
+	Text bool `json:"text,omitempty"`
+}
+
+// indexName returns the index name, generated from its keys when not set
+func (i IndexSpec) indexName() string {
+	if i.Name != "" {
+		return i.Name
+	}
+
+	parts := make([]string, 0, len(i.Keys))
+	for _, key := range i.Keys {
+		switch {
+		case key.Text:
+			parts = append(parts, key.Field+"_text")
+		case key.Descending:
+			parts = append(parts, key.Field+"_-1")
+		default:
+			parts = append(parts, key.Field+"_1")
+		}
+	}
+
+	return strings.Join(parts, "_")
+}
+
+// validate checks the index can be created
+func (i IndexSpec) validate() error {
+	if len(i.Keys) == 0 {
+		return fmt.Errorf("index must have at least one key")
+	}
+
+	if i.ExpireAfter > 0 && len(i.Keys) > 1 {
+		return fmt.Errorf("TTL index %s must have a single key", i.indexName())
+	}
+
+	if i.ExpireAfter > 0 && i.ExpireAfter < time.Second {
+		return fmt.Errorf("TTL index %s must expire documents after at least one second", i.indexName())
+	}
+
+	for _, key := range i.Keys {
+		if key.Field == "" {
+			return fmt.Errorf("index key field cannot be empty")
+		}
+	}
+
+	return nil
+}
+
+// SortField model for a document sort key
+type SortField struct {
+	Field      string `json:"field"`
+	Descending bool   `json:"descending,omitempty"`
+}
+
+// Page model for a page of documents
+type Page struct {
+	// Items holds a pointer to a slice of the data model
+	Items interface{} `json:"items"`
+	// Total is the number of documents matching the filter when IncludeTotal is set
+	Total int64 `json:"total,omitempty"`
+	// NextCursor resumes the read after the last item, it is empty on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 const (
 	// MONGODB database
 	MONGODB = iota
+	// INMEMORYDOC in-process database, for tests and offline use
+	INMEMORYDOC
+	// EMBEDDEDDOC on-disk database, embedded in the process
+	EMBEDDEDDOC
 )
 
 // newNoSQLDocument init instance by factory pattern
@@ -21,6 +300,10 @@ func newNoSQLDocument(databaseCompany int, config *Config) interface{} {
 	switch databaseCompany {
 	case MONGODB:
 		return newMongoDB(&config.MongoDB)
+	case INMEMORYDOC:
+		return newMemoryDocument(&config.InMemoryDocument)
+	case EMBEDDEDDOC:
+		return newEmbeddedDocument(&config.EmbeddedDocument)
 	}
 
 	return nil
@@ -16,6 +16,7 @@ import (
 // BigCacheClient manage all BigCache actions
 type BigCacheClient struct {
 	Client *bigcache.BigCache
+	stats  keyValueStatsRecorder
 }
 
 var (
@@ -26,7 +27,27 @@ var (
 // newBigCache init new instance
 func newBigCache(config *bigcache.Config) INoSQLKeyValue {
 	hasher := &hash.Client{}
-	configAsJSON, err := json.Marshal(config)
+	// bigcache.Config holds callbacks and a logger which cannot be marshalled,
+	// so only the settings identify the session
+	configAsJSON, err := json.Marshal(struct {
+		Shards             int
+		LifeWindow         time.Duration
+		CleanWindow        time.Duration
+		MaxEntriesInWindow int
+		MaxEntrySize       int
+		StatsEnabled       bool
+		Verbose            bool
+		HardMaxCacheSize   int
+	}{
+		config.Shards,
+		config.LifeWindow,
+		config.CleanWindow,
+		config.MaxEntriesInWindow,
+		config.MaxEntrySize,
+		config.StatsEnabled,
+		config.Verbose,
+		config.HardMaxCacheSize,
+	})
 	if err != nil {
 		log.Fatalln("Unable to marshal BigCache configuration: ", err)
 	}
@@ -34,8 +55,28 @@ func newBigCache(config *bigcache.Config) INoSQLKeyValue {
 
 	currentBigCacheClientSession := bigCacheClientSessionMapping[configAsString]
 	if currentBigCacheClientSession == nil {
-		currentBigCacheClientSession = &BigCacheClient{nil}
-		client, err := bigcache.NewBigCache(*config)
+		currentBigCacheClientSession = &BigCacheClient{}
+
+		// Count expirations and evictions unless the caller handles removals with
+		// a callback that takes precedence over OnRemoveWithReason
+		cacheConfig := *config
+		if cacheConfig.OnRemove == nil && cacheConfig.OnRemoveWithMetadata == nil {
+			onRemove := cacheConfig.OnRemoveWithReason
+			cacheConfig.OnRemoveWithReason = func(key string, entry []byte, reason bigcache.RemoveReason) {
+				switch reason {
+				case bigcache.Expired:
+					currentBigCacheClientSession.stats.expiredRecord()
+				case bigcache.NoSpace:
+					currentBigCacheClientSession.stats.evicted(1)
+				}
+
+				if onRemove != nil {
+					onRemove(key, entry, reason)
+				}
+			}
+		}
+
+		client, err := bigcache.NewBigCache(cacheConfig)
 		if err != nil {
 			log.Fatalln("Unable to connect to BigCache: ", err)
 		} else {
@@ -73,17 +114,26 @@ func (bc *BigCacheClient) Middleware(hash hash.IHash) echo.MiddlewareFunc {
 
 // Set new record set key and value
 func (bc *BigCacheClient) Set(key string, value interface{}, expire time.Duration) error {
+	defer bc.stats.set.observe(time.Now())
+
 	b, err := json.Marshal(value)
 	if err != nil {
 		log.Println("Unable to marshal value to []byte: ", err)
 		return errors.New("Unable to marshal value")
 	}
 
-	return bc.Client.Set(key, b)
+	if err := bc.Client.Set(key, b); err != nil {
+		return err
+	}
+
+	bc.stats.stored()
+	return nil
 }
 
 // Get return value based on the key provided
 func (bc *BigCacheClient) Get(key string) (interface{}, error) {
+	defer bc.stats.get.observe(time.Now())
+
 	b, err := bc.Client.Get(key)
 	if err != nil {
 		log.Println("Unable to get value: ", err)
@@ -91,13 +141,18 @@ func (bc *BigCacheClient) Get(key string) (interface{}, error) {
 	}
 
 	var value interface{}
-	json.Unmarshal(b, value)
+	if err := json.Unmarshal(b, &value); err != nil {
+		log.Println("Unable to unmarshal value: ", err)
+		return nil, err
+	}
 
 	return value, nil
 }
 
 // Update new value over the key provided
 func (bc *BigCacheClient) Update(key string, value interface{}, expire time.Duration) error {
+	defer bc.stats.set.observe(time.Now())
+
 	_, err := bc.Client.Get(key)
 	if err != nil {
 		log.Println("Unable to get value: ", err)
@@ -115,7 +170,12 @@ func (bc *BigCacheClient) Update(key string, value interface{}, expire time.Dura
 		return err
 	}
 
-	return bc.Client.Set(key, b)
+	if err := bc.Client.Set(key, b); err != nil {
+		return err
+	}
+
+	bc.stats.stored()
+	return nil
 }
 
 // Append new value base on the key provide, With Append() you can concatenate multiple entries under the same key in an lock optimized way.
@@ -131,7 +191,14 @@ func (bc *BigCacheClient) Append(key string, value interface{}) error {
 
 // Delete function will delete value based on the key provided
 func (bc *BigCacheClient) Delete(key string) error {
-	return bc.Client.Delete(key)
+	defer bc.stats.delete.observe(time.Now())
+
+	if err := bc.Client.Delete(key); err != nil {
+		return err
+	}
+
+	bc.stats.deleted()
+	return nil
 }
 
 // GetNumberOfRecords return number of records
@@ -144,6 +211,19 @@ func (bc *BigCacheClient) GetCapacity() (interface{}, error) {
 	return bc.Client.Capacity(), nil
 }
 
+// Stats returns the cache statistics
+// Hits and misses come from BigCache and include the lookups made by Update
+func (bc *BigCacheClient) Stats() KeyValueStats {
+	stats := bc.stats.snapshot()
+	stats.Records = int64(bc.Client.Len())
+
+	cacheStats := bc.Client.Stats()
+	stats.Hits = cacheStats.Hits
+	stats.Misses = cacheStats.Misses
+
+	return stats
+}
+
 // Close function will close BigCache connection
 func (bc *BigCacheClient) Close() error {
 	return bc.Client.Close()
@@ -0,0 +1,384 @@
+package storage
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+var (
+	// errWrongStructureType is returned when a data structure operation targets a key holding another kind of value
+	errWrongStructureType = errors.New("operation against a key holding the wrong kind of value")
+)
+
+// customHash emulates a redis hash
+type customHash map[string]string
+
+// customList emulates a redis list
+type customList struct {
+	values []string
+}
+
+// customSet emulates a redis set
+type customSet map[string]struct{}
+
+// customSortedSet emulates a redis sorted set
+type customSortedSet map[string]float64
+
+// structure returns the data structure stored at key
+// When the key does not exist, a new structure is stored if create is provided, otherwise nil is returned.
+// Data structures do not expire, like their redis counterparts.
+// The caller must hold cl.mu.
+func (cl *KeyValueCustomClient) structure(key string, create func() interface{}) (interface{}, error) {
+	if cl.client == nil {
+		return nil, errors.New("client is not initialized")
+	}
+
+	if key == "" {
+		return nil, errors.New("key cannot be empty")
+	}
+
+	if obj, err := cl.client.Read(key); err == nil && obj != nil {
+		item, ok := obj.(customKeyValueItem)
+		if !ok {
+			return nil, errors.New("invalid cache item format")
+		}
+
+		if item.expires >= time.Now().UnixNano() {
+			return item.data, nil
+		}
+
+		cl.expire(key)
+	}
+
+	if create == nil {
+		return nil, nil
+	}
+
+	data := create()
+	if err := cl.push(key, customKeyValueItem{data: data, expires: math.MaxInt64}); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// HGet returns the value of the hash field, or an empty string if it does not exist
+func (cl *KeyValueCustomClient) HGet(key, field string) (string, error) {
+	cl.mu.Lock()
+	defer cl.unlock()
+
+	obj, err := cl.structure(key, nil)
+	if err != nil || obj == nil {
+		return "", err
+	}
+
+	hash, ok := obj.(customHash)
+	if !ok {
+		return "", errWrongStructureType
+	}
+
+	return hash[field], nil
+}
+
+// HSet sets the value of the hash field
+func (cl *KeyValueCustomClient) HSet(key, field string, value interface{}) error {
+	stringValue, err := toRedisString(value)
+	if err != nil {
+		return err
+	}
+
+	cl.mu.Lock()
+	defer cl.unlock()
+
+	obj, err := cl.structure(key, func() interface{} { return customHash{} })
+	if err != nil {
+		return err
+	}
+
+	hash, ok := obj.(customHash)
+	if !ok {
+		return errWrongStructureType
+	}
+	hash[field] = stringValue
+
+	return nil
+}
+
+// HDel removes the hash fields and returns the number of fields removed
+// The key is removed with its last field
+func (cl *KeyValueCustomClient) HDel(key string, fields ...string) (int64, error) {
+	cl.mu.Lock()
+	defer cl.unlock()
+
+	obj, err := cl.structure(key, nil)
+	if err != nil || obj == nil {
+		return 0, err
+	}
+
+	hash, ok := obj.(customHash)
+	if !ok {
+		return 0, errWrongStructureType
+	}
+
+	var removed int64
+	for _, field := range fields {
+		if _, exists := hash[field]; exists {
+			delete(hash, field)
+			removed++
+		}
+	}
+
+	if len(hash) == 0 {
+		if err := cl.remove(key); err != nil {
+			return removed, err
+		}
+	}
+
+	return removed, nil
+}
+
+// LPush prepends the values to the list and returns the list length
+func (cl *KeyValueCustomClient) LPush(key string, values ...interface{}) (int64, error) {
+	stringValues, err := toRedisStrings(values)
+	if err != nil {
+		return 0, err
+	}
+
+	cl.mu.Lock()
+	defer cl.unlock()
+
+	obj, err := cl.structure(key, func() interface{} { return &customList{} })
+	if err != nil {
+		return 0, err
+	}
+
+	list, ok := obj.(*customList)
+	if !ok {
+		return 0, errWrongStructureType
+	}
+
+	// Like redis, values are inserted one after the other at the head of the list
+	for _, value := range stringValues {
+		list.values = append([]string{value.(string)}, list.values...)
+	}
+
+	return int64(len(list.values)), nil
+}
+
+// LRange returns the list elements between start and stop (inclusive)
+// Negative indexes are offsets from the end of the list
+func (cl *KeyValueCustomClient) LRange(key string, start, stop int64) ([]string, error) {
+	cl.mu.Lock()
+	defer cl.unlock()
+
+	obj, err := cl.structure(key, nil)
+	if err != nil || obj == nil {
+		return []string{}, err
+	}
+
+	list, ok := obj.(*customList)
+	if !ok {
+		return nil, errWrongStructureType
+	}
+
+	length := int64(len(list.values))
+	if start < 0 {
+		start += length
+	}
+	if stop < 0 {
+		stop += length
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= length {
+		stop = length - 1
+	}
+
+	if start > stop {
+		return []string{}, nil
+	}
+
+	return append([]string{}, list.values[start:stop+1]...), nil
+}
+
+// SAdd adds the members to the set and returns the number of members added
+func (cl *KeyValueCustomClient) SAdd(key string, members ...interface{}) (int64, error) {
+	stringMembers, err := toRedisStrings(members)
+	if err != nil {
+		return 0, err
+	}
+
+	cl.mu.Lock()
+	defer cl.unlock()
+
+	obj, err := cl.structure(key, func() interface{} { return customSet{} })
+	if err != nil {
+		return 0, err
+	}
+
+	set, ok := obj.(customSet)
+	if !ok {
+		return 0, errWrongStructureType
+	}
+
+	var added int64
+	for _, member := range stringMembers {
+		if _, exists := set[member.(string)]; !exists {
+			set[member.(string)] = struct{}{}
+			added++
+		}
+	}
+
+	return added, nil
+}
+
+// SIsMember reports whether the member belongs to the set
+func (cl *KeyValueCustomClient) SIsMember(key string, member interface{}) (bool, error) {
+	stringMember, err := toRedisString(member)
+	if err != nil {
+		return false, err
+	}
+
+	cl.mu.Lock()
+	defer cl.unlock()
+
+	obj, err := cl.structure(key, nil)
+	if err != nil || obj == nil {
+		return false, err
+	}
+
+	set, ok := obj.(customSet)
+	if !ok {
+		return false, errWrongStructureType
+	}
+
+	_, exists := set[stringMember]
+	return exists, nil
+}
+
+// SMembers returns the members of the set
+func (cl *KeyValueCustomClient) SMembers(key string) ([]string, error) {
+	cl.mu.Lock()
+	defer cl.unlock()
+
+	obj, err := cl.structure(key, nil)
+	if err != nil || obj == nil {
+		return []string{}, err
+	}
+
+	set, ok := obj.(customSet)
+	if !ok {
+		return nil, errWrongStructureType
+	}
+
+	members := make([]string, 0, len(set))
+	for member := range set {
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// SRem removes the members from the set and returns the number of members removed
+// The key is removed with its last member
+func (cl *KeyValueCustomClient) SRem(key string, members ...interface{}) (int64, error) {
+	stringMembers, err := toRedisStrings(members)
+	if err != nil {
+		return 0, err
+	}
+
+	cl.mu.Lock()
+	defer cl.unlock()
+
+	obj, err := cl.structure(key, nil)
+	if err != nil || obj == nil {
+		return 0, err
+	}
+
+	set, ok := obj.(customSet)
+	if !ok {
+		return 0, errWrongStructureType
+	}
+
+	var removed int64
+	for _, member := range stringMembers {
+		if _, exists := set[member.(string)]; exists {
+			delete(set, member.(string))
+			removed++
+		}
+	}
+
+	if len(set) == 0 {
+		if err := cl.remove(key); err != nil {
+			return removed, err
+		}
+	}
+
+	return removed, nil
+}
+
+// ZAdd adds or updates the members of the sorted set and returns the number of members added
+func (cl *KeyValueCustomClient) ZAdd(key string, members ...ScoredMember) (int64, error) {
+	if len(members) == 0 {
+		return 0, nil
+	}
+
+	cl.mu.Lock()
+	defer cl.unlock()
+
+	obj, err := cl.structure(key, func() interface{} { return customSortedSet{} })
+	if err != nil {
+		return 0, err
+	}
+
+	sortedSet, ok := obj.(customSortedSet)
+	if !ok {
+		return 0, errWrongStructureType
+	}
+
+	var added int64
+	for _, member := range members {
+		if _, exists := sortedSet[member.Member]; !exists {
+			added++
+		}
+		sortedSet[member.Member] = member.Score
+	}
+
+	return added, nil
+}
+
+// ZRangeByScore returns the members with a score between min and max (inclusive),
+// ordered by score then member like redis
+func (cl *KeyValueCustomClient) ZRangeByScore(key string, min, max float64) ([]ScoredMember, error) {
+	cl.mu.Lock()
+	defer cl.unlock()
+
+	obj, err := cl.structure(key, nil)
+	if err != nil || obj == nil {
+		return []ScoredMember{}, err
+	}
+
+	sortedSet, ok := obj.(customSortedSet)
+	if !ok {
+		return nil, errWrongStructureType
+	}
+
+	members := []ScoredMember{}
+	for member, score := range sortedSet {
+		if score >= min && score <= max {
+			members = append(members, ScoredMember{Member: member, Score: score})
+		}
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].Score != members[j].Score {
+			return members[i].Score < members[j].Score
+		}
+		return members[i].Member < members[j].Member
+	})
+
+	return members, nil
+}
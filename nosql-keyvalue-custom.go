@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -17,6 +18,15 @@ import (
 type KeyValueCustomClient struct {
 	client *linear.Linear
 	close  chan struct{}
+	events keyValueEventHub
+	stats  keyValueStatsRecorder
+
+	// mu serialises writes and read-modify-write operations built on top of the linear
+	mu sync.Mutex
+
+	// pending holds the events queued until no lock is held, so handlers can write to the store
+	pending   []KeyValueEvent
+	pendingMu sync.Mutex
 }
 
 var (
@@ -35,7 +45,7 @@ func newKeyValueCustom(config *CustomKeyValue) INoSQLKeyValue {
 
 	currentCustomClientSession := keyValueCustomClientSessionMapping[configAsString]
 	if currentCustomClientSession == nil {
-		currentCustomClientSession = &KeyValueCustomClient{linear.New(config.MemorySize, config.CleaningEnable), make(chan struct{})}
+		currentCustomClientSession = &KeyValueCustomClient{client: linear.New(config.MemorySize, config.CleaningEnable), close: make(chan struct{})}
 		keyValueCustomClientSessionMapping[configAsString] = currentCustomClientSession
 		log.Println("Key-value custom is ready")
 
@@ -53,11 +63,12 @@ func newKeyValueCustom(config *CustomKeyValue) INoSQLKeyValue {
 
 						if item.expires < time.Now().UnixNano() {
 							k, _ := key.(string)
-							currentCustomClientSession.client.Get(k)
+							currentCustomClientSession.expire(k)
 						}
 
 						return true
 					})
+					currentCustomClientSession.flush()
 
 				case <-currentCustomClientSession.close:
 					return
@@ -91,6 +102,13 @@ func (cl *KeyValueCustomClient) Middleware(hash hash.IHash) echo.MiddlewareFunc
 // Get retrieves a value from the cache based on the key provided
 // Returns nil, nil if the key exists but the value is expired
 func (cl *KeyValueCustomClient) Get(key string) (interface{}, error) {
+	defer cl.flush()
+
+	return cl.get(key)
+}
+
+// get implements Get, the queued events are left to the caller
+func (cl *KeyValueCustomClient) get(key string) (interface{}, error) {
 	if cl.client == nil {
 		return nil, errors.New("client is not initialized")
 	}
@@ -98,13 +116,16 @@ func (cl *KeyValueCustomClient) Get(key string) (interface{}, error) {
 	if key == "" {
 		return nil, errors.New("key cannot be empty")
 	}
+	defer cl.stats.get.observe(time.Now())
 
 	obj, err := cl.client.Read(key)
 	if err != nil {
+		cl.stats.miss()
 		return nil, err
 	}
 	
 	if obj == nil {
+		cl.stats.miss()
 		return nil, errors.New("key not found: " + key)
 	}
 
@@ -117,10 +138,12 @@ func (cl *KeyValueCustomClient) Get(key string) (interface{}, error) {
 	// Check if item is expired
 	if item.expires < time.Now().UnixNano() {
 		// Automatically remove expired items
-		cl.client.Get(key)
+		cl.expire(key)
+		cl.stats.miss()
 		return nil, nil
 	}
 
+	cl.stats.hit()
 	return item.data, nil
 }
 
@@ -170,6 +193,14 @@ func (cl *KeyValueCustomClient) GetMany(keys []string) (map[string]interface{},
 
 // Set creates a new record with the specified key, value, and expiration
 func (cl *KeyValueCustomClient) Set(key string, value interface{}, expire time.Duration) error {
+	cl.mu.Lock()
+	defer cl.unlock()
+
+	return cl.set(key, value, expire)
+}
+
+// set implements Set, the caller must hold cl.mu
+func (cl *KeyValueCustomClient) set(key string, value interface{}, expire time.Duration) error {
 	if cl.client == nil {
 		return errors.New("client is not initialized")
 	}
@@ -181,6 +212,7 @@ func (cl *KeyValueCustomClient) Set(key string, value interface{}, expire time.D
 	if value == nil {
 		return errors.New("value cannot be nil")
 	}
+	defer cl.stats.set.observe(time.Now())
 
 	// Set default expiration if not provided
 	if expire <= 0 {
@@ -194,10 +226,12 @@ func (cl *KeyValueCustomClient) Set(key string, value interface{}, expire time.D
 		expires: expirationTime,
 	}
 	
-	if err := cl.client.Push(key, item); err != nil {
+	if err := cl.push(key, item); err != nil {
 		log.Printf("Unable to push data for key %s: %v", key, err)
 		return err
 	}
+	cl.stats.stored()
+	cl.notify(EventSet, key, value)
 
 	return nil
 }
@@ -205,6 +239,14 @@ func (cl *KeyValueCustomClient) Set(key string, value interface{}, expire time.D
 // Update modifies an existing key with a new value and expiration
 // Returns an error if the key doesn't exist
 func (cl *KeyValueCustomClient) Update(key string, value interface{}, expire time.Duration) error {
+	cl.mu.Lock()
+	defer cl.unlock()
+
+	return cl.update(key, value, expire)
+}
+
+// update implements Update, the caller must hold cl.mu
+func (cl *KeyValueCustomClient) update(key string, value interface{}, expire time.Duration) error {
 	if cl.client == nil {
 		return errors.New("client is not initialized")
 	}
@@ -216,6 +258,7 @@ func (cl *KeyValueCustomClient) Update(key string, value interface{}, expire tim
 	if value == nil {
 		return errors.New("value cannot be nil")
 	}
+	defer cl.stats.set.observe(time.Now())
 
 	// Check if key exists
 	_, err := cl.client.Get(key)
@@ -236,10 +279,12 @@ func (cl *KeyValueCustomClient) Update(key string, value interface{}, expire tim
 		expires: expirationTime,
 	}
 	
-	if err := cl.client.Push(key, item); err != nil {
+	if err := cl.push(key, item); err != nil {
 		log.Printf("Unable to update data for key %s: %v", key, err)
 		return err
 	}
+	cl.stats.stored()
+	cl.notify(EventSet, key, value)
 
 	return nil
 }
@@ -247,6 +292,13 @@ func (cl *KeyValueCustomClient) Update(key string, value interface{}, expire tim
 // Delete removes a key from the cache
 // Returns an error if the key doesn't exist
 func (cl *KeyValueCustomClient) Delete(key string) error {
+	defer cl.flush()
+
+	return cl.delete(key)
+}
+
+// delete implements Delete, the queued events are left to the caller
+func (cl *KeyValueCustomClient) delete(key string) error {
 	if cl.client == nil {
 		return errors.New("client is not initialized")
 	}
@@ -255,22 +307,112 @@ func (cl *KeyValueCustomClient) Delete(key string) error {
 		return errors.New("key cannot be empty")
 	}
 
+	defer cl.stats.delete.observe(time.Now())
+
+	return cl.remove(key)
+}
+
+// remove deletes the record, counts it and notifies delete subscribers
+func (cl *KeyValueCustomClient) remove(key string) error {
 	// The Get method in linear.Linear removes the item if found
-	_, err := cl.client.Get(key)
+	obj, err := cl.client.Get(key)
 	if err != nil {
 		log.Printf("Key %s not found for deletion: %v", key, err)
 		return errors.New("key not found: " + key)
 	}
 
+	if item, ok := obj.(customKeyValueItem); ok {
+		cl.stats.deleted()
+		cl.notify(EventDelete, key, item.data)
+	}
+
 	return nil
 }
 
+// push stores the item, counts the records the linear removed to make room
+// for it and notifies evict subscribers about them.
+// The caller must hold cl.mu.
+func (cl *KeyValueCustomClient) push(key string, item customKeyValueItem) error {
+	numberOfKeys := cl.client.GetNumberOfKeys()
+
+	var candidates map[string]interface{}
+	if cl.events.hasSubscribers(EventEvict) {
+		candidates = cl.evictionCandidates()
+	}
+
+	if err := cl.client.Push(key, item); err != nil {
+		return err
+	}
+
+	// Every push appends the key, so a shorter key list means records were evicted
+	if evicted := numberOfKeys + 1 - cl.client.GetNumberOfKeys(); evicted > 0 {
+		cl.stats.evicted(int64(evicted))
+	}
+
+	for k, obj := range candidates {
+		if _, exists := cl.client.IsExits(k); exists {
+			continue
+		}
+
+		if evicted, ok := obj.(customKeyValueItem); ok {
+			cl.notify(EventEvict, k, evicted.data)
+		}
+	}
+
+	return nil
+}
+
+// evictionCandidates returns the records the linear may remove to make room for a new one
+// The linear removes records from the head of its key list until the new one fits, and
+// every record takes as much room as the new one, so only the head records covering the
+// missing room are returned.
+// The caller must hold cl.mu.
+func (cl *KeyValueCustomClient) evictionCandidates() map[string]interface{} {
+	keys := cl.client.Getkeys()
+	if len(keys) == 0 {
+		return nil
+	}
+
+	itemSize, _ := cl.client.IsExits(keys[0])
+	missing := cl.client.GetLinearCurrentSize() + itemSize - cl.client.GetLinearSizes()
+
+	candidates := make(map[string]interface{})
+	for i := 0; i < len(keys) && missing > 0; i++ {
+		size, exists := cl.client.IsExits(keys[i])
+		if !exists {
+			continue
+		}
+
+		if obj, err := cl.client.Read(keys[i]); err == nil && obj != nil {
+			candidates[keys[i]] = obj
+		}
+		missing -= size
+	}
+
+	return candidates
+}
+
+// expire removes a record after its expiration time, counts it and notifies expire subscribers
+// Nothing is reported when a concurrent operation removed the record first.
+func (cl *KeyValueCustomClient) expire(key string) {
+	obj, err := cl.client.Get(key)
+	if err != nil || obj == nil {
+		return
+	}
+
+	if item, ok := obj.(customKeyValueItem); ok {
+		cl.stats.expiredRecord()
+		cl.notify(EventExpire, key, item.data)
+	}
+}
+
 // Range iterates over all non-expired items in the cache
 // The provided function is called for each key-value pair
 func (cl *KeyValueCustomClient) Range(f func(key, value interface{}) bool) {
 	if cl.client == nil || f == nil {
 		return
 	}
+	defer cl.flush()
 
 	fn := func(key, value interface{}) bool {
 		// Skip if value is nil
@@ -289,7 +431,7 @@ func (cl *KeyValueCustomClient) Range(f func(key, value interface{}) bool) {
 		if item.expires > 0 && item.expires < time.Now().UnixNano() {
 			// Optionally remove expired items during iteration
 			if k, ok := key.(string); ok {
-				cl.client.Get(k)
+				cl.expire(k)
 			}
 			return true
 		}
@@ -301,6 +443,118 @@ func (cl *KeyValueCustomClient) Range(f func(key, value interface{}) bool) {
 	cl.client.Range(fn)
 }
 
+// notify queues the event for the subscribers of its type
+// Events are emitted by flush once no lock is held, so handlers can write to the store.
+func (cl *KeyValueCustomClient) notify(eventType KeyValueEventType, key string, value interface{}) {
+	if !cl.events.hasSubscribers(eventType) {
+		return
+	}
+
+	cl.pendingMu.Lock()
+	cl.pending = append(cl.pending, KeyValueEvent{Type: eventType, Key: key, Value: value})
+	cl.pendingMu.Unlock()
+}
+
+// flush emits the queued events
+// It must not be called while holding cl.mu.
+func (cl *KeyValueCustomClient) flush() {
+	cl.pendingMu.Lock()
+	events := cl.pending
+	cl.pending = nil
+	cl.pendingMu.Unlock()
+
+	for _, event := range events {
+		cl.events.emit(event.Type, event.Key, event.Value)
+	}
+}
+
+// unlock releases cl.mu and emits the events queued while it was held
+func (cl *KeyValueCustomClient) unlock() {
+	cl.mu.Unlock()
+	cl.flush()
+}
+
+// OnSet registers a handler called after a record is created or updated
+func (cl *KeyValueCustomClient) OnSet(handler KeyValueEventHandler) {
+	cl.events.subscribe(EventSet, handler)
+}
+
+// OnDelete registers a handler called after a record is deleted
+func (cl *KeyValueCustomClient) OnDelete(handler KeyValueEventHandler) {
+	cl.events.subscribe(EventDelete, handler)
+}
+
+// OnExpire registers a handler called after an expired record is removed,
+// either by the cleaning process or when it is accessed
+func (cl *KeyValueCustomClient) OnExpire(handler KeyValueEventHandler) {
+	cl.events.subscribe(EventExpire, handler)
+}
+
+// OnEvict registers a handler called after a record is removed to free up memory
+func (cl *KeyValueCustomClient) OnEvict(handler KeyValueEventHandler) {
+	cl.events.subscribe(EventEvict, handler)
+}
+
+// acquireLock stores the lock record if no live record exists for the key
+func (cl *KeyValueCustomClient) acquireLock(key, token string, ttl time.Duration) (bool, error) {
+	if cl.client == nil {
+		return false, errors.New("client is not initialized")
+	}
+
+	cl.mu.Lock()
+	defer cl.unlock()
+
+	if val, err := cl.get(key); err == nil && val != nil {
+		return false, nil
+	}
+
+	if err := cl.set(key, token, ttl); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// refreshLock resets the lock record expiration if it still holds the token
+func (cl *KeyValueCustomClient) refreshLock(key, token string, ttl time.Duration) (bool, error) {
+	if cl.client == nil {
+		return false, errors.New("client is not initialized")
+	}
+
+	cl.mu.Lock()
+	defer cl.unlock()
+
+	if val, err := cl.get(key); err != nil || val != token {
+		return false, nil
+	}
+
+	if err := cl.update(key, token, ttl); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// releaseLock deletes the lock record if it still holds the token
+func (cl *KeyValueCustomClient) releaseLock(key, token string) (bool, error) {
+	if cl.client == nil {
+		return false, errors.New("client is not initialized")
+	}
+
+	cl.mu.Lock()
+	defer cl.unlock()
+
+	if val, err := cl.get(key); err != nil || val != token {
+		return false, nil
+	}
+
+	if err := cl.delete(key); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 // GetNumberOfRecords returns the total number of records in the cache
 // Note: This includes expired records that haven't been cleaned up yet
 func (cl *KeyValueCustomClient) GetNumberOfRecords() int {
@@ -318,6 +572,13 @@ func (cl *KeyValueCustomClient) GetCapacity() (interface{}, error) {
 	return cl.client.GetLinearCurrentSize(), nil
 }
 
+// Stats returns the cache statistics
+func (cl *KeyValueCustomClient) Stats() KeyValueStats {
+	stats := cl.stats.snapshot()
+	stats.Records = int64(cl.GetNumberOfRecords())
+	return stats
+}
+
 // Close stops the background cleaning process and frees up resources
 func (cl *KeyValueCustomClient) Close() error {
 	if cl.client == nil {
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"sync"
+)
+
+// KeyValueEventType defines the type of key-value event
+type KeyValueEventType int
+
+const (
+	// EventSet is emitted when a record is created or updated
+	EventSet KeyValueEventType = iota
+	// EventDelete is emitted when a record is deleted explicitly
+	EventDelete
+	// EventExpire is emitted when a record is removed because its expiration time has passed
+	EventExpire
+	// EventEvict is emitted when a record is removed to free up memory
+	EventEvict
+)
+
+// String returns the name of the event type
+func (t KeyValueEventType) String() string {
+	switch t {
+	case EventSet:
+		return "set"
+	case EventDelete:
+		return "delete"
+	case EventExpire:
+		return "expire"
+	case EventEvict:
+		return "evict"
+	default:
+		return "unknown"
+	}
+}
+
+// KeyValueEvent model for key-value event
+// Value is only populated when the backend knows it (e.g. the custom store),
+// keyspace notifications from Redis carry the key only
+type KeyValueEvent struct {
+	Type  KeyValueEventType
+	Key   string
+	Value interface{}
+}
+
+// KeyValueEventHandler is called for every event the handler is subscribed to
+// Handlers are called synchronously, so they should not block
+type KeyValueEventHandler func(event KeyValueEvent)
+
+// IKeyValueNotifier is implemented by key-value backends that publish record events
+type IKeyValueNotifier interface {
+	OnSet(handler KeyValueEventHandler)
+	OnDelete(handler KeyValueEventHandler)
+	OnExpire(handler KeyValueEventHandler)
+	OnEvict(handler KeyValueEventHandler)
+}
+
+// keyValueEventHub keeps the subscribers of each event type
+type keyValueEventHub struct {
+	mu       sync.RWMutex
+	handlers map[KeyValueEventType][]KeyValueEventHandler
+}
+
+// subscribe registers a handler for the event type
+func (h *keyValueEventHub) subscribe(eventType KeyValueEventType, handler KeyValueEventHandler) {
+	if handler == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.handlers == nil {
+		h.handlers = make(map[KeyValueEventType][]KeyValueEventHandler)
+	}
+	h.handlers[eventType] = append(h.handlers[eventType], handler)
+}
+
+// hasSubscribers reports whether at least one handler listens to the event type
+func (h *keyValueEventHub) hasSubscribers(eventType KeyValueEventType) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.handlers[eventType]) > 0
+}
+
+// emit calls every handler subscribed to the event type
+func (h *keyValueEventHub) emit(eventType KeyValueEventType, key string, value interface{}) {
+	h.mu.RLock()
+	handlers := h.handlers[eventType]
+	h.mu.RUnlock()
+
+	event := KeyValueEvent{Type: eventType, Key: key, Value: value}
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
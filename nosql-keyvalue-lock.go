@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrLockNotAcquired is returned when a lock is held by another owner
+	ErrLockNotAcquired = errors.New("lock not acquired")
+	// ErrLockNotHeld is returned when a lease is refreshed or released after it was lost
+	ErrLockNotHeld = errors.New("lock not held")
+	// ErrLockUnsupported is returned when the key-value backend cannot provide locks
+	ErrLockUnsupported = errors.New("key-value backend does not support locks")
+)
+
+const (
+	// lockKeyPrefix namespaces lock records in the key-value store
+	lockKeyPrefix = "lock:"
+	// defaultLockRetryInterval is the delay between two acquisition attempts
+	defaultLockRetryInterval = 100 * time.Millisecond
+)
+
+// lockBackend is implemented by key-value backends able to store fenced lock records
+// Every method reports whether the operation was applied
+type lockBackend interface {
+	acquireLock(key, token string, ttl time.Duration) (bool, error)
+	refreshLock(key, token string, ttl time.Duration) (bool, error)
+	releaseLock(key, token string) (bool, error)
+}
+
+// Locker provides mutual exclusion on top of a key-value store
+type Locker struct {
+	backend lockBackend
+
+	// RetryInterval is the delay between two acquisition attempts in Lock
+	RetryInterval time.Duration
+}
+
+// Lease is a lock held by the current owner until it expires or is released
+type Lease struct {
+	locker *Locker
+	name   string
+	token  string
+}
+
+// NewLocker returns a locker using the key-value store provided
+// REDIS and CUSTOM backends are supported
+func NewLocker(store INoSQLKeyValue) (*Locker, error) {
+	backend, ok := store.(lockBackend)
+	if !ok {
+		return nil, ErrLockUnsupported
+	}
+
+	return &Locker{backend: backend, RetryInterval: defaultLockRetryInterval}, nil
+}
+
+// TryLock acquires the lock once and returns ErrLockNotAcquired if it is held by another owner
+func (l *Locker) TryLock(name string, ttl time.Duration) (*Lease, error) {
+	if name == "" {
+		return nil, errors.New("lock name cannot be empty")
+	}
+
+	if ttl <= 0 {
+		return nil, errors.New("lock ttl must be positive")
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	acquired, err := l.backend.acquireLock(lockKeyPrefix+name, token, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	if !acquired {
+		return nil, ErrLockNotAcquired
+	}
+
+	return &Lease{locker: l, name: name, token: token}, nil
+}
+
+// Lock waits until the lock is acquired or the context is done
+func (l *Locker) Lock(ctx context.Context, name string, ttl time.Duration) (*Lease, error) {
+	retryInterval := l.RetryInterval
+	if retryInterval <= 0 {
+		retryInterval = defaultLockRetryInterval
+	}
+
+	for {
+		lease, err := l.TryLock(name, ttl)
+		if err == nil || !errors.Is(err, ErrLockNotAcquired) {
+			return lease, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %v", ErrLockNotAcquired, ctx.Err())
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// Name returns the lock name
+func (le *Lease) Name() string {
+	return le.name
+}
+
+// Token returns the fencing token identifying this owner
+func (le *Lease) Token() string {
+	return le.token
+}
+
+// Refresh extends the lease with a new ttl
+// Returns ErrLockNotHeld if the lease expired or was taken by another owner
+func (le *Lease) Refresh(ttl time.Duration) error {
+	if ttl <= 0 {
+		return errors.New("lock ttl must be positive")
+	}
+
+	refreshed, err := le.locker.backend.refreshLock(lockKeyPrefix+le.name, le.token, ttl)
+	if err != nil {
+		return err
+	}
+
+	if !refreshed {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+// Unlock releases the lock if it is still held by this owner
+// Returns ErrLockNotHeld if the lease expired or was taken by another owner
+func (le *Lease) Unlock() error {
+	released, err := le.locker.backend.releaseLock(lockKeyPrefix+le.name, le.token)
+	if err != nil {
+		return err
+	}
+
+	if !released {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
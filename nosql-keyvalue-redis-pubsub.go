@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"reflect"
+
+	"github.com/go-redis/redis"
+)
+
+// RedisMessage model for a message received from a redis channel
+type RedisMessage struct {
+	Channel string
+	Pattern string
+	Payload string
+	// Data holds a pointer to the decoded payload when a data model is provided,
+	// otherwise the raw payload
+	Data interface{}
+	// Err is set when the payload cannot be decoded into the data model
+	Err error
+}
+
+// Publish posts a message to the channel and returns the number of clients that received it
+// Strings and byte slices are sent as is, other values are marshalled to JSON
+func (r *RedisClient) Publish(channel string, message interface{}) (int64, error) {
+	if r.UniversalClient == nil {
+		return 0, errors.New("redis client is not initialized")
+	}
+
+	if channel == "" {
+		return 0, errors.New("channel cannot be empty")
+	}
+
+	payload, err := toRedisString(message)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.UniversalClient.Publish(channel, payload).Result()
+}
+
+// Subscribe listens to the channels until the context is done
+// Payloads are decoded from JSON into a new value of dataModel when it is not nil.
+// The connection is re-established automatically when it is lost, messages
+// published in the meantime are not delivered.
+func (r *RedisClient) Subscribe(ctx context.Context, dataModel reflect.Type, channels ...string) (<-chan RedisMessage, error) {
+	if r.UniversalClient == nil {
+		return nil, errors.New("redis client is not initialized")
+	}
+
+	if len(channels) == 0 {
+		return nil, errors.New("channels cannot be empty")
+	}
+
+	return r.listen(ctx, r.UniversalClient.Subscribe(channels...), dataModel)
+}
+
+// PSubscribe listens to the channels matching the patterns until the context is done
+// It behaves like Subscribe
+func (r *RedisClient) PSubscribe(ctx context.Context, dataModel reflect.Type, patterns ...string) (<-chan RedisMessage, error) {
+	if r.UniversalClient == nil {
+		return nil, errors.New("redis client is not initialized")
+	}
+
+	if len(patterns) == 0 {
+		return nil, errors.New("patterns cannot be empty")
+	}
+
+	return r.listen(ctx, r.UniversalClient.PSubscribe(patterns...), dataModel)
+}
+
+// listen waits for the subscription confirmation and forwards the decoded messages
+func (r *RedisClient) listen(ctx context.Context, pubsub *redis.PubSub, dataModel reflect.Type) (<-chan RedisMessage, error) {
+	if ctx == nil {
+		ctx = GetContext()
+	}
+
+	// Wait for confirmation that subscription is created before publishing anything
+	if _, err := pubsub.Receive(); err != nil {
+		log.Printf("Unable to subscribe to redis channels: %v", err)
+		pubsub.Close()
+		return nil, err
+	}
+
+	messages := make(chan RedisMessage)
+	go func() {
+		defer close(messages)
+		defer pubsub.Close()
+
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+
+				select {
+				case messages <- decodeRedisMessage(msg, dataModel):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return messages, nil
+}
+
+// decodeRedisMessage converts a pub/sub message and decodes its payload into the data model
+func decodeRedisMessage(msg *redis.Message, dataModel reflect.Type) RedisMessage {
+	message := RedisMessage{
+		Channel: msg.Channel,
+		Pattern: msg.Pattern,
+		Payload: msg.Payload,
+		Data:    msg.Payload,
+	}
+
+	if dataModel == nil {
+		return message
+	}
+
+	data := reflect.New(dataModel).Interface()
+	if err := json.Unmarshal([]byte(msg.Payload), data); err != nil {
+		message.Err = err
+		return message
+	}
+	message.Data = data
+
+	return message
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// StreamMessage model for a redis stream entry
+type StreamMessage struct {
+	Stream string
+	ID     string
+	Values map[string]interface{}
+}
+
+// StreamAdd appends an entry to the stream and returns its ID
+// The stream is trimmed to about maxLen entries when maxLen is positive
+func (r *RedisClient) StreamAdd(stream string, values map[string]interface{}, maxLen int64) (string, error) {
+	if r.UniversalClient == nil {
+		return "", errors.New("redis client is not initialized")
+	}
+
+	if stream == "" {
+		return "", errors.New("stream cannot be empty")
+	}
+
+	if len(values) == 0 {
+		return "", errors.New("values cannot be empty")
+	}
+
+	return r.UniversalClient.XAdd(&redis.XAddArgs{
+		Stream:       stream,
+		MaxLenApprox: maxLen,
+		Values:       values,
+	}).Result()
+}
+
+// StreamCreateGroup creates a consumer group reading the stream from the start ID
+// ("0" for the whole history, "$" for new entries only). The stream is created
+// if needed and an existing group is not an error.
+func (r *RedisClient) StreamCreateGroup(stream, group, start string) error {
+	if r.UniversalClient == nil {
+		return errors.New("redis client is not initialized")
+	}
+
+	if stream == "" || group == "" {
+		return errors.New("stream and group cannot be empty")
+	}
+
+	if start == "" {
+		start = "$"
+	}
+
+	err := r.UniversalClient.XGroupCreateMkStream(stream, group, start).Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+
+	return err
+}
+
+// StreamReadGroup reads up to count entries never delivered to the group
+// It blocks up to block when no entry is available, a zero block returns immediately
+func (r *RedisClient) StreamReadGroup(stream, group, consumer string, count int64, block time.Duration) ([]StreamMessage, error) {
+	if r.UniversalClient == nil {
+		return nil, errors.New("redis client is not initialized")
+	}
+
+	if stream == "" || group == "" || consumer == "" {
+		return nil, errors.New("stream, group and consumer cannot be empty")
+	}
+
+	// go-redis leaves out BLOCK for negative durations
+	if block <= 0 {
+		block = -1
+	}
+
+	streams, err := r.UniversalClient.XReadGroup(&redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{stream, ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Unable to read stream %s for group %s: %v", stream, group, err)
+		return nil, err
+	}
+
+	var messages []StreamMessage
+	for _, s := range streams {
+		messages = append(messages, toStreamMessages(s.Stream, s.Messages)...)
+	}
+
+	return messages, nil
+}
+
+// StreamAck acknowledges processed entries and returns the number of entries acknowledged
+func (r *RedisClient) StreamAck(stream, group string, ids ...string) (int64, error) {
+	if r.UniversalClient == nil {
+		return 0, errors.New("redis client is not initialized")
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	return r.UniversalClient.XAck(stream, group, ids...).Result()
+}
+
+// StreamClaimPending transfers to the consumer up to count entries delivered to the group
+// but not acknowledged for at least minIdle, so entries of crashed consumers are processed again
+func (r *RedisClient) StreamClaimPending(stream, group, consumer string, minIdle time.Duration, count int64) ([]StreamMessage, error) {
+	if r.UniversalClient == nil {
+		return nil, errors.New("redis client is not initialized")
+	}
+
+	if stream == "" || group == "" || consumer == "" {
+		return nil, errors.New("stream, group and consumer cannot be empty")
+	}
+
+	if count <= 0 {
+		count = 100
+	}
+
+	pending, err := r.UniversalClient.XPendingExt(&redis.XPendingExtArgs{
+		Stream: stream,
+		Group:  group,
+		Start:  "-",
+		End:    "+",
+		Count:  count,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Unable to list pending entries of stream %s for group %s: %v", stream, group, err)
+		return nil, err
+	}
+
+	var ids []string
+	for _, entry := range pending {
+		if entry.Idle >= minIdle {
+			ids = append(ids, entry.Id)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	claimed, err := r.UniversalClient.XClaim(&redis.XClaimArgs{
+		Stream:   stream,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Messages: ids,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Unable to claim pending entries of stream %s for group %s: %v", stream, group, err)
+		return nil, err
+	}
+
+	return toStreamMessages(stream, claimed), nil
+}
+
+// toStreamMessages converts go-redis stream entries
+func toStreamMessages(stream string, entries []redis.XMessage) []StreamMessage {
+	messages := make([]StreamMessage, 0, len(entries))
+	for _, entry := range entries {
+		messages = append(messages, StreamMessage{Stream: stream, ID: entry.ID, Values: entry.Values})
+	}
+
+	return messages
+}
@@ -0,0 +1,240 @@
+package storage
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/go-redis/redis"
+)
+
+// HGet returns the value of the hash field, or an empty string if it does not exist
+func (r *RedisClient) HGet(key, field string) (string, error) {
+	if r.UniversalClient == nil {
+		return "", errors.New("redis client is not initialized")
+	}
+
+	if key == "" {
+		return "", errors.New("key cannot be empty")
+	}
+
+	result, err := r.UniversalClient.HGet(key, field).Result()
+	if err == redis.Nil {
+		return "", nil // Field does not exist
+	}
+	return result, err
+}
+
+// HSet sets the value of the hash field
+func (r *RedisClient) HSet(key, field string, value interface{}) error {
+	if r.UniversalClient == nil {
+		return errors.New("redis client is not initialized")
+	}
+
+	if key == "" {
+		return errors.New("key cannot be empty")
+	}
+
+	stringValue, err := toRedisString(value)
+	if err != nil {
+		return err
+	}
+
+	return r.UniversalClient.HSet(key, field, stringValue).Err()
+}
+
+// HDel removes the hash fields and returns the number of fields removed
+func (r *RedisClient) HDel(key string, fields ...string) (int64, error) {
+	if r.UniversalClient == nil {
+		return 0, errors.New("redis client is not initialized")
+	}
+
+	if key == "" {
+		return 0, errors.New("key cannot be empty")
+	}
+
+	if len(fields) == 0 {
+		return 0, nil
+	}
+
+	return r.UniversalClient.HDel(key, fields...).Result()
+}
+
+// LPush prepends the values to the list and returns the list length
+func (r *RedisClient) LPush(key string, values ...interface{}) (int64, error) {
+	if r.UniversalClient == nil {
+		return 0, errors.New("redis client is not initialized")
+	}
+
+	if key == "" {
+		return 0, errors.New("key cannot be empty")
+	}
+
+	stringValues, err := toRedisStrings(values)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.UniversalClient.LPush(key, stringValues...).Result()
+}
+
+// LRange returns the list elements between start and stop (inclusive)
+// Negative indexes are offsets from the end of the list
+func (r *RedisClient) LRange(key string, start, stop int64) ([]string, error) {
+	if r.UniversalClient == nil {
+		return nil, errors.New("redis client is not initialized")
+	}
+
+	if key == "" {
+		return nil, errors.New("key cannot be empty")
+	}
+
+	return r.UniversalClient.LRange(key, start, stop).Result()
+}
+
+// SAdd adds the members to the set and returns the number of members added
+func (r *RedisClient) SAdd(key string, members ...interface{}) (int64, error) {
+	if r.UniversalClient == nil {
+		return 0, errors.New("redis client is not initialized")
+	}
+
+	if key == "" {
+		return 0, errors.New("key cannot be empty")
+	}
+
+	stringMembers, err := toRedisStrings(members)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.UniversalClient.SAdd(key, stringMembers...).Result()
+}
+
+// SIsMember reports whether the member belongs to the set
+func (r *RedisClient) SIsMember(key string, member interface{}) (bool, error) {
+	if r.UniversalClient == nil {
+		return false, errors.New("redis client is not initialized")
+	}
+
+	if key == "" {
+		return false, errors.New("key cannot be empty")
+	}
+
+	stringMember, err := toRedisString(member)
+	if err != nil {
+		return false, err
+	}
+
+	return r.UniversalClient.SIsMember(key, stringMember).Result()
+}
+
+// SMembers returns the members of the set
+func (r *RedisClient) SMembers(key string) ([]string, error) {
+	if r.UniversalClient == nil {
+		return nil, errors.New("redis client is not initialized")
+	}
+
+	if key == "" {
+		return nil, errors.New("key cannot be empty")
+	}
+
+	return r.UniversalClient.SMembers(key).Result()
+}
+
+// SRem removes the members from the set and returns the number of members removed
+func (r *RedisClient) SRem(key string, members ...interface{}) (int64, error) {
+	if r.UniversalClient == nil {
+		return 0, errors.New("redis client is not initialized")
+	}
+
+	if key == "" {
+		return 0, errors.New("key cannot be empty")
+	}
+
+	if len(members) == 0 {
+		return 0, nil
+	}
+
+	stringMembers, err := toRedisStrings(members)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.UniversalClient.SRem(key, stringMembers...).Result()
+}
+
+// ZAdd adds or updates the members of the sorted set and returns the number of members added
+func (r *RedisClient) ZAdd(key string, members ...ScoredMember) (int64, error) {
+	if r.UniversalClient == nil {
+		return 0, errors.New("redis client is not initialized")
+	}
+
+	if key == "" {
+		return 0, errors.New("key cannot be empty")
+	}
+
+	if len(members) == 0 {
+		return 0, nil
+	}
+
+	zMembers := make([]redis.Z, 0, len(members))
+	for _, member := range members {
+		zMembers = append(zMembers, redis.Z{Score: member.Score, Member: member.Member})
+	}
+
+	return r.UniversalClient.ZAdd(key, zMembers...).Result()
+}
+
+// ZRangeByScore returns the members with a score between min and max (inclusive),
+// ordered by score
+func (r *RedisClient) ZRangeByScore(key string, min, max float64) ([]ScoredMember, error) {
+	if r.UniversalClient == nil {
+		return nil, errors.New("redis client is not initialized")
+	}
+
+	if key == "" {
+		return nil, errors.New("key cannot be empty")
+	}
+
+	zMembers, err := r.UniversalClient.ZRangeByScoreWithScores(key, redis.ZRangeBy{
+		Min: formatRedisScore(min),
+		Max: formatRedisScore(max),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]ScoredMember, 0, len(zMembers))
+	for _, z := range zMembers {
+		member, _ := z.Member.(string)
+		members = append(members, ScoredMember{Member: member, Score: z.Score})
+	}
+
+	return members, nil
+}
+
+// formatRedisScore formats a score the way redis parses range bounds
+func formatRedisScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "+inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	default:
+		return strconv.FormatFloat(score, 'f', -1, 64)
+	}
+}
+
+// toRedisStrings converts a list of values with toRedisString
+func toRedisStrings(values []interface{}) ([]interface{}, error) {
+	stringValues := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		stringValue, err := toRedisString(value)
+		if err != nil {
+			return nil, err
+		}
+		stringValues = append(stringValues, stringValue)
+	}
+
+	return stringValues, nil
+}
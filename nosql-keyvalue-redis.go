@@ -1,10 +1,17 @@
 package storage
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis"
@@ -15,12 +22,35 @@ import (
 
 // RedisClient manage all redis actions
 type RedisClient struct {
+	// Client is the single-node or sentinel client, it is nil in cluster mode
 	Client *redis.Client
+	// UniversalClient is the client used in every mode
+	UniversalClient redis.UniversalClient
+	config          *Redis
+
+	events        keyValueEventHub
+	notifications *redis.PubSub
+	stats         keyValueStatsRecorder
+	notifyOnce    sync.Once
 }
 
 var (
 	// redisClientSessionMapping singleton pattern
 	redisClientSessionMapping = make(map[string]*RedisClient)
+
+	// redisRefreshLockScript extends the lock expiration only if the caller still owns it
+	redisRefreshLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	// redisReleaseLockScript deletes the lock only if the caller still owns it
+	redisReleaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
 )
 
 // newRedis init new instance
@@ -34,12 +64,13 @@ func newRedis(config *Redis) INoSQLKeyValue {
 
 	currentRedisClientSession := redisClientSessionMapping[configAsString]
 	if currentRedisClientSession == nil {
-		currentRedisClientSession = &RedisClient{nil}
+		currentRedisClientSession = &RedisClient{config: config}
 		client, err := currentRedisClientSession.connect(config)
 		if err != nil {
 			log.Fatalln("Unable to connect to Redis: ", err)
 		} else {
-			currentRedisClientSession.Client = client
+			currentRedisClientSession.UniversalClient = client
+			currentRedisClientSession.Client, _ = client.(*redis.Client)
 			redisClientSessionMapping[configAsString] = currentRedisClientSession
 			log.Println("Connected to Redis")
 		}
@@ -48,14 +79,13 @@ func newRedis(config *Redis) INoSQLKeyValue {
 	return currentRedisClientSession
 }
 
-func (r *RedisClient) connect(data *Redis) (client *redis.Client, err error) {
-	if r.Client == nil {
-		client = redis.NewClient(&redis.Options{
-			Addr:       data.Host,
-			Password:   data.Password,
-			DB:         data.DB,
-			MaxRetries: data.MaxRetries,
-		})
+func (r *RedisClient) connect(data *Redis) (client redis.UniversalClient, err error) {
+	if r.UniversalClient == nil {
+		client, err = newRedisUniversalClient(data)
+		if err != nil {
+			log.Fatalln("Unable to configure Redis client: ", err)
+			return nil, err
+		}
 
 		_, err := client.Ping().Result()
 		if err != nil {
@@ -63,12 +93,140 @@ func (r *RedisClient) connect(data *Redis) (client *redis.Client, err error) {
 			return nil, err
 		}
 	} else {
-		client = r.Client
+		client = r.UniversalClient
 		err = nil
 	}
 	return
 }
 
+// newRedisUniversalClient returns a sentinel-backed, cluster or single-node client based on the config
+func newRedisUniversalClient(data *Redis) (redis.UniversalClient, error) {
+	tlsConfig, err := newRedisTLSConfig(&data.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	password := data.Password
+	db := data.DB
+	var onConnect func(*redis.Conn) error
+
+	// go-redis only knows the legacy AUTH command, so ACL authentication is sent
+	// on each new connection and the database is selected afterwards
+	if data.Username != "" {
+		password = ""
+		db = 0
+		onConnect = func(cn *redis.Conn) error {
+			if err := cn.Do("AUTH", data.Username, data.Password).Err(); err != nil {
+				return err
+			}
+
+			if data.DB > 0 {
+				return cn.Select(data.DB).Err()
+			}
+
+			return nil
+		}
+	}
+
+	switch {
+	case data.MasterName != "":
+		sentinelAddrs := data.SentinelAddrs
+		if len(sentinelAddrs) == 0 {
+			sentinelAddrs = []string{data.Host}
+		}
+
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    data.MasterName,
+			SentinelAddrs: sentinelAddrs,
+			OnConnect:     onConnect,
+			Password:      password,
+			DB:            db,
+			MaxRetries:    data.MaxRetries,
+			DialTimeout:   data.DialTimeout,
+			ReadTimeout:   data.ReadTimeout,
+			WriteTimeout:  data.WriteTimeout,
+			PoolSize:      data.PoolSize,
+			MinIdleConns:  data.MinIdleConns,
+			PoolTimeout:   data.PoolTimeout,
+			IdleTimeout:   data.IdleTimeout,
+			TLSConfig:     tlsConfig,
+		}), nil
+
+	case len(data.ClusterAddrs) > 0:
+		if data.DB != 0 {
+			return nil, errors.New("redis cluster only supports database 0")
+		}
+
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        data.ClusterAddrs,
+			OnConnect:    onConnect,
+			Password:     password,
+			MaxRetries:   data.MaxRetries,
+			DialTimeout:  data.DialTimeout,
+			ReadTimeout:  data.ReadTimeout,
+			WriteTimeout: data.WriteTimeout,
+			PoolSize:     data.PoolSize,
+			MinIdleConns: data.MinIdleConns,
+			PoolTimeout:  data.PoolTimeout,
+			IdleTimeout:  data.IdleTimeout,
+			TLSConfig:    tlsConfig,
+		}), nil
+
+	default:
+		return redis.NewClient(&redis.Options{
+			Addr:         data.Host,
+			OnConnect:    onConnect,
+			Password:     password,
+			DB:           db,
+			MaxRetries:   data.MaxRetries,
+			DialTimeout:  data.DialTimeout,
+			ReadTimeout:  data.ReadTimeout,
+			WriteTimeout: data.WriteTimeout,
+			PoolSize:     data.PoolSize,
+			MinIdleConns: data.MinIdleConns,
+			PoolTimeout:  data.PoolTimeout,
+			IdleTimeout:  data.IdleTimeout,
+			TLSConfig:    tlsConfig,
+		}), nil
+	}
+}
+
+// newRedisTLSConfig returns the TLS config, or nil when TLS is disabled
+func newRedisTLSConfig(data *RedisTLS) (*tls.Config, error) {
+	if !data.Enable {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         data.ServerName,
+		InsecureSkipVerify: data.InsecureSkipVerify,
+	}
+
+	if data.CAFile != "" {
+		caCert, err := ioutil.ReadFile(data.CAFile)
+		if err != nil {
+			log.Printf("Unable to read Redis CA file: %v", err)
+			return nil, err
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("unable to parse Redis CA file: " + data.CAFile)
+		}
+	}
+
+	if data.CertFile != "" || data.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(data.CertFile, data.KeyFile)
+		if err != nil {
+			log.Printf("Unable to load Redis client certificate: %v", err)
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
 // Middleware for echo framework
 func (r *RedisClient) Middleware(hash hash.IHash) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -90,7 +248,7 @@ func (r *RedisClient) Middleware(hash hash.IHash) echo.MiddlewareFunc {
 
 // Get retrieves a value from Redis based on the key provided
 func (r *RedisClient) Get(key string) (interface{}, error) {
-	if r.Client == nil {
+	if r.UniversalClient == nil {
 		return nil, errors.New("redis client is not initialized")
 	}
 	
@@ -98,30 +256,42 @@ func (r *RedisClient) Get(key string) (interface{}, error) {
 		return nil, errors.New("key cannot be empty")
 	}
 	
-	result, err := r.Client.Get(key).Result()
+	defer r.stats.get.observe(time.Now())
+
+	result, err := r.UniversalClient.Get(key).Result()
 	if err == redis.Nil {
+		r.stats.miss()
 		return "", nil // Key does not exist
 	}
+	if err == nil {
+		r.stats.hit()
+	}
 	return result, err
 }
 
 // Set creates a new record with the specified key, value, and expiration
 func (r *RedisClient) Set(key string, value interface{}, expire time.Duration) error {
-	if r.Client == nil {
+	if r.UniversalClient == nil {
 		return errors.New("redis client is not initialized")
 	}
 	
 	if key == "" {
 		return errors.New("key cannot be empty")
 	}
-	
-	return r.Client.Set(key, value, expire).Err()
+	defer r.stats.set.observe(time.Now())
+
+	if err := r.UniversalClient.Set(key, value, expire).Err(); err != nil {
+		return err
+	}
+
+	r.stats.stored()
+	return nil
 }
 
 // Update modifies an existing key with a new value and expiration
 // Returns an error if the key doesn't exist
 func (r *RedisClient) Update(key string, value interface{}, expire time.Duration) error {
-	if r.Client == nil {
+	if r.UniversalClient == nil {
 		return errors.New("redis client is not initialized")
 	}
 	
@@ -129,8 +299,10 @@ func (r *RedisClient) Update(key string, value interface{}, expire time.Duration
 		return errors.New("key cannot be empty")
 	}
 	
+	defer r.stats.set.observe(time.Now())
+
 	// Check if key exists
-	exists, err := r.Client.Exists(key).Result()
+	exists, err := r.UniversalClient.Exists(key).Result()
 	if err != nil {
 		log.Printf("Unable to check if key exists: %v", err)
 		return err
@@ -140,12 +312,17 @@ func (r *RedisClient) Update(key string, value interface{}, expire time.Duration
 		return errors.New("key does not exist: " + key)
 	}
 
-	return r.Client.Set(key, value, expire).Err()
+	if err := r.UniversalClient.Set(key, value, expire).Err(); err != nil {
+		return err
+	}
+
+	r.stats.stored()
+	return nil
 }
 
 // Append adds a string value to the end of an existing string key
 func (r *RedisClient) Append(key string, value interface{}) error {
-	if r.Client == nil {
+	if r.UniversalClient == nil {
 		return errors.New("redis client is not initialized")
 	}
 	
@@ -153,29 +330,36 @@ func (r *RedisClient) Append(key string, value interface{}) error {
 		return errors.New("key cannot be empty")
 	}
 	
-	// Convert value to string
-	var stringValue string
+	stringValue, err := toRedisString(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.UniversalClient.Append(key, stringValue).Result()
+	return err
+}
+
+// toRedisString converts a value to the string stored in redis
+// Strings and byte slices are kept as is, other values are marshalled to JSON
+func toRedisString(value interface{}) (string, error) {
 	switch v := value.(type) {
 	case string:
-		stringValue = v
+		return v, nil
 	case []byte:
-		stringValue = string(v)
+		return string(v), nil
 	default:
 		b, err := json.Marshal(value)
 		if err != nil {
 			log.Printf("Unable to marshal value: %v", err)
-			return errors.New("cannot marshal value: " + err.Error())
+			return "", errors.New("cannot marshal value: " + err.Error())
 		}
-		stringValue = string(b)
+		return string(b), nil
 	}
-
-	_, err := r.Client.Append(key, stringValue).Result()
-	return err
 }
 
 // Delete removes a key from Redis
 func (r *RedisClient) Delete(key string) error {
-	if r.Client == nil {
+	if r.UniversalClient == nil {
 		return errors.New("redis client is not initialized")
 	}
 	
@@ -183,23 +367,210 @@ func (r *RedisClient) Delete(key string) error {
 		return errors.New("key cannot be empty")
 	}
 	
-	_, err := r.Client.Del(key).Result()
-	return err
+	defer r.stats.delete.observe(time.Now())
+
+	deleted, err := r.UniversalClient.Del(key).Result()
+	if err != nil {
+		return err
+	}
+
+	if deleted > 0 {
+		r.stats.deleted()
+	}
+	return nil
 }
 
 // GetNumberOfRecords return number of records
 func (r *RedisClient) GetNumberOfRecords() int {
-	return len(r.Client.Do("KEYS", "*").Args())
+	cmd := redis.NewCmd("KEYS", "*")
+	r.UniversalClient.Process(cmd)
+
+	return len(cmd.Args())
 }
 
 // GetCapacity method return redis database size
 func (r *RedisClient) GetCapacity() (interface{}, error) {
-	IntCmd := r.Client.DBSize()
+	IntCmd := r.UniversalClient.DBSize()
 
 	return IntCmd.Result()
 }
 
+// acquireLock stores the lock record if the key does not exist
+func (r *RedisClient) acquireLock(key, token string, ttl time.Duration) (bool, error) {
+	if r.UniversalClient == nil {
+		return false, errors.New("redis client is not initialized")
+	}
+
+	return r.UniversalClient.SetNX(key, token, ttl).Result()
+}
+
+// refreshLock resets the lock expiration if the record still holds the token
+func (r *RedisClient) refreshLock(key, token string, ttl time.Duration) (bool, error) {
+	if r.UniversalClient == nil {
+		return false, errors.New("redis client is not initialized")
+	}
+
+	result, err := redisRefreshLockScript.Run(r.UniversalClient, []string{key}, token, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return false, err
+	}
+
+	return result == 1, nil
+}
+
+// releaseLock deletes the lock record if it still holds the token
+func (r *RedisClient) releaseLock(key, token string) (bool, error) {
+	if r.UniversalClient == nil {
+		return false, errors.New("redis client is not initialized")
+	}
+
+	result, err := redisReleaseLockScript.Run(r.UniversalClient, []string{key}, token).Int64()
+	if err != nil {
+		return false, err
+	}
+
+	return result == 1, nil
+}
+
+// OnSet registers a handler called when a string key is set, based on keyspace notifications
+func (r *RedisClient) OnSet(handler KeyValueEventHandler) {
+	r.events.subscribe(EventSet, handler)
+	r.listenKeyspaceEvents()
+}
+
+// OnDelete registers a handler called when a key is deleted, based on keyspace notifications
+func (r *RedisClient) OnDelete(handler KeyValueEventHandler) {
+	r.events.subscribe(EventDelete, handler)
+	r.listenKeyspaceEvents()
+}
+
+// OnExpire registers a handler called when a key expires, based on keyspace notifications
+func (r *RedisClient) OnExpire(handler KeyValueEventHandler) {
+	r.events.subscribe(EventExpire, handler)
+	r.listenKeyspaceEvents()
+}
+
+// OnEvict registers a handler called when a key is evicted by the maxmemory policy,
+// based on keyspace notifications
+func (r *RedisClient) OnEvict(handler KeyValueEventHandler) {
+	r.events.subscribe(EventEvict, handler)
+	r.listenKeyspaceEvents()
+}
+
+// redisKeyspaceEvents maps keyevent notification names to event types
+var redisKeyspaceEvents = map[string]KeyValueEventType{
+	"set":     EventSet,
+	"del":     EventDelete,
+	"expired": EventExpire,
+	"evicted": EventEvict,
+}
+
+// listenKeyspaceEvents subscribes once to the keyevent notifications of the selected database
+// and forwards them to the registered handlers. Redis Cluster publishes notifications on
+// each node, only the events of the node serving the subscription are received.
+func (r *RedisClient) listenKeyspaceEvents() {
+	r.notifyOnce.Do(func() {
+		if r.UniversalClient == nil {
+			log.Println("Unable to listen keyspace events: redis client is not initialized")
+			return
+		}
+
+		r.enableKeyspaceEvents()
+
+		db := 0
+		if r.config != nil {
+			db = r.config.DB
+		}
+		prefix := fmt.Sprintf("__keyevent@%d__:", db)
+
+		r.notifications = r.UniversalClient.PSubscribe(prefix + "*")
+		go func() {
+			for msg := range r.notifications.Channel() {
+				eventType, ok := redisKeyspaceEvents[strings.TrimPrefix(msg.Channel, prefix)]
+				if !ok {
+					continue
+				}
+
+				r.events.emit(eventType, msg.Payload, nil)
+			}
+		}()
+	})
+}
+
+// enableKeyspaceEvents checks that the server publishes the notification classes needed
+// by the event handlers. They are added to the server configuration when EnableKeyspaceEvents
+// is set, otherwise the operator is asked to enable them.
+func (r *RedisClient) enableKeyspaceEvents() {
+	const required = "Eg$xe"
+
+	values, err := r.UniversalClient.ConfigGet("notify-keyspace-events").Result()
+	if err != nil || len(values) != 2 {
+		log.Printf("Unable to check keyspace notifications, make sure notify-keyspace-events includes %q on the server: %v", required, err)
+		return
+	}
+	current, _ := values[1].(string)
+
+	flags := current
+	for _, flag := range required {
+		if !strings.ContainsRune(flags, flag) {
+			flags += string(flag)
+		}
+	}
+
+	if flags == current {
+		return
+	}
+
+	if r.config == nil || !r.config.EnableKeyspaceEvents {
+		log.Printf("Keyspace notifications are disabled, set notify-keyspace-events to %q on the server or enable EnableKeyspaceEvents", flags)
+		return
+	}
+
+	if err := r.UniversalClient.ConfigSet("notify-keyspace-events", flags).Err(); err != nil {
+		log.Printf("Unable to enable keyspace notifications, make sure they are enabled on the server: %v", err)
+	}
+}
+
+// Stats returns the client statistics
+// Evictions and Expired are read from the server INFO stats and cover every client
+func (r *RedisClient) Stats() KeyValueStats {
+	stats := r.stats.snapshot()
+	if size, err := r.UniversalClient.DBSize().Result(); err == nil {
+		stats.Records = size
+	} else {
+		log.Printf("Unable to get number of records: %v", err)
+	}
+
+	info, err := r.UniversalClient.Info("stats").Result()
+	if err != nil {
+		log.Printf("Unable to get Redis server stats: %v", err)
+		return stats
+	}
+
+	for _, line := range strings.Split(info, "\n") {
+		name, value, found := strings.Cut(strings.TrimSpace(line), ":")
+		if !found {
+			continue
+		}
+
+		switch name {
+		case "evicted_keys":
+			stats.Evictions, _ = strconv.ParseInt(value, 10, 64)
+		case "expired_keys":
+			stats.Expired, _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+
+	return stats
+}
+
 // Close method will close redis connection
 func (r *RedisClient) Close() error {
-	return r.Client.Close()
+	if r.notifications != nil {
+		if err := r.notifications.Close(); err != nil {
+			log.Printf("Unable to close keyspace notification subscription: %v", err)
+		}
+	}
+
+	return r.UniversalClient.Close()
 }
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the latency histogram buckets
+// Observations above the last bound are counted in an extra overflow bucket
+var latencyBuckets = []time.Duration{
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// IKeyValueStats is implemented by key-value backends collecting usage statistics
+type IKeyValueStats interface {
+	Stats() KeyValueStats
+}
+
+// KeyValueStats model for key-value backend statistics
+// Counters are collected by the client since it was created, except Evictions and Expired
+// on REDIS which are read from the server and cover every client
+type KeyValueStats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Sets      int64 `json:"sets"`
+	Deletes   int64 `json:"deletes"`
+	Evictions int64 `json:"evictions"`
+	Expired   int64 `json:"expired"`
+	Records   int64 `json:"records"`
+
+	GetLatency    LatencyHistogram `json:"getLatency"`
+	SetLatency    LatencyHistogram `json:"setLatency"`
+	DeleteLatency LatencyHistogram `json:"deleteLatency"`
+}
+
+// LatencyHistogram model for the latency distribution of an operation
+type LatencyHistogram struct {
+	Count   int64           `json:"count"`
+	Sum     time.Duration   `json:"sum"`
+	Buckets []LatencyBucket `json:"buckets"`
+}
+
+// LatencyBucket model for the number of operations faster than UpperBound
+// The overflow bucket has a zero UpperBound
+type LatencyBucket struct {
+	UpperBound time.Duration `json:"upperBound"`
+	Count      int64         `json:"count"`
+}
+
+// Mean returns the average latency
+func (h LatencyHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// keyValueStatsRecorder collects the statistics of a key-value client
+type keyValueStatsRecorder struct {
+	hits      int64
+	misses    int64
+	sets      int64
+	deletes   int64
+	evictions int64
+	expired   int64
+
+	get    latencyRecorder
+	set    latencyRecorder
+	delete latencyRecorder
+}
+
+// latencyRecorder collects the latency distribution of an operation
+type latencyRecorder struct {
+	count   int64
+	sum     int64
+	buckets [10]int64 // one per latencyBuckets entry plus the overflow bucket
+}
+
+// observe records the time elapsed since start
+func (l *latencyRecorder) observe(start time.Time) {
+	elapsed := time.Since(start)
+
+	bucket := len(latencyBuckets)
+	for i, upperBound := range latencyBuckets {
+		if elapsed <= upperBound {
+			bucket = i
+			break
+		}
+	}
+
+	atomic.AddInt64(&l.count, 1)
+	atomic.AddInt64(&l.sum, int64(elapsed))
+	atomic.AddInt64(&l.buckets[bucket], 1)
+}
+
+// snapshot returns the current histogram
+func (l *latencyRecorder) snapshot() LatencyHistogram {
+	histogram := LatencyHistogram{
+		Count:   atomic.LoadInt64(&l.count),
+		Sum:     time.Duration(atomic.LoadInt64(&l.sum)),
+		Buckets: make([]LatencyBucket, 0, len(l.buckets)),
+	}
+
+	for i := range l.buckets {
+		bucket := LatencyBucket{Count: atomic.LoadInt64(&l.buckets[i])}
+		if i < len(latencyBuckets) {
+			bucket.UpperBound = latencyBuckets[i]
+		}
+		histogram.Buckets = append(histogram.Buckets, bucket)
+	}
+
+	return histogram
+}
+
+// hit records a successful lookup
+func (s *keyValueStatsRecorder) hit() {
+	atomic.AddInt64(&s.hits, 1)
+}
+
+// miss records a lookup of a missing or expired key
+func (s *keyValueStatsRecorder) miss() {
+	atomic.AddInt64(&s.misses, 1)
+}
+
+// stored records a successful write
+func (s *keyValueStatsRecorder) stored() {
+	atomic.AddInt64(&s.sets, 1)
+}
+
+// deleted records a successful delete
+func (s *keyValueStatsRecorder) deleted() {
+	atomic.AddInt64(&s.deletes, 1)
+}
+
+// evicted records records removed to free up memory
+func (s *keyValueStatsRecorder) evicted(count int64) {
+	atomic.AddInt64(&s.evictions, count)
+}
+
+// expiredRecord records a record removed after its expiration time
+func (s *keyValueStatsRecorder) expiredRecord() {
+	atomic.AddInt64(&s.expired, 1)
+}
+
+// snapshot returns the current statistics
+func (s *keyValueStatsRecorder) snapshot() KeyValueStats {
+	return KeyValueStats{
+		Hits:          atomic.LoadInt64(&s.hits),
+		Misses:        atomic.LoadInt64(&s.misses),
+		Sets:          atomic.LoadInt64(&s.sets),
+		Deletes:       atomic.LoadInt64(&s.deletes),
+		Evictions:     atomic.LoadInt64(&s.evictions),
+		Expired:       atomic.LoadInt64(&s.expired),
+		GetLatency:    s.get.snapshot(),
+		SetLatency:    s.set.snapshot(),
+		DeleteLatency: s.delete.snapshot(),
+	}
+}
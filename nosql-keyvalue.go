@@ -20,6 +20,29 @@ type INoSQLKeyValue interface {
 	Close() error
 }
 
+// IStructuredKeyValue is implemented by key-value backends supporting redis-like data structures
+// Values and members are stored as strings: strings and byte slices are kept as is,
+// other values are marshalled to JSON
+type IStructuredKeyValue interface {
+	HGet(key, field string) (string, error)
+	HSet(key, field string, value interface{}) error
+	HDel(key string, fields ...string) (int64, error)
+	LPush(key string, values ...interface{}) (int64, error)
+	LRange(key string, start, stop int64) ([]string, error)
+	SAdd(key string, members ...interface{}) (int64, error)
+	SIsMember(key string, member interface{}) (bool, error)
+	SMembers(key string) ([]string, error)
+	SRem(key string, members ...interface{}) (int64, error)
+	ZAdd(key string, members ...ScoredMember) (int64, error)
+	ZRangeByScore(key string, min, max float64) ([]ScoredMember, error)
+}
+
+// ScoredMember model for a sorted set member
+type ScoredMember struct {
+	Member string
+	Score  float64
+}
+
 const (
 	// CUSTOM caching on local memory
 	CUSTOM = iota
@@ -0,0 +1,147 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/golang-common-packages/storage"
+)
+
+// localLimiter rate limiter for in-process key-value stores
+// A mutex makes the read-modify-write of the limiter records atomic
+type localLimiter struct {
+	store  storage.INoSQLKeyValue
+	config Config
+	mu     sync.Mutex
+}
+
+// localState private model for the limiter record, stored as JSON
+type localState struct {
+	Count     int64   `json:"count,omitempty"`
+	Reset     int64   `json:"reset,omitempty"` // unix nanosecond
+	Log       []int64 `json:"log,omitempty"`   // unix nanosecond
+	Tokens    float64 `json:"tokens,omitempty"`
+	Timestamp int64   `json:"ts,omitempty"` // unix nanosecond
+}
+
+// newLocalLimiter init new instance
+func newLocalLimiter(store storage.INoSQLKeyValue, config Config) ILimiter {
+	return &localLimiter{store: store, config: config}
+}
+
+// Allow reports whether the request identified by key is allowed
+func (ll *localLimiter) Allow(key string) (*Result, error) {
+	if key == "" {
+		return nil, errors.New("key cannot be empty")
+	}
+
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
+
+	storeKey := ll.config.Prefix + key
+	state, exists := ll.load(storeKey)
+	now := time.Now().UnixNano()
+	window := int64(ll.config.Window)
+	result := &Result{Limit: ll.config.Limit}
+	expire := ll.config.Window
+
+	switch ll.config.Algorithm {
+	case FIXEDWINDOW:
+		if state.Reset <= now {
+			state = localState{Reset: now + window}
+		}
+		state.Count++
+		expire = time.Duration(state.Reset - now)
+
+		result.Allowed = state.Count <= ll.config.Limit
+		result.Remaining = ll.config.Limit - state.Count
+		if result.Remaining < 0 {
+			result.Remaining = 0
+		}
+		if !result.Allowed {
+			result.RetryAfter = expire
+		}
+
+	case SLIDINGWINDOWLOG:
+		requests := state.Log[:0]
+		for _, timestamp := range state.Log {
+			if timestamp > now-window {
+				requests = append(requests, timestamp)
+			}
+		}
+		state.Log = requests
+
+		if int64(len(state.Log)) < ll.config.Limit {
+			state.Log = append(state.Log, now)
+			result.Allowed = true
+			result.Remaining = ll.config.Limit - int64(len(state.Log))
+		} else {
+			result.RetryAfter = time.Duration(state.Log[0] + window - now)
+		}
+
+	case TOKENBUCKET:
+		capacity := float64(ll.config.Limit)
+		rate := capacity / float64(window) // tokens per nanosecond
+		if !exists {
+			state = localState{Tokens: capacity, Timestamp: now}
+		}
+
+		state.Tokens = math.Min(capacity, state.Tokens+math.Max(0, float64(now-state.Timestamp))*rate)
+		state.Timestamp = now
+		if state.Tokens >= 1 {
+			state.Tokens--
+			result.Allowed = true
+		} else {
+			result.RetryAfter = time.Duration(math.Ceil((1 - state.Tokens) / rate))
+		}
+		result.Remaining = int64(math.Floor(state.Tokens))
+	}
+
+	if err := ll.save(storeKey, state, exists, expire); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// load returns the limiter record and whether it exists
+func (ll *localLimiter) load(key string) (localState, bool) {
+	var state localState
+
+	value, err := ll.store.Get(key)
+	if err != nil || value == nil {
+		return state, false
+	}
+
+	data, ok := value.(string)
+	if !ok || data == "" {
+		return state, false
+	}
+
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		return localState{}, false
+	}
+
+	return state, true
+}
+
+// save stores the limiter record
+func (ll *localLimiter) save(key string, state localState, exists bool, expire time.Duration) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	if expire <= 0 {
+		expire = time.Millisecond
+	}
+
+	if exists {
+		return ll.store.Update(key, string(data), expire)
+	}
+
+	return ll.store.Set(key, string(data), expire)
+}
@@ -0,0 +1,43 @@
+package ratelimit
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/golang-common-packages/hash"
+)
+
+// Middleware for echo framework
+// Requests are limited per access token, hashed like the key-value Middleware,
+// and per client IP when no token is provided
+func Middleware(limiter ILimiter, hash hash.IHash) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.RealIP()
+			if token := c.Request().Header.Get(echo.HeaderAuthorization); token != "" {
+				key = hash.SHA512(token)
+			}
+
+			result, err := limiter.Allow(key)
+			if err != nil {
+				log.Println("Unable to check rate limit in echo middleware: ", err)
+				return echo.NewHTTPError(http.StatusInternalServerError, err)
+			}
+
+			header := c.Response().Header()
+			header.Set("X-RateLimit-Limit", strconv.FormatInt(result.Limit, 10))
+			header.Set("X-RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+
+			if !result.Allowed {
+				header.Set("Retry-After", strconv.FormatInt(int64(math.Ceil(result.RetryAfter.Seconds())), 10))
+				return c.NoContent(http.StatusTooManyRequests)
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+
+	"github.com/golang-common-packages/storage"
+)
+
+var (
+	// fixedWindowScript counts the request and starts the window on the first one
+	// Returns the request count and the window remaining time in milliseconds
+	fixedWindowScript = redis.NewScript(`
+local current = redis.call("INCR", KEYS[1])
+if current == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return {current, redis.call("PTTL", KEYS[1])}`)
+
+	// slidingWindowLogScript drops the requests older than the window and logs the request if allowed
+	// Returns whether the request is allowed, the remaining requests and the wait time in milliseconds
+	slidingWindowLogScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+local count = redis.call("ZCARD", KEYS[1])
+if count < limit then
+	redis.call("ZADD", KEYS[1], now, ARGV[4])
+	redis.call("PEXPIRE", KEYS[1], window)
+	return {1, limit - count - 1, 0}
+end
+local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+return {0, 0, tonumber(oldest[2]) + window - now}`)
+
+	// tokenBucketScript refills the bucket since the last request and takes a token if available
+	// Returns whether the request is allowed, the remaining tokens and the wait time in milliseconds
+	tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], ARGV[4])
+return {allowed, math.floor(tokens), retry}`)
+)
+
+// redisLimiter rate limiter running atomic Lua scripts on redis
+type redisLimiter struct {
+	client *storage.RedisClient
+	config Config
+}
+
+// newRedisLimiter init new instance
+func newRedisLimiter(client *storage.RedisClient, config Config) ILimiter {
+	return &redisLimiter{client: client, config: config}
+}
+
+// Allow reports whether the request identified by key is allowed
+func (rl *redisLimiter) Allow(key string) (*Result, error) {
+	if rl.client.UniversalClient == nil {
+		return nil, errors.New("redis client is not initialized")
+	}
+
+	if key == "" {
+		return nil, errors.New("key cannot be empty")
+	}
+
+	redisKey := rl.config.Prefix + key
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	window := rl.config.Window.Milliseconds()
+	if window <= 0 {
+		window = 1
+	}
+
+	var (
+		values []interface{}
+		err    error
+	)
+	switch rl.config.Algorithm {
+	case FIXEDWINDOW:
+		values, err = rl.run(fixedWindowScript, redisKey, window)
+		if err != nil {
+			return nil, err
+		}
+
+		count, ttl := toInt64(values, 0), toInt64(values, 1)
+		result := &Result{Allowed: count <= rl.config.Limit, Limit: rl.config.Limit, Remaining: rl.config.Limit - count}
+		if result.Remaining < 0 {
+			result.Remaining = 0
+		}
+		if !result.Allowed && ttl > 0 {
+			result.RetryAfter = time.Duration(ttl) * time.Millisecond
+		}
+		return result, nil
+
+	case SLIDINGWINDOWLOG:
+		member := fmt.Sprintf("%d-%d", now, rand.Int63())
+		values, err = rl.run(slidingWindowLogScript, redisKey, now, window, rl.config.Limit, member)
+
+	case TOKENBUCKET:
+		rate := float64(rl.config.Limit) / float64(window)
+		values, err = rl.run(tokenBucketScript, redisKey, rl.config.Limit, strconv.FormatFloat(rate, 'f', -1, 64), now, window)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Allowed:    toInt64(values, 0) == 1,
+		Limit:      rl.config.Limit,
+		Remaining:  toInt64(values, 1),
+		RetryAfter: time.Duration(toInt64(values, 2)) * time.Millisecond,
+	}, nil
+}
+
+// run executes the script and returns its array reply
+func (rl *redisLimiter) run(script *redis.Script, key string, args ...interface{}) ([]interface{}, error) {
+	reply, err := script.Run(rl.client.UniversalClient, []string{key}, args...).Result()
+	if err != nil {
+		log.Printf("Unable to run rate limiter script for key %s: %v", key, err)
+		return nil, err
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok {
+		return nil, errors.New("unexpected rate limiter script reply")
+	}
+
+	return values, nil
+}
+
+// toInt64 returns the integer at index i of a script reply
+func toInt64(values []interface{}, i int) int64 {
+	if i >= len(values) {
+		return 0
+	}
+
+	value, _ := values[i].(int64)
+	return value
+}
@@ -0,0 +1,72 @@
+// Package ratelimit provides rate limiters built on top of the storage key-value stores.
+package ratelimit
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-common-packages/storage"
+)
+
+// Algorithm defines the rate limiting algorithm
+type Algorithm int
+
+const (
+	// FIXEDWINDOW allows Limit requests per window starting at the first request
+	FIXEDWINDOW Algorithm = iota
+	// SLIDINGWINDOWLOG allows Limit requests during any period of Window
+	SLIDINGWINDOWLOG
+	// TOKENBUCKET allows bursts of Limit requests and refills the whole bucket over Window
+	TOKENBUCKET
+)
+
+var (
+	// ErrInvalidConfig is returned when the limiter configuration is invalid
+	ErrInvalidConfig = errors.New("invalid rate limiter configuration")
+)
+
+// ILimiter rate limiter interface
+type ILimiter interface {
+	Allow(key string) (*Result, error)
+}
+
+// Config model for rate limiter config
+type Config struct {
+	Algorithm Algorithm     `json:"algorithm"`
+	Limit     int64         `json:"limit"`
+	Window    time.Duration `json:"window"` // nanosecond
+	// Prefix namespaces the limiter records in the key-value store
+	Prefix string `json:"prefix"`
+}
+
+// Result model for the outcome of a rate limited request
+type Result struct {
+	Allowed   bool
+	Limit     int64
+	Remaining int64
+	// RetryAfter is the time to wait before the next request can be allowed
+	RetryAfter time.Duration
+}
+
+// New returns a rate limiter using the key-value store provided
+// Limiters on REDIS are atomic across replicas using Lua scripts, limiters on
+// in-process stores (CUSTOM, BIGCACHE) serialise requests with a mutex.
+func New(store storage.INoSQLKeyValue, config Config) (ILimiter, error) {
+	if store == nil || config.Limit <= 0 || config.Window <= 0 {
+		return nil, ErrInvalidConfig
+	}
+
+	if config.Algorithm != FIXEDWINDOW && config.Algorithm != SLIDINGWINDOWLOG && config.Algorithm != TOKENBUCKET {
+		return nil, ErrInvalidConfig
+	}
+
+	if config.Prefix == "" {
+		config.Prefix = "ratelimit:"
+	}
+
+	if redisClient, ok := store.(*storage.RedisClient); ok {
+		return newRedisLimiter(redisClient, config), nil
+	}
+
+	return newLocalLimiter(store, config), nil
+}
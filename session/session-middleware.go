@@ -0,0 +1,55 @@
+package session
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// contextKey private type for the request context keys of this package
+type contextKey struct{}
+
+// sessionContextKey is the request context key holding the loaded session
+var sessionContextKey = contextKey{}
+
+// ContextKey is the echo context key holding the loaded session
+const ContextKey = "session"
+
+// Middleware for echo framework
+// The session matching the Authorization token, with an optional "Bearer " prefix,
+// is attached to the echo context and to the request context
+func (m *Manager) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token := c.Request().Header.Get(echo.HeaderAuthorization)
+			token = strings.TrimPrefix(token, "Bearer ")
+
+			session, err := m.Load(token)
+			if err == ErrSessionNotFound {
+				return c.NoContent(http.StatusUnauthorized)
+			} else if err != nil {
+				log.Println("Unable to load session in echo middleware: ", err)
+				return echo.NewHTTPError(http.StatusInternalServerError, err)
+			}
+
+			c.Set(ContextKey, session)
+			c.SetRequest(c.Request().WithContext(NewContext(c.Request().Context(), session)))
+
+			return next(c)
+		}
+	}
+}
+
+// NewContext returns a copy of the context holding the session
+func NewContext(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionContextKey, session)
+}
+
+// FromContext returns the session attached to the context by the middleware
+func FromContext(ctx context.Context) (*Session, bool) {
+	session, ok := ctx.Value(sessionContextKey).(*Session)
+	return session, ok
+}
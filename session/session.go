@@ -0,0 +1,369 @@
+// Package session provides a session manager built on top of the storage key-value stores.
+package session
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/golang-common-packages/hash"
+
+	"github.com/golang-common-packages/storage"
+)
+
+var (
+	// ErrSessionNotFound is returned when the token does not match a live session
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrUnsupportedStore is returned when the key-value store cannot index sessions per user
+	ErrUnsupportedStore = errors.New("key-value store does not support sessions")
+	// ErrInvalidConfig is returned when the session configuration is invalid
+	ErrInvalidConfig = errors.New("invalid session configuration")
+)
+
+// Config model for session manager config
+type Config struct {
+	// TTL is the session lifetime, extended on each access when Sliding is enabled
+	TTL time.Duration `json:"ttl"` // nanosecond
+	// Sliding resets the expiration on each successful Load
+	Sliding bool `json:"sliding"`
+	// MaxLifetime caps the session lifetime regardless of activity, zero means no cap
+	MaxLifetime time.Duration `json:"maxLifetime"` // nanosecond
+	// DataModel is the type session data is decoded into, raw JSON is returned when nil
+	DataModel reflect.Type `json:"-"`
+	// KeyPrefix namespaces the session records
+	KeyPrefix string `json:"keyPrefix"`
+	// UserIndexPrefix namespaces the per-user session indexes
+	UserIndexPrefix string `json:"userIndexPrefix"`
+}
+
+// Session model for a user session
+type Session struct {
+	// ID identifies the session without revealing its token, e.g. to revoke another device
+	ID     string `json:"id"`
+	UserID string `json:"userId"`
+	// Data holds a pointer to a new DataModel value, or json.RawMessage when DataModel is nil
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"createdAt"`
+	ExpiresAt time.Time   `json:"expiresAt"`
+}
+
+// record private model for the session stored in the key-value store
+type record struct {
+	UserID    string          `json:"userId"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"createdAt"`
+	ExpiresAt time.Time       `json:"expiresAt"`
+}
+
+// Manager manages sessions stored in a key-value store
+// Session records are stored under the SHA512 hash of their token prefixed with
+// KeyPrefix, so tokens never reach the store.
+type Manager struct {
+	store  storage.INoSQLKeyValue
+	index  storage.IStructuredKeyValue
+	hasher hash.IHash
+	config Config
+}
+
+// New returns a session manager using the key-value store provided
+// REDIS and CUSTOM backends are supported
+func New(store storage.INoSQLKeyValue, config Config) (*Manager, error) {
+	if config.TTL <= 0 || config.MaxLifetime < 0 {
+		return nil, ErrInvalidConfig
+	}
+
+	index, ok := store.(storage.IStructuredKeyValue)
+	if !ok {
+		return nil, ErrUnsupportedStore
+	}
+
+	if config.KeyPrefix == "" {
+		config.KeyPrefix = "session:"
+	}
+
+	if config.UserIndexPrefix == "" {
+		config.UserIndexPrefix = "session-user:"
+	}
+
+	return &Manager{store: store, index: index, hasher: &hash.Client{}, config: config}, nil
+}
+
+// Create starts a session for the user and returns its opaque token
+func (m *Manager) Create(userID string, data interface{}) (string, *Session, error) {
+	if userID == "" {
+		return "", nil, errors.New("user id cannot be empty")
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	rawData, err := json.Marshal(data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	rec := &record{UserID: userID, Data: rawData, CreatedAt: now, ExpiresAt: m.expiration(now, now)}
+	id := m.hasher.SHA512(token)
+
+	if err := m.save(id, rec, false); err != nil {
+		return "", nil, err
+	}
+
+	if _, err := m.index.SAdd(m.config.UserIndexPrefix+userID, id); err != nil {
+		log.Printf("Unable to index session for user %s: %v", userID, err)
+		return "", nil, err
+	}
+
+	session, err := m.toSession(id, rec)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, session, nil
+}
+
+// Load returns the session matching the token
+// The expiration is extended when sliding expiration is enabled
+func (m *Manager) Load(token string) (*Session, error) {
+	if token == "" {
+		return nil, ErrSessionNotFound
+	}
+
+	id := m.hasher.SHA512(token)
+	rec, err := m.load(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.config.Sliding {
+		rec.ExpiresAt = m.expiration(rec.CreatedAt, time.Now())
+		if err := m.save(id, rec, true); err != nil {
+			return nil, err
+		}
+	}
+
+	return m.toSession(id, rec)
+}
+
+// Update replaces the session data, keeping its expiration
+func (m *Manager) Update(token string, data interface{}) error {
+	id := m.hasher.SHA512(token)
+	rec, err := m.load(id)
+	if err != nil {
+		return err
+	}
+
+	rec.Data, err = json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return m.save(id, rec, true)
+}
+
+// Rotate replaces the session token with a new one, e.g. after a privilege change
+// The old token stops working immediately
+func (m *Manager) Rotate(token string) (string, *Session, error) {
+	id := m.hasher.SHA512(token)
+	rec, err := m.load(id)
+	if err != nil {
+		return "", nil, err
+	}
+
+	newToken, err := generateToken()
+	if err != nil {
+		return "", nil, err
+	}
+	newID := m.hasher.SHA512(newToken)
+
+	if err := m.save(newID, rec, false); err != nil {
+		return "", nil, err
+	}
+
+	if _, err := m.index.SAdd(m.config.UserIndexPrefix+rec.UserID, newID); err != nil {
+		return "", nil, err
+	}
+
+	if err := m.RevokeByID(id); err != nil {
+		return "", nil, err
+	}
+
+	session, err := m.toSession(newID, rec)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return newToken, session, nil
+}
+
+// Revoke ends the session matching the token
+func (m *Manager) Revoke(token string) error {
+	return m.RevokeByID(m.hasher.SHA512(token))
+}
+
+// RevokeByID ends the session with the ID provided
+func (m *Manager) RevokeByID(id string) error {
+	rec, err := m.load(id)
+	if err != nil {
+		return err
+	}
+
+	if err := m.store.Delete(m.config.KeyPrefix + id); err != nil {
+		return err
+	}
+
+	_, err = m.index.SRem(m.config.UserIndexPrefix+rec.UserID, id)
+	return err
+}
+
+// List returns the live sessions of the user, most recent first
+// Expired sessions are removed from the user index
+func (m *Manager) List(userID string) ([]*Session, error) {
+	ids, err := m.index.SMembers(m.config.UserIndexPrefix + userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*Session, 0, len(ids))
+	var expired []interface{}
+	for _, id := range ids {
+		rec, err := m.load(id)
+		if errors.Is(err, ErrSessionNotFound) {
+			expired = append(expired, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		session, err := m.toSession(id, rec)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	if len(expired) > 0 {
+		if _, err := m.index.SRem(m.config.UserIndexPrefix+userID, expired...); err != nil {
+			log.Printf("Unable to prune expired sessions of user %s: %v", userID, err)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+	})
+
+	return sessions, nil
+}
+
+// RevokeAll ends every session of the user and returns the number of sessions revoked
+func (m *Manager) RevokeAll(userID string) (int, error) {
+	sessions, err := m.List(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	for _, session := range sessions {
+		if err := m.RevokeByID(session.ID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return revoked, err
+		}
+		revoked++
+	}
+
+	return revoked, nil
+}
+
+// expiration returns the expiration time of a session accessed at now
+func (m *Manager) expiration(createdAt, now time.Time) time.Time {
+	expiresAt := now.Add(m.config.TTL)
+	if m.config.MaxLifetime > 0 && expiresAt.After(createdAt.Add(m.config.MaxLifetime)) {
+		expiresAt = createdAt.Add(m.config.MaxLifetime)
+	}
+	return expiresAt
+}
+
+// load returns the stored session record
+func (m *Manager) load(id string) (*record, error) {
+	value, err := m.store.Get(m.config.KeyPrefix + id)
+	if err != nil || value == nil {
+		return nil, ErrSessionNotFound
+	}
+
+	data, ok := value.(string)
+	if !ok || data == "" {
+		return nil, ErrSessionNotFound
+	}
+
+	var rec record
+	if err := json.Unmarshal([]byte(data), &rec); err != nil {
+		log.Printf("Unable to unmarshal session record: %v", err)
+		return nil, err
+	}
+
+	if !rec.ExpiresAt.After(time.Now()) {
+		return nil, ErrSessionNotFound
+	}
+
+	return &rec, nil
+}
+
+// save stores the session record until it expires
+func (m *Manager) save(id string, rec *record, exists bool) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	expire := time.Until(rec.ExpiresAt)
+	if expire <= 0 {
+		return ErrSessionNotFound
+	}
+
+	if exists {
+		return m.store.Update(m.config.KeyPrefix+id, string(data), expire)
+	}
+
+	return m.store.Set(m.config.KeyPrefix+id, string(data), expire)
+}
+
+// toSession converts the stored record and decodes its data
+func (m *Manager) toSession(id string, rec *record) (*Session, error) {
+	session := &Session{
+		ID:        id,
+		UserID:    rec.UserID,
+		Data:      rec.Data,
+		CreatedAt: rec.CreatedAt,
+		ExpiresAt: rec.ExpiresAt,
+	}
+
+	if m.config.DataModel == nil {
+		return session, nil
+	}
+
+	data := reflect.New(m.config.DataModel).Interface()
+	if err := json.Unmarshal(rec.Data, data); err != nil {
+		log.Printf("Unable to decode session data: %v", err)
+		return nil, err
+	}
+	session.Data = data
+
+	return session, nil
+}
+
+// generateToken returns a random URL-safe token
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -1,6 +1,8 @@
 package tests
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -63,3 +65,122 @@ func TestCustomKeyValueOperations(t *testing.T) {
 	err = client.Close()
 	assert.NoError(t, err, "Close should not return an error")
 }
+
+func TestCustomKeyValueEvents(t *testing.T) {
+	factory := storage.New(nil, storage.NOSQLKEYVALUE)
+	customClient := factory(storage.CUSTOM, &storage.Config{
+		CustomKeyValue: storage.CustomKeyValue{
+			MemorySize:       64, // room for two records
+			CleaningEnable:   true,
+			CleaningInterval: 50 * time.Millisecond,
+		},
+	})
+
+	client, ok := customClient.(storage.IKeyValueNotifier)
+	assert.True(t, ok, "Should be able to cast to IKeyValueNotifier")
+
+	var mu sync.Mutex
+	events := make(map[storage.KeyValueEventType][]string)
+	record := func(event storage.KeyValueEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events[event.Type] = append(events[event.Type], event.Key)
+	}
+	received := func(eventType storage.KeyValueEventType) []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), events[eventType]...)
+	}
+
+	client.OnSet(record)
+	client.OnDelete(record)
+	client.OnExpire(record)
+	client.OnEvict(record)
+
+	kv := customClient.(storage.INoSQLKeyValue)
+	assert.NoError(t, kv.Set("first", "1", time.Hour))
+	assert.NoError(t, kv.Set("second", "2", time.Hour))
+	assert.NoError(t, kv.Set("third", "3", time.Hour))
+	assert.Equal(t, []string{"first", "second", "third"}, received(storage.EventSet), "Set events should be emitted in order")
+	assert.Equal(t, []string{"first"}, received(storage.EventEvict), "The oldest record should be evicted")
+
+	assert.NoError(t, kv.Delete("second"))
+	assert.Equal(t, []string{"second"}, received(storage.EventDelete), "Delete event should be emitted")
+
+	assert.NoError(t, kv.Set("short-lived", "4", 10*time.Millisecond))
+	assert.Eventually(t, func() bool {
+		for _, key := range received(storage.EventExpire) {
+			if key == "short-lived" {
+				return true
+			}
+		}
+		return false
+	}, time.Second, 20*time.Millisecond, "Expire event should be emitted by the cleaning process")
+
+	assert.NoError(t, kv.Close())
+}
+
+func TestCustomKeyValueEvictEvents(t *testing.T) {
+	client := storage.New(nil, storage.NOSQLKEYVALUE)(storage.CUSTOM, &storage.Config{
+		CustomKeyValue: storage.CustomKeyValue{
+			MemorySize:       96, // room for three records
+			CleaningEnable:   true,
+			CleaningInterval: time.Minute,
+		},
+	})
+
+	var evicted []string
+	client.(storage.IKeyValueNotifier).OnEvict(func(event storage.KeyValueEvent) {
+		evicted = append(evicted, event.Key)
+	})
+
+	kv := client.(storage.INoSQLKeyValue)
+	for i := 0; i < 6; i++ {
+		assert.NoError(t, kv.Set(fmt.Sprintf("record-%d", i), i, time.Hour))
+	}
+	assert.Equal(t, []string{"record-0", "record-1", "record-2"}, evicted, "The oldest records should be evicted one by one")
+	assert.Equal(t, 3, kv.GetNumberOfRecords())
+}
+
+func TestCustomKeyValueEventHandlersWriteToStore(t *testing.T) {
+	client := storage.New(nil, storage.NOSQLKEYVALUE)(storage.CUSTOM, &storage.Config{
+		CustomKeyValue: storage.CustomKeyValue{
+			MemorySize:       1024 * 1024 * 4,
+			CleaningEnable:   false,
+			CleaningInterval: time.Minute,
+		},
+	})
+	kv := client.(storage.INoSQLKeyValue)
+	notifier := client.(storage.IKeyValueNotifier)
+
+	// Dependent records are maintained from the handlers of their source record
+	notifier.OnSet(func(event storage.KeyValueEvent) {
+		if event.Key == "source" {
+			assert.NoError(t, kv.Set("derived", event.Value, time.Hour))
+		}
+	})
+	notifier.OnDelete(func(event storage.KeyValueEvent) {
+		if event.Key == "source" {
+			assert.NoError(t, kv.Delete("derived"))
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, kv.Set("source", "value", time.Hour))
+		value, err := kv.Get("derived")
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value, "The dependent record should be stored")
+
+		assert.NoError(t, kv.Delete("source"))
+		_, err = kv.Get("derived")
+		assert.Error(t, err, "The dependent record should be deleted")
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Handlers writing to the store should not deadlock")
+	}
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/golang-common-packages/storage"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newEmbeddedDocument(t *testing.T, directory string, changeLogSize int) storage.INoSQLDocument {
+	client, ok := storage.New(context.Background(), storage.NOSQLDOCUMENT)(storage.EMBEDDEDDOC, &storage.Config{
+		EmbeddedDocument: storage.EmbeddedDocument{Directory: directory, ChangeLogSize: changeLogSize},
+	}).(storage.INoSQLDocument)
+	assert.True(t, ok, "Should be able to cast to INoSQLDocument")
+
+	return client
+}
+
+func TestEmbeddedDocumentPersistence(t *testing.T) {
+	directory := t.TempDir()
+	ctx := context.Background()
+	client := newEmbeddedDocument(t, directory, 10)
+
+	id := primitive.NewObjectID()
+	_, err := client.Create("shop", "orders.archive", []interface{}{
+		bson.M{"_id": id, "customer": "ann", "amount": 10, "items": bson.A{bson.M{"sku": "a"}}},
+		bson.M{"customer": "bob", "amount": 20},
+	})
+	assert.NoError(t, err)
+
+	_, err = client.EnsureIndexes(ctx, "shop", "orders.archive", []storage.IndexSpec{
+		{Keys: []storage.IndexKey{{Field: "customer"}}, Unique: true},
+	})
+	assert.NoError(t, err)
+
+	_, err = client.UpdateOne(ctx, "shop", "orders.archive", bson.M{"customer": "ann"}, bson.M{"$inc": bson.M{"amount": 5}})
+	assert.NoError(t, err)
+
+	_, err = client.Create("bad.name", "orders", []interface{}{bson.M{"customer": "eve"}})
+	assert.Error(t, err, "Database names with dots should be rejected")
+
+	assert.FileExists(t, filepath.Join(directory, "shop", "orders.archive.bson"))
+
+	// A different config opens a new client on the same directory, like a restarted process
+	reopened := newEmbeddedDocument(t, directory, 20)
+
+	order, err := reopened.FindOne(ctx, "shop", "orders.archive", bson.M{"customer": "ann"}, reflect.TypeOf(bson.M{}))
+	assert.NoError(t, err)
+	assert.Equal(t, id, (*order.(*bson.M))["_id"], "ObjectID should survive a reload")
+	assert.EqualValues(t, 15, (*order.(*bson.M))["amount"])
+
+	indexes, err := reopened.ListIndexes(ctx, "shop", "orders.archive")
+	assert.NoError(t, err)
+	assert.Len(t, indexes, 2)
+
+	_, err = reopened.Create("shop", "orders.archive", []interface{}{bson.M{"customer": "bob"}})
+	var writeError *storage.WriteError
+	assert.True(t, errors.As(err, &writeError), "Unique index should be enforced after a reload")
+
+	_, err = reopened.Delete("shop", "orders.archive", bson.M{"customer": bson.M{"$in": bson.A{"ann", "bob"}}})
+	assert.NoError(t, err)
+
+	files, err := os.ReadDir(filepath.Join(directory, "shop"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1, "Temporary files should be removed")
+
+	items, err := newEmbeddedDocument(t, directory, 30).Read("shop", "orders.archive", bson.M{}, 0, reflect.TypeOf(bson.M{}))
+	assert.NoError(t, err)
+	assert.Empty(t, *items.(*[]bson.M))
+}
+
+func TestEmbeddedDocumentValidator(t *testing.T) {
+	directory := t.TempDir()
+	ctx := context.Background()
+	client := newEmbeddedDocument(t, directory, 10)
+
+	assert.NoError(t, client.SetValidator(ctx, "shop", "orders", bson.M{
+		"required":   bson.A{"amount"},
+		"properties": bson.M{"amount": bson.M{"bsonType": "int", "minimum": 1}},
+	}))
+
+	reopened := newEmbeddedDocument(t, directory, 20)
+	_, err := reopened.Create("shop", "orders", []interface{}{bson.M{"amount": 0}})
+	var writeError *storage.WriteError
+	assert.True(t, errors.As(err, &writeError), "Validators should survive a reload")
+
+	_, err = reopened.Create("shop", "orders", []interface{}{bson.M{"amount": 1}})
+	assert.NoError(t, err)
+}
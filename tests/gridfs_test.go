@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golang-common-packages/storage"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+)
+
+func TestGridFSUninitialized(t *testing.T) {
+	client := &storage.MongoClient{}
+	ctx := context.Background()
+
+	_, err := client.UploadFile(ctx, "db", "", "report.pdf", strings.NewReader("content"), nil)
+	assert.EqualError(t, err, "MongoDB client is not initialized")
+
+	_, err = client.DownloadFile(ctx, "db", "", "id", &bytes.Buffer{})
+	assert.EqualError(t, err, "MongoDB client is not initialized")
+
+	_, err = client.ListFiles(ctx, "db", "", nil, 0)
+	assert.EqualError(t, err, "MongoDB client is not initialized")
+}
+
+func TestGridFS(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping MongoDB tests in short mode")
+	}
+
+	uri := os.Getenv("MONGODB_URI")
+	if uri == "" {
+		t.Skip("Skipping MongoDB tests, MONGODB_URI is not set")
+	}
+
+	config := &storage.Config{
+		MongoDB: storage.MongoDB{URI: uri},
+		GridFS:  storage.GridFS{DB: "storage_test", Bucket: "documents", PoolSize: 2},
+	}
+	client := storage.New(context.Background(), storage.NOSQLDOCUMENT)(storage.MONGODB, config).(*storage.MongoClient)
+	ctx := context.Background()
+
+	content := bytes.Repeat([]byte("pdf"), 100000)
+	id, err := client.UploadFile(ctx, "storage_test", "documents", "report.pdf", bytes.NewReader(content), bson.M{"owner": "ann"})
+	assert.NoError(t, err)
+	defer client.DeleteFile(ctx, "storage_test", "documents", id)
+
+	var downloaded bytes.Buffer
+	size, err := client.DownloadFile(ctx, "storage_test", "documents", id, &downloaded)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), size)
+	assert.Equal(t, content, downloaded.Bytes())
+
+	files, err := client.ListFiles(ctx, "storage_test", "documents", bson.M{"metadata.owner": "ann", "_id": id}, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, files, 1) {
+		assert.Equal(t, "report.pdf", files[0].Name)
+	}
+
+	fileClient := storage.New(context.Background(), storage.FILE)(storage.GRIDFS, config).(storage.IFILE)
+	uploaded, err := fileClient.Upload("notes.txt", strings.NewReader("notes"), "reports")
+	assert.NoError(t, err)
+	fileID := uploaded.(*gridfs.File).ID.(primitive.ObjectID)
+
+	moved, err := fileClient.Move(id.Hex(), "", "archive")
+	assert.NoError(t, err)
+	assert.Equal(t, "archive", moved.(*gridfs.File).Metadata.Lookup("parents", "0").StringValue())
+
+	stream, err := fileClient.Download(fileID.Hex())
+	assert.NoError(t, err)
+	var notes bytes.Buffer
+	_, err = notes.ReadFrom(stream.(*gridfs.DownloadStream))
+	assert.NoError(t, err)
+	assert.Equal(t, "notes", notes.String())
+	assert.NoError(t, stream.(*gridfs.DownloadStream).Close())
+
+	assert.NoError(t, client.DeleteFile(ctx, "storage_test", "documents", fileID))
+	_, err = client.DownloadFile(ctx, "storage_test", "documents", fileID, &bytes.Buffer{})
+	assert.True(t, errors.Is(err, gridfs.ErrFileNotFound))
+}
@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v2"
+	"github.com/golang-common-packages/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func testKeyValueStats(t *testing.T, client storage.INoSQLKeyValue) {
+	assert.NoError(t, client.Set("stats-key", "value", time.Hour))
+	_, err := client.Get("stats-key")
+	assert.NoError(t, err)
+	_, _ = client.Get("stats-missing")
+	assert.NoError(t, client.Delete("stats-key"))
+
+	stats := client.(storage.IKeyValueStats).Stats()
+	assert.Equal(t, int64(1), stats.Hits, "Stats should count hits")
+	assert.Equal(t, int64(1), stats.Misses, "Stats should count misses")
+	assert.Equal(t, int64(1), stats.Sets, "Stats should count sets")
+	assert.Equal(t, int64(1), stats.Deletes, "Stats should count deletes")
+	assert.Equal(t, int64(0), stats.Records, "Stats should count the remaining records")
+	assert.Equal(t, int64(2), stats.GetLatency.Count, "Stats should observe get latency")
+	assert.Equal(t, int64(1), stats.SetLatency.Count, "Stats should observe set latency")
+	assert.Equal(t, int64(1), stats.DeleteLatency.Count, "Stats should observe delete latency")
+
+	var bucketed int64
+	for _, bucket := range stats.GetLatency.Buckets {
+		bucketed += bucket.Count
+	}
+	assert.Equal(t, stats.GetLatency.Count, bucketed, "Every observation should fall in a bucket")
+}
+
+func TestCustomKeyValueStats(t *testing.T) {
+	client := storage.New(nil, storage.NOSQLKEYVALUE)(storage.CUSTOM, &storage.Config{
+		CustomKeyValue: storage.CustomKeyValue{
+			MemorySize:       1024 * 1024 * 3,
+			CleaningEnable:   false,
+			CleaningInterval: time.Second,
+		},
+	}).(storage.INoSQLKeyValue)
+
+	testKeyValueStats(t, client)
+
+	assert.NoError(t, client.Set("stats-expiring", "value", 10*time.Millisecond))
+	time.Sleep(20 * time.Millisecond)
+	_, _ = client.Get("stats-expiring")
+	assert.Equal(t, int64(1), client.(storage.IKeyValueStats).Stats().Expired, "Stats should count expired records")
+}
+
+func TestRedisStats(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Redis tests in short mode")
+	}
+
+	s, config := setupMiniRedis(t)
+	defer s.Close()
+
+	client := storage.New(nil, storage.NOSQLKEYVALUE)(storage.REDIS, &storage.Config{
+		Redis: *config,
+	}).(storage.INoSQLKeyValue)
+
+	testKeyValueStats(t, client)
+}
+
+func TestBigCacheStats(t *testing.T) {
+	client := storage.New(nil, storage.NOSQLKEYVALUE)(storage.BIGCACHE, &storage.Config{
+		BigCache: bigcache.DefaultConfig(time.Minute),
+	}).(storage.INoSQLKeyValue)
+
+	testKeyValueStats(t, client)
+	assert.NoError(t, client.Close())
+}
+
+func TestCustomKeyValueStatsFailedPush(t *testing.T) {
+	client := storage.New(nil, storage.NOSQLKEYVALUE)(storage.CUSTOM, &storage.Config{
+		CustomKeyValue: storage.CustomKeyValue{
+			MemorySize:       16,
+			CleaningEnable:   true,
+			CleaningInterval: time.Second,
+		},
+	}).(storage.INoSQLKeyValue)
+
+	assert.Error(t, client.Set("too-large", "value", time.Hour), "Set should fail when the record does not fit in memory")
+	assert.Equal(t, int64(0), client.(storage.IKeyValueStats).Stats().Evictions, "A failed push should not count evictions")
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-common-packages/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func testLocker(t *testing.T, store storage.INoSQLKeyValue) {
+	locker, err := storage.NewLocker(store)
+	assert.NoError(t, err, "NewLocker should not return an error")
+
+	lease, err := locker.TryLock("migration", time.Second)
+	assert.NoError(t, err, "TryLock should acquire a free lock")
+
+	_, err = locker.TryLock("migration", time.Second)
+	assert.ErrorIs(t, err, storage.ErrLockNotAcquired, "TryLock should fail while the lock is held")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	_, err = locker.Lock(ctx, "migration", time.Second)
+	assert.ErrorIs(t, err, storage.ErrLockNotAcquired, "Lock should give up when the context is done")
+
+	assert.NoError(t, lease.Refresh(2*time.Second), "Refresh should extend a held lease")
+
+	assert.NoError(t, lease.Unlock(), "Unlock should release a held lease")
+	assert.ErrorIs(t, lease.Unlock(), storage.ErrLockNotHeld, "Unlock should fail once the lease is released")
+	assert.ErrorIs(t, lease.Refresh(time.Second), storage.ErrLockNotHeld, "Refresh should fail once the lease is released")
+
+	next, err := locker.Lock(context.Background(), "migration", time.Second)
+	assert.NoError(t, err, "Lock should acquire a released lock")
+	assert.NotEqual(t, lease.Token(), next.Token(), "Each lease should have its own token")
+	assert.NoError(t, next.Unlock())
+}
+
+func TestCustomKeyValueLocker(t *testing.T) {
+	store := storage.New(nil, storage.NOSQLKEYVALUE)(storage.CUSTOM, &storage.Config{
+		CustomKeyValue: storage.CustomKeyValue{
+			MemorySize:       1024 * 1024,
+			CleaningEnable:   false,
+			CleaningInterval: time.Second,
+		},
+	}).(storage.INoSQLKeyValue)
+
+	testLocker(t, store)
+}
+
+func TestRedisLocker(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Redis tests in short mode")
+	}
+
+	s, config := setupMiniRedis(t)
+	defer s.Close()
+
+	store := storage.New(nil, storage.NOSQLKEYVALUE)(storage.REDIS, &storage.Config{
+		Redis: *config,
+	}).(storage.INoSQLKeyValue)
+
+	testLocker(t, store)
+}
@@ -0,0 +1,520 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-common-packages/storage"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memoryUser struct {
+	ID    string   `bson:"_id"`
+	Name  string   `bson:"name"`
+	Age   int      `bson:"age"`
+	Tags  []string `bson:"tags,omitempty"`
+	Email string   `bson:"email,omitempty"`
+}
+
+func newMemoryDocument(t *testing.T) storage.INoSQLDocument {
+	client, ok := storage.New(context.Background(), storage.NOSQLDOCUMENT)(storage.INMEMORYDOC, &storage.Config{
+		InMemoryDocument: storage.InMemoryDocument{Name: fmt.Sprintf("%s-%d", t.Name(), time.Now().UnixNano())},
+	}).(storage.INoSQLDocument)
+	assert.True(t, ok, "Should be able to cast to INoSQLDocument")
+
+	return client
+}
+
+func seedMemoryUsers(t *testing.T, client storage.INoSQLDocument) {
+	_, err := client.Create("db", "users", []interface{}{
+		memoryUser{ID: "u1", Name: "Ann", Age: 31, Tags: []string{"admin", "ops"}},
+		memoryUser{ID: "u2", Name: "Bob", Age: 25, Tags: []string{"dev"}},
+		memoryUser{ID: "u3", Name: "Cid", Age: 42},
+		bson.M{"_id": "u4", "name": "Dan", "age": 25},
+	})
+	assert.NoError(t, err, "Create should not return an error")
+}
+
+func userIDs(items interface{}) []string {
+	ids := []string{}
+	for _, user := range *items.(*[]memoryUser) {
+		ids = append(ids, user.ID)
+	}
+	return ids
+}
+
+func TestMemoryDocumentCRUD(t *testing.T) {
+	client := newMemoryDocument(t)
+	seedMemoryUsers(t, client)
+	userType := reflect.TypeOf(memoryUser{})
+
+	result, err := client.Create("db", "users", []interface{}{bson.M{"name": "Eve"}})
+	assert.NoError(t, err)
+	assert.Len(t, result.(*mongo.InsertManyResult).InsertedIDs, 1, "Missing _id should be generated")
+
+	items, err := client.Read("db", "users", bson.M{"age": bson.M{"$gt": 24, "$lt": 40}}, 0, userType)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u1", "u2", "u4"}, userIDs(items))
+
+	items, err = client.Read("db", "users", bson.M{"$or": bson.A{bson.M{"name": "Cid"}, bson.M{"tags": "dev"}}}, 0, userType)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u2", "u3"}, userIDs(items))
+
+	items, err = client.Read("db", "users", bson.M{"_id": bson.M{"$in": bson.A{"u3", "u4", "missing"}}, "age": bson.M{"$eq": 25}}, 0, userType)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u4"}, userIDs(items))
+
+	items, err = client.Read("db", "users", bson.M{"$and": bson.A{bson.M{"age": 25}, bson.M{"tags": bson.M{"$exists": false}}}}, 0, userType)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u4"}, userIDs(items))
+
+	updated, err := client.Update("db", "users", bson.M{"age": 25}, bson.M{"$inc": bson.M{"age": 1}, "$set": bson.M{"email": "x@example.com"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), updated.(*mongo.UpdateResult).ModifiedCount)
+
+	user, err := client.FindOne(context.Background(), "db", "users", bson.M{"_id": "u2"}, userType)
+	assert.NoError(t, err)
+	assert.Equal(t, 26, user.(*memoryUser).Age)
+	assert.Equal(t, "x@example.com", user.(*memoryUser).Email)
+
+	_, err = client.UpdateOne(context.Background(), "db", "users", bson.M{"_id": "u2"}, bson.M{"$unset": bson.M{"email": ""}})
+	assert.NoError(t, err)
+	user, err = client.FindOne(context.Background(), "db", "users", bson.M{"_id": "u2"}, userType)
+	assert.NoError(t, err)
+	assert.Empty(t, user.(*memoryUser).Email)
+
+	_, err = client.UpdateOne(context.Background(), "db", "users", bson.M{"_id": "u2"}, bson.M{"$set": bson.M{"_id": "changed"}})
+	assert.Error(t, err, "_id should not be modifiable")
+
+	upserted, err := client.Upsert(context.Background(), "db", "users", bson.M{"_id": "u9"}, bson.M{"$set": bson.M{"name": "Ivy"}, "$setOnInsert": bson.M{"age": 18}})
+	assert.NoError(t, err)
+	assert.Equal(t, "u9", upserted.(*mongo.UpdateResult).UpsertedID)
+
+	counter, err := client.FindOneAndUpdate(context.Background(), "db", "users", bson.M{"_id": "u9"}, bson.M{"$inc": bson.M{"age": 2}}, storage.ReturnAfter, userType)
+	assert.NoError(t, err)
+	assert.Equal(t, 20, counter.(*memoryUser).Age)
+
+	_, err = client.ReplaceOne(context.Background(), "db", "users", bson.M{"_id": "u9"}, bson.M{"name": "Ivy Replaced"})
+	assert.NoError(t, err)
+	user, err = client.FindOne(context.Background(), "db", "users", bson.M{"_id": "u9"}, userType)
+	assert.NoError(t, err)
+	assert.Equal(t, "Ivy Replaced", user.(*memoryUser).Name)
+	assert.Equal(t, 0, user.(*memoryUser).Age, "Replace should drop the fields missing from the replacement")
+
+	deleted, err := client.Delete("db", "users", bson.M{"age": bson.M{"$gte": 30}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), deleted.(*mongo.DeleteResult).DeletedCount)
+
+	_, err = client.FindOne(context.Background(), "db", "users", bson.M{"_id": "u1"}, userType)
+	assert.True(t, errors.Is(err, storage.ErrDocumentNotFound), "Deleted document should not be found")
+
+	_, err = client.Read("db", "users", bson.M{"age": bson.M{"$where": "1"}}, 0, userType)
+	assert.True(t, errors.Is(err, storage.ErrUnsupportedOperator), "Unknown operators should be rejected")
+}
+
+func TestMemoryDocumentReadPage(t *testing.T) {
+	client := newMemoryDocument(t)
+	seedMemoryUsers(t, client)
+	userType := reflect.TypeOf(memoryUser{})
+
+	findOptions := &storage.FindOptions{
+		Limit:        2,
+		Sort:         []storage.SortField{{Field: "age", Descending: true}},
+		IncludeTotal: true,
+	}
+	page, err := client.ReadPage(context.Background(), "db", "users", bson.M{}, findOptions, userType)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u3", "u1"}, userIDs(page.Items))
+	assert.Equal(t, int64(4), page.Total)
+	assert.NotEmpty(t, page.NextCursor)
+
+	findOptions.Cursor = page.NextCursor
+	page, err = client.ReadPage(context.Background(), "db", "users", bson.M{}, findOptions, userType)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u2", "u4"}, userIDs(page.Items), "Equal ages should be ordered by _id")
+	assert.Empty(t, page.NextCursor, "Last page should have no cursor")
+
+	cursor, err := client.ReadStream(context.Background(), "db", "users", bson.M{"age": 25}, &storage.FindOptions{Projection: []string{"name"}})
+	assert.NoError(t, err)
+	names := []string{}
+	for cursor.Next(context.Background()) {
+		var user memoryUser
+		assert.NoError(t, cursor.Decode(&user))
+		assert.Equal(t, 0, user.Age, "Projection should drop the other fields")
+		names = append(names, user.Name)
+	}
+	assert.NoError(t, cursor.Err())
+	assert.NoError(t, cursor.Close(context.Background()))
+	assert.Equal(t, []string{"Bob", "Dan"}, names)
+}
+
+// testReadPageNullGroup pages through documents whose sort key is null or missing,
+// with page boundaries inside the null group in both sort directions
+func testReadPageNullGroup(t *testing.T, client storage.INoSQLDocument, db, collection string) {
+	_, err := client.Create(db, collection, []interface{}{
+		bson.M{"_id": "n1"},
+		bson.M{"_id": "n2", "rank": nil},
+		bson.M{"_id": "n3"},
+		bson.M{"_id": "r1", "rank": 10},
+		bson.M{"_id": "r2", "rank": 20},
+	})
+	assert.NoError(t, err, "Create should not return an error")
+
+	idType := reflect.TypeOf(struct {
+		ID string `bson:"_id"`
+	}{})
+	readAll := func(sort storage.SortField) []string {
+		ids := []string{}
+		findOptions := &storage.FindOptions{Limit: 2, Sort: []storage.SortField{sort}}
+		for {
+			page, err := client.ReadPage(context.Background(), db, collection, bson.M{}, findOptions, idType)
+			assert.NoError(t, err, "ReadPage should not return an error")
+			if err != nil {
+				return ids
+			}
+
+			items := reflect.ValueOf(page.Items).Elem()
+			for i := 0; i < items.Len(); i++ {
+				ids = append(ids, items.Index(i).Field(0).String())
+			}
+
+			if page.NextCursor == "" || len(ids) > 5 {
+				return ids
+			}
+			findOptions.Cursor = page.NextCursor
+		}
+	}
+
+	assert.Equal(t, []string{"n1", "n2", "n3", "r1", "r2"}, readAll(storage.SortField{Field: "rank"}),
+		"Null and missing keys should sort first and the page after them should resume inside the null group")
+	assert.Equal(t, []string{"r2", "r1", "n1", "n2", "n3"}, readAll(storage.SortField{Field: "rank", Descending: true}),
+		"Null and missing keys should sort last in descending order")
+}
+
+func TestMemoryDocumentReadPageNullGroup(t *testing.T) {
+	testReadPageNullGroup(t, newMemoryDocument(t), "db", "ranks")
+}
+
+func TestMemoryDocumentIndexes(t *testing.T) {
+	client := newMemoryDocument(t)
+	seedMemoryUsers(t, client)
+	ctx := context.Background()
+
+	_, err := client.Create("db", "users", []interface{}{bson.M{"_id": "u1"}})
+	var writeError *storage.WriteError
+	assert.True(t, errors.As(err, &writeError), "Duplicate _id should return a write error")
+	assert.Equal(t, 11000, writeError.Code)
+
+	names, err := client.EnsureIndexes(ctx, "db", "users", []storage.IndexSpec{
+		{Keys: []storage.IndexKey{{Field: "email"}}, Unique: true, Sparse: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"email_1"}, names)
+
+	_, err = client.EnsureIndexes(ctx, "db", "users", []storage.IndexSpec{
+		{Keys: []storage.IndexKey{{Field: "email"}}, Unique: true, Sparse: true},
+	})
+	assert.NoError(t, err, "Ensuring an existing index should be a no-op")
+
+	_, err = client.UpdateOne(ctx, "db", "users", bson.M{"_id": "u1"}, bson.M{"$set": bson.M{"email": "a@example.com"}})
+	assert.NoError(t, err)
+	_, err = client.UpdateOne(ctx, "db", "users", bson.M{"_id": "u2"}, bson.M{"$set": bson.M{"email": "a@example.com"}})
+	assert.True(t, errors.As(err, &writeError), "Duplicate email should return a write error")
+
+	indexes, err := client.ListIndexes(ctx, "db", "users")
+	assert.NoError(t, err)
+	assert.Len(t, indexes, 2)
+	assert.Equal(t, "_id_", indexes[0].Name)
+
+	assert.NoError(t, client.DropIndex(ctx, "db", "users", "email_1"))
+	_, err = client.UpdateOne(ctx, "db", "users", bson.M{"_id": "u2"}, bson.M{"$set": bson.M{"email": "a@example.com"}})
+	assert.NoError(t, err, "Dropped index should not be enforced")
+
+	_, err = client.EnsureIndexes(ctx, "db", "sessions", []storage.IndexSpec{
+		{Keys: []storage.IndexKey{{Field: "expiresAt"}}, ExpireAfter: time.Millisecond},
+	})
+	assert.Error(t, err, "Sub-second TTL indexes should be rejected")
+	_, err = client.EnsureIndexes(ctx, "db", "sessions", []storage.IndexSpec{
+		{Keys: []storage.IndexKey{{Field: "expiresAt"}}, ExpireAfter: time.Second},
+	})
+	assert.NoError(t, err)
+	_, err = client.Create("db", "sessions", []interface{}{bson.M{"expiresAt": time.Now().Add(-time.Minute)}, bson.M{"expiresAt": time.Now().Add(time.Hour)}})
+	assert.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	items, err := client.Read("db", "sessions", bson.M{}, 0, reflect.TypeOf(bson.M{}))
+	assert.NoError(t, err)
+	assert.Len(t, *items.(*[]bson.M), 1, "Expired documents should be removed")
+}
+
+func TestMemoryDocumentAggregate(t *testing.T) {
+	client := newMemoryDocument(t)
+	ctx := context.Background()
+
+	_, err := client.Create("db", "customers", []interface{}{
+		bson.M{"_id": "c1", "name": "Ann"},
+		bson.M{"_id": "c2", "name": "Bob"},
+	})
+	assert.NoError(t, err)
+	_, err = client.Create("db", "orders", []interface{}{
+		bson.M{"customerId": "c1", "amount": 10, "status": "paid"},
+		bson.M{"customerId": "c1", "amount": 5, "status": "paid"},
+		bson.M{"customerId": "c2", "amount": 7, "status": "paid"},
+		bson.M{"customerId": "c2", "amount": 100, "status": "cancelled"},
+	})
+	assert.NoError(t, err)
+
+	type customerTotal struct {
+		ID       string   `bson:"_id"`
+		Total    int      `bson:"total"`
+		Orders   int      `bson:"orders"`
+		Customer []bson.M `bson:"customer"`
+	}
+
+	pipeline := storage.NewPipeline().
+		Match(bson.M{"status": "paid"}).
+		Group("$customerId",
+			storage.Accumulator{Field: "total", Operator: "$sum", Expression: "$amount"},
+			storage.Accumulator{Field: "orders", Operator: "$sum", Expression: 1}).
+		Lookup("customers", "_id", "_id", "customer").
+		Sort(storage.SortField{Field: "total", Descending: true})
+	totals, err := client.Aggregate(ctx, "db", "orders", pipeline, reflect.TypeOf(customerTotal{}), nil)
+	assert.NoError(t, err)
+
+	results := *totals.(*[]customerTotal)
+	assert.Len(t, results, 2)
+	assert.Equal(t, "c1", results[0].ID)
+	assert.Equal(t, 15, results[0].Total)
+	assert.Equal(t, 2, results[0].Orders)
+	assert.Equal(t, "Ann", results[0].Customer[0]["name"])
+	assert.Equal(t, 7, results[1].Total)
+
+	cursor, err := client.AggregateStream(ctx, "db", "orders", storage.NewPipeline().Match(bson.M{"status": "paid"}).Count("paid"), nil)
+	assert.NoError(t, err)
+	assert.True(t, cursor.Next(ctx))
+	var count struct {
+		Paid int `bson:"paid"`
+	}
+	assert.NoError(t, cursor.Decode(&count))
+	assert.Equal(t, 3, count.Paid)
+}
+
+func TestMemoryDocumentTransaction(t *testing.T) {
+	client := newMemoryDocument(t)
+	ctx := context.Background()
+
+	_, err := client.Create("bank", "accounts", []interface{}{
+		bson.M{"_id": "a", "balance": 100},
+		bson.M{"_id": "b", "balance": 0},
+	})
+	assert.NoError(t, err)
+
+	transfer := func(amount int) error {
+		return client.WithTransaction(ctx, func(tx storage.IDocumentTx) error {
+			if _, err := tx.UpdateOne("bank", "accounts", bson.M{"_id": "a"}, bson.M{"$inc": bson.M{"balance": -amount}}); err != nil {
+				return err
+			}
+			if _, err := tx.FindOne("bank", "accounts", bson.M{"_id": "a", "balance": bson.M{"$lt": 0}}, reflect.TypeOf(bson.M{})); err == nil {
+				return errors.New("insufficient funds")
+			}
+			_, err := tx.UpdateOne("bank", "accounts", bson.M{"_id": "b"}, bson.M{"$inc": bson.M{"balance": amount}})
+			return err
+		})
+	}
+
+	assert.NoError(t, transfer(60))
+	assert.EqualError(t, transfer(60), "insufficient funds")
+
+	balance := func(id string) interface{} {
+		account, err := client.FindOne(ctx, "bank", "accounts", bson.M{"_id": id}, reflect.TypeOf(bson.M{}))
+		assert.NoError(t, err)
+		return (*account.(*bson.M))["balance"]
+	}
+	assert.EqualValues(t, 40, balance("a"), "Aborted transaction should not change the balance")
+	assert.EqualValues(t, 60, balance("b"))
+}
+
+// testBulkWrite runs unordered and ordered bulk writes holding a duplicate key error
+func testBulkWrite(t *testing.T, client storage.INoSQLDocument, db, collection string) {
+	_, err := client.Create(db, collection, []interface{}{
+		bson.M{"_id": "u1", "name": "Ann", "age": 31},
+		bson.M{"_id": "u2", "name": "Bob", "age": 25},
+		bson.M{"_id": "u3", "name": "Cid", "age": 42},
+		bson.M{"_id": "u4", "name": "Dan", "age": 25},
+	})
+	assert.NoError(t, err, "Create should not return an error")
+	ctx := context.Background()
+
+	models := []storage.WriteModel{
+		{Operation: storage.WriteInsertOne, Document: bson.M{"_id": "u5", "name": "Eve"}},
+		{Operation: storage.WriteInsertOne, Document: bson.M{"_id": "u1", "name": "Duplicate"}},
+		{Operation: storage.WriteUpdateMany, Filter: bson.M{"age": 25}, Update: bson.M{"$set": bson.M{"junior": true}}},
+		{Operation: storage.WriteUpdateOne, Filter: bson.M{"_id": "u6"}, Update: bson.M{"$set": bson.M{"name": "Fay"}}, Upsert: true},
+		{Operation: storage.WriteDeleteOne, Filter: bson.M{"_id": "u3"}},
+	}
+
+	result, err := client.BulkWrite(ctx, db, collection, models, false)
+	assert.True(t, errors.Is(err, storage.ErrBulkWrite))
+	assert.Equal(t, int64(1), result.InsertedCount)
+	assert.Equal(t, int64(2), result.ModifiedCount)
+	assert.Equal(t, int64(1), result.UpsertedCount)
+	assert.Equal(t, int64(1), result.DeletedCount)
+	assert.Equal(t, 11000, result.Operations[1].Err.Code)
+	assert.Equal(t, "u6", result.Operations[3].UpsertedID)
+
+	models[0].Document = bson.M{"_id": "u7"}
+	result, err = client.BulkWrite(ctx, db, collection, models, true)
+	assert.True(t, errors.Is(err, storage.ErrBulkWrite))
+	assert.True(t, result.Operations[0].Executed)
+	assert.NotNil(t, result.Operations[1].Err)
+	assert.False(t, result.Operations[2].Executed, "Ordered bulk write should stop at the first failure")
+}
+
+func TestMemoryDocumentBulkWrite(t *testing.T) {
+	testBulkWrite(t, newMemoryDocument(t), "db", "users")
+}
+
+func TestMemoryDocumentWatch(t *testing.T) {
+	client := newMemoryDocument(t)
+	tokenStore := storage.New(context.Background(), storage.NOSQLKEYVALUE)(storage.CUSTOM, &storage.Config{
+		CustomKeyValue: storage.CustomKeyValue{MemorySize: 1024 * 1024, CleaningInterval: time.Minute},
+	}).(storage.INoSQLKeyValue)
+	watchOptions := &storage.WatchOptions{
+		DataModel:        reflect.TypeOf(memoryUser{}),
+		ResumeTokenStore: tokenStore,
+		ResumeTokenKey:   fmt.Sprintf("%s-%d", t.Name(), time.Now().UnixNano()),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := client.Watch(ctx, "db", "users", storage.NewPipeline().Match(bson.M{"operationType": bson.M{"$in": bson.A{"insert", "update"}}}), watchOptions)
+	assert.NoError(t, err)
+
+	_, err = client.Create("db", "users", []interface{}{memoryUser{ID: "u1", Name: "Ann"}, memoryUser{ID: "u2", Name: "Bob"}})
+	assert.NoError(t, err)
+	_, err = client.Create("db", "other", []interface{}{bson.M{"_id": "ignored"}})
+	assert.NoError(t, err)
+	_, err = client.DeleteOne(context.Background(), "db", "users", bson.M{"_id": "u2"})
+	assert.NoError(t, err)
+	_, err = client.UpdateOne(context.Background(), "db", "users", bson.M{"_id": "u1"}, bson.M{"$set": bson.M{"age": 30}})
+	assert.NoError(t, err)
+
+	event := <-events
+	assert.Equal(t, storage.ChangeInsert, event.Operation)
+	assert.Equal(t, "Ann", event.FullDocument.(*memoryUser).Name)
+
+	event = <-events
+	assert.Equal(t, storage.ChangeInsert, event.Operation, "Filtered out delete should not be delivered")
+	assert.Equal(t, "u2", event.DocumentID)
+
+	event = <-events
+	assert.Equal(t, storage.ChangeUpdate, event.Operation)
+	assert.EqualValues(t, 30, event.UpdatedFields["age"])
+	assert.Nil(t, event.FullDocument, "Updates should not include the full document by default")
+	cancel()
+	for range events {
+	}
+
+	// Only the first two events were processed, so the watch resumes with the update
+	events, err = client.Watch(context.Background(), "db", "users", nil, watchOptions)
+	assert.NoError(t, err)
+	event = <-events
+	assert.Equal(t, storage.ChangeDelete, event.Operation)
+	assert.Equal(t, "u2", event.DocumentID)
+}
+
+// nextChangeEvent returns the next change event, failing the test when none is received in time
+func nextChangeEvent(t *testing.T, events <-chan storage.ChangeEvent) storage.ChangeEvent {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(10 * time.Second):
+		t.Fatal("No change event received")
+		return storage.ChangeEvent{}
+	}
+}
+
+func TestMemoryDocumentWatchRedisResumeToken(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Redis tests in short mode")
+	}
+
+	s, config := setupMiniRedis(t)
+	defer s.Close()
+
+	client := newMemoryDocument(t)
+	tokenStore := storage.New(context.Background(), storage.NOSQLKEYVALUE)(storage.REDIS, &storage.Config{
+		Redis: *config,
+	}).(storage.INoSQLKeyValue)
+	watchOptions := &storage.WatchOptions{ResumeTokenStore: tokenStore}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := client.Watch(ctx, "db", "users", nil, watchOptions)
+	assert.NoError(t, err)
+
+	_, err = client.Create("db", "users", []interface{}{bson.M{"_id": "u1"}, bson.M{"_id": "u2"}})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "u1", nextChangeEvent(t, events).DocumentID)
+	assert.Equal(t, "u2", nextChangeEvent(t, events).DocumentID)
+	cancel()
+	for range events {
+	}
+
+	_, err = client.Create("db", "users", []interface{}{bson.M{"_id": "u3"}})
+	assert.NoError(t, err)
+
+	token, err := s.Get("watch:db.users")
+	assert.NoError(t, err, "The resume token should be stored in Redis")
+	assert.NotEmpty(t, token)
+
+	// Only the first event was processed, so the watch resumes with the second one
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	events, err = client.Watch(ctx, "db", "users", nil, watchOptions)
+	assert.NoError(t, err)
+	assert.Equal(t, "u2", nextChangeEvent(t, events).DocumentID, "Watch should resume after the last processed change")
+	assert.Equal(t, "u3", nextChangeEvent(t, events).DocumentID)
+}
+
+func TestMemoryDocumentCountDistinctExists(t *testing.T) {
+	client := newMemoryDocument(t)
+	seedMemoryUsers(t, client)
+	ctx := context.Background()
+
+	count, err := client.Count(ctx, "db", "users", bson.M{"age": 25})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	count, err = client.Count(ctx, "db", "users", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), count, "Nil filter should count every document")
+
+	count, err = client.EstimatedCount(ctx, "db", "users")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+
+	count, err = client.EstimatedCount(ctx, "db", "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	ages, err := client.Distinct(ctx, "db", "users", "age", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{int32(25), int32(31), int32(42)}, ages)
+
+	tags, err := client.Distinct(ctx, "db", "users", "tags", bson.M{"age": bson.M{"$lt": 40}})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"admin", "dev", "ops"}, tags, "Arrays should be unwound")
+
+	exists, err := client.Exists(ctx, "db", "users", bson.M{"name": "Cid"})
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = client.Exists(ctx, "db", "users", bson.M{"name": "Zed"})
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -0,0 +1,262 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-common-packages/storage"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// newMongoDocument returns a MongoDB client and a collection name unique to the test,
+// whose documents are deleted when the test ends
+func newMongoDocument(t *testing.T) (*storage.MongoClient, string) {
+	if testing.Short() {
+		t.Skip("Skipping MongoDB tests in short mode")
+	}
+
+	uri := os.Getenv("MONGODB_URI")
+	if uri == "" {
+		t.Skip("Skipping MongoDB tests, MONGODB_URI is not set")
+	}
+
+	client := storage.New(context.Background(), storage.NOSQLDOCUMENT)(storage.MONGODB, &storage.Config{
+		MongoDB: storage.MongoDB{URI: uri},
+	}).(*storage.MongoClient)
+
+	collection := fmt.Sprintf("%s_%d", t.Name(), time.Now().UnixNano())
+	t.Cleanup(func() {
+		if _, err := client.Delete("storage_test", collection, bson.M{}); err != nil {
+			t.Logf("Unable to clean up collection %s: %v", collection, err)
+		}
+	})
+
+	return client, collection
+}
+
+func TestMongoDBReadPageNullGroup(t *testing.T) {
+	client, collection := newMongoDocument(t)
+
+	testReadPageNullGroup(t, client, "storage_test", collection)
+}
+
+func TestMongoDBBulkWrite(t *testing.T) {
+	client, collection := newMongoDocument(t)
+
+	testBulkWrite(t, client, "storage_test", collection)
+}
+
+func TestMongoDBWatchResume(t *testing.T) {
+	client, collection := newMongoDocument(t)
+	tokenStore := storage.New(context.Background(), storage.NOSQLKEYVALUE)(storage.CUSTOM, &storage.Config{
+		CustomKeyValue: storage.CustomKeyValue{MemorySize: 1024 * 1024, CleaningInterval: time.Minute},
+	}).(storage.INoSQLKeyValue)
+	watchOptions := &storage.WatchOptions{
+		ResumeTokenStore: tokenStore,
+		ResumeTokenKey:   collection,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := client.Watch(ctx, "storage_test", collection, nil, watchOptions)
+	if err != nil {
+		t.Skipf("Skipping change stream tests, MongoDB does not support them: %v", err)
+	}
+
+	_, err = client.Create("storage_test", collection, []interface{}{
+		bson.M{"_id": "w1"},
+		bson.M{"_id": "w2"},
+		bson.M{"_id": "w3"},
+	})
+	assert.NoError(t, err)
+
+	event := nextChangeEvent(t, events)
+	assert.NoError(t, event.Err)
+	assert.Equal(t, storage.ChangeInsert, event.Operation)
+	assert.Equal(t, "w1", event.DocumentID)
+	event = nextChangeEvent(t, events)
+	assert.Equal(t, "w2", event.DocumentID)
+	cancel()
+	for range events {
+	}
+
+	// Only the first event was processed, so the watch resumes with the second one
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	events, err = client.Watch(ctx, "storage_test", collection, nil, watchOptions)
+	assert.NoError(t, err)
+	event = nextChangeEvent(t, events)
+	assert.NoError(t, event.Err)
+	assert.Equal(t, "w2", event.DocumentID, "Watch should resume after the last processed change")
+	assert.Equal(t, "w3", nextChangeEvent(t, events).DocumentID)
+}
+
+func TestMongoDBReadStream(t *testing.T) {
+	client, collection := newMongoDocument(t)
+	ctx := context.Background()
+
+	_, err := client.Create("storage_test", collection, []interface{}{
+		memoryUser{ID: "u1", Name: "Ann", Age: 31},
+		memoryUser{ID: "u2", Name: "Bob", Age: 25},
+		memoryUser{ID: "u3", Name: "Cid", Age: 25},
+	})
+	assert.NoError(t, err)
+
+	cursor, err := client.ReadStream(ctx, "storage_test", collection, bson.M{"age": 25}, &storage.FindOptions{
+		Sort:       []storage.SortField{{Field: "name"}},
+		Projection: []string{"name"},
+	})
+	assert.NoError(t, err)
+	names := []string{}
+	for cursor.Next(ctx) {
+		var user memoryUser
+		assert.NoError(t, cursor.Decode(&user))
+		assert.Equal(t, 0, user.Age, "Projection should drop the other fields")
+		names = append(names, user.Name)
+	}
+	assert.NoError(t, cursor.Err())
+	assert.NoError(t, cursor.Close(ctx))
+	assert.Equal(t, []string{"Bob", "Cid"}, names)
+	assert.False(t, cursor.Next(ctx), "Closed cursor should not return documents")
+}
+
+func TestMongoDBSingleDocument(t *testing.T) {
+	client, collection := newMongoDocument(t)
+	ctx := context.Background()
+	userType := reflect.TypeOf(memoryUser{})
+
+	_, err := client.FindOne(ctx, "storage_test", collection, bson.M{"_id": "u1"}, userType)
+	assert.True(t, errors.Is(err, storage.ErrDocumentNotFound), "Missing document should not be found")
+
+	upserted, err := client.Upsert(ctx, "storage_test", collection, bson.M{"_id": "u1"}, bson.M{"$set": bson.M{"name": "Ann"}, "$setOnInsert": bson.M{"age": 30}})
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", upserted.(*mongo.UpdateResult).UpsertedID, "First upsert should insert")
+	upserted, err = client.Upsert(ctx, "storage_test", collection, bson.M{"_id": "u1"}, bson.M{"$set": bson.M{"name": "Anna"}, "$setOnInsert": bson.M{"age": 99}})
+	assert.NoError(t, err)
+	assert.Nil(t, upserted.(*mongo.UpdateResult).UpsertedID, "Second upsert should update")
+	assert.Equal(t, int64(1), upserted.(*mongo.UpdateResult).ModifiedCount)
+
+	user, err := client.FindOne(ctx, "storage_test", collection, bson.M{"_id": "u1"}, userType)
+	assert.NoError(t, err)
+	assert.Equal(t, "Anna", user.(*memoryUser).Name)
+	assert.Equal(t, 30, user.(*memoryUser).Age, "$setOnInsert should only apply to the insert")
+
+	updated, err := client.UpdateOne(ctx, "storage_test", collection, bson.M{"_id": "u1"}, bson.M{"$set": bson.M{"email": "ann@example.com"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), updated.(*mongo.UpdateResult).ModifiedCount)
+
+	before, err := client.FindOneAndUpdate(ctx, "storage_test", collection, bson.M{"_id": "u1"}, bson.M{"$inc": bson.M{"age": 1}}, storage.ReturnBefore, userType)
+	assert.NoError(t, err)
+	assert.Equal(t, 30, before.(*memoryUser).Age)
+	after, err := client.FindOneAndUpdate(ctx, "storage_test", collection, bson.M{"_id": "u1"}, bson.M{"$inc": bson.M{"age": 1}}, storage.ReturnAfter, userType)
+	assert.NoError(t, err)
+	assert.Equal(t, 32, after.(*memoryUser).Age)
+	_, err = client.FindOneAndUpdate(ctx, "storage_test", collection, bson.M{"_id": "missing"}, bson.M{"$inc": bson.M{"age": 1}}, storage.ReturnAfter, userType)
+	assert.True(t, errors.Is(err, storage.ErrDocumentNotFound))
+
+	_, err = client.ReplaceOne(ctx, "storage_test", collection, bson.M{"_id": "u1"}, bson.M{"name": "Ann Replaced"})
+	assert.NoError(t, err)
+	user, err = client.FindOne(ctx, "storage_test", collection, bson.M{"_id": "u1"}, userType)
+	assert.NoError(t, err)
+	assert.Equal(t, "Ann Replaced", user.(*memoryUser).Name)
+	assert.Empty(t, user.(*memoryUser).Email, "Replace should drop the fields missing from the replacement")
+
+	deleted, err := client.DeleteOne(ctx, "storage_test", collection, bson.M{"_id": "u1"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted.(*mongo.DeleteResult).DeletedCount)
+	_, err = client.FindOne(ctx, "storage_test", collection, bson.M{"_id": "u1"}, userType)
+	assert.True(t, errors.Is(err, storage.ErrDocumentNotFound), "Deleted document should not be found")
+}
+
+func TestMongoDBIndexes(t *testing.T) {
+	client, collection := newMongoDocument(t)
+	ctx := context.Background()
+
+	_, err := client.Create("storage_test", collection, []interface{}{
+		memoryUser{ID: "u1", Name: "Ann"},
+		memoryUser{ID: "u2", Name: "Bob"},
+	})
+	assert.NoError(t, err)
+
+	emailIndex := []storage.IndexSpec{{Keys: []storage.IndexKey{{Field: "email"}}, Unique: true, Sparse: true}}
+	names, err := client.EnsureIndexes(ctx, "storage_test", collection, emailIndex)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"email_1"}, names)
+	_, err = client.EnsureIndexes(ctx, "storage_test", collection, emailIndex)
+	assert.NoError(t, err, "Ensuring an existing index should be a no-op")
+
+	_, err = client.UpdateOne(ctx, "storage_test", collection, bson.M{"_id": "u1"}, bson.M{"$set": bson.M{"email": "a@example.com"}})
+	assert.NoError(t, err)
+	_, err = client.UpdateOne(ctx, "storage_test", collection, bson.M{"_id": "u2"}, bson.M{"$set": bson.M{"email": "a@example.com"}})
+	assert.True(t, mongo.IsDuplicateKeyError(err), "Duplicate email should return a duplicate key error")
+
+	_, err = client.EnsureIndexes(ctx, "storage_test", collection, []storage.IndexSpec{
+		{Keys: []storage.IndexKey{{Field: "expiresAt"}}, ExpireAfter: 1500 * time.Millisecond},
+	})
+	assert.NoError(t, err)
+
+	indexes, err := client.ListIndexes(ctx, "storage_test", collection)
+	assert.NoError(t, err)
+	expireAfter := map[string]time.Duration{}
+	for _, index := range indexes {
+		expireAfter[index.Name] = index.ExpireAfter
+	}
+	assert.Contains(t, expireAfter, "_id_")
+	assert.Contains(t, expireAfter, "email_1")
+	assert.Equal(t, 2*time.Second, expireAfter["expiresAt_1"], "TTL should be rounded up to whole seconds")
+
+	assert.NoError(t, client.DropIndex(ctx, "storage_test", collection, "email_1"))
+	_, err = client.UpdateOne(ctx, "storage_test", collection, bson.M{"_id": "u2"}, bson.M{"$set": bson.M{"email": "a@example.com"}})
+	assert.NoError(t, err, "Dropped index should not be enforced")
+}
+
+func TestMongoDBTransaction(t *testing.T) {
+	client, collection := newMongoDocument(t)
+	ctx := context.Background()
+	accountType := reflect.TypeOf(bson.M{})
+
+	_, err := client.Create("storage_test", collection, []interface{}{
+		bson.M{"_id": "a", "balance": 100},
+		bson.M{"_id": "b", "balance": 0},
+	})
+	assert.NoError(t, err)
+
+	if err := client.WithTransaction(ctx, func(tx storage.IDocumentTx) error {
+		_, err := tx.FindOne("storage_test", collection, bson.M{"_id": "a"}, accountType)
+		return err
+	}); err != nil {
+		t.Skipf("Skipping transaction tests, MongoDB does not support them: %v", err)
+	}
+
+	err = client.WithTransaction(ctx, func(tx storage.IDocumentTx) error {
+		if _, err := tx.UpdateOne("storage_test", collection, bson.M{"_id": "a"}, bson.M{"$inc": bson.M{"balance": -60}}); err != nil {
+			return err
+		}
+		if _, err := tx.UpdateOne("storage_test", collection, bson.M{"_id": "b"}, bson.M{"$inc": bson.M{"balance": 60}}); err != nil {
+			return err
+		}
+		return errors.New("rejected transfer")
+	})
+	assert.EqualError(t, err, "rejected transfer")
+
+	balance := func(id string) interface{} {
+		account, err := client.FindOne(ctx, "storage_test", collection, bson.M{"_id": id}, accountType)
+		assert.NoError(t, err)
+		return (*account.(*bson.M))["balance"]
+	}
+	assert.EqualValues(t, 100, balance("a"), "Aborted transaction should not change the balance")
+	assert.EqualValues(t, 0, balance("b"))
+
+	assert.NoError(t, client.WithTransaction(ctx, func(tx storage.IDocumentTx) error {
+		_, err := tx.UpdateOne("storage_test", collection, bson.M{"_id": "a"}, bson.M{"$inc": bson.M{"balance": -60}})
+		return err
+	}))
+	assert.EqualValues(t, 40, balance("a"), "Committed transaction should change the balance")
+}
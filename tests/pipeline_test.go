@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/golang-common-packages/storage"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestPipelineBuilder(t *testing.T) {
+	base := storage.NewPipeline().Match(bson.M{"status": "paid"})
+
+	byCustomer := base.
+		Group("$customerId",
+			storage.Accumulator{Field: "total", Operator: "$sum", Expression: "$amount"},
+			storage.Accumulator{Field: "orders", Operator: "$sum", Expression: 1},
+		).
+		Sort(storage.SortField{Field: "total", Descending: true}).
+		Limit(10)
+
+	assert.Len(t, base, 1, "Builder methods should not modify the receiver")
+	assert.Len(t, byCustomer, 4, "Each builder method should append one stage")
+	assert.Equal(t, bson.D{{Key: "$group", Value: bson.D{
+		{Key: "_id", Value: "$customerId"},
+		{Key: "total", Value: bson.D{{Key: "$sum", Value: "$amount"}}},
+		{Key: "orders", Value: bson.D{{Key: "$sum", Value: 1}}},
+	}}}, byCustomer[1], "Group should build the accumulators in order")
+	assert.Equal(t, bson.D{{Key: "$sort", Value: bson.D{{Key: "total", Value: -1}}}}, byCustomer[2])
+
+	byDay := base.Count("orders")
+	assert.Equal(t, bson.D{{Key: "$count", Value: "orders"}}, byDay[1], "Sharing a prefix should not overwrite sibling pipelines")
+	assert.Equal(t, "$group", byCustomer[1][0].Key)
+
+	faceted := storage.NewPipeline().
+		Lookup("customers", "customerId", "_id", "customer").
+		Unwind("customer", false).
+		Facet(map[string]storage.Pipeline{
+			"total": storage.NewPipeline().Count("count"),
+			"page":  storage.NewPipeline().Skip(20).Limit(10),
+		})
+
+	assert.Equal(t, bson.D{{Key: "$unwind", Value: bson.D{
+		{Key: "path", Value: "$customer"},
+		{Key: "preserveNullAndEmptyArrays", Value: false},
+	}}}, faceted[1])
+
+	facet := faceted[2][0].Value.(bson.D)
+	assert.Equal(t, "page", facet[0].Key, "Facets should be ordered by name")
+	assert.Equal(t, "total", facet[1].Key, "Facets should be ordered by name")
+
+	_, err := bson.Marshal(bson.D{{Key: "pipeline", Value: faceted}})
+	assert.NoError(t, err, "Pipeline should be encodable to BSON")
+}
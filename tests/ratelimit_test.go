@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-common-packages/hash"
+	"github.com/golang-common-packages/storage"
+	"github.com/golang-common-packages/storage/ratelimit"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRateLimiter(t *testing.T, store storage.INoSQLKeyValue) {
+	algorithms := map[string]ratelimit.Algorithm{
+		"Fixed Window":       ratelimit.FIXEDWINDOW,
+		"Sliding Window Log": ratelimit.SLIDINGWINDOWLOG,
+		"Token Bucket":       ratelimit.TOKENBUCKET,
+	}
+
+	for name, algorithm := range algorithms {
+		t.Run(name, func(t *testing.T) {
+			limiter, err := ratelimit.New(store, ratelimit.Config{
+				Algorithm: algorithm,
+				Limit:     2,
+				Window:    time.Minute,
+				Prefix:    name + ":",
+			})
+			assert.NoError(t, err, "New should not return an error")
+
+			for i := int64(1); i <= 2; i++ {
+				result, err := limiter.Allow("client")
+				assert.NoError(t, err, "Allow should not return an error")
+				assert.True(t, result.Allowed, "Requests within the limit should be allowed")
+				assert.Equal(t, 2-i, result.Remaining)
+			}
+
+			result, err := limiter.Allow("client")
+			assert.NoError(t, err, "Allow should not return an error")
+			assert.False(t, result.Allowed, "Requests over the limit should be rejected")
+			assert.Greater(t, int64(result.RetryAfter), int64(0), "Rejected requests should have a retry delay")
+
+			result, err = limiter.Allow("other-client")
+			assert.NoError(t, err)
+			assert.True(t, result.Allowed, "Limits should be tracked per key")
+		})
+	}
+}
+
+func TestCustomKeyValueRateLimiter(t *testing.T) {
+	store := storage.New(nil, storage.NOSQLKEYVALUE)(storage.CUSTOM, &storage.Config{
+		CustomKeyValue: storage.CustomKeyValue{
+			MemorySize:       1024 * 1024 * 4,
+			CleaningEnable:   false,
+			CleaningInterval: time.Second,
+		},
+	}).(storage.INoSQLKeyValue)
+
+	testRateLimiter(t, store)
+}
+
+func TestRedisRateLimiter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Redis tests in short mode")
+	}
+
+	s, config := setupMiniRedis(t)
+	defer s.Close()
+
+	store := storage.New(nil, storage.NOSQLKEYVALUE)(storage.REDIS, &storage.Config{
+		Redis: *config,
+	}).(storage.INoSQLKeyValue)
+
+	testRateLimiter(t, store)
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	store := storage.New(nil, storage.NOSQLKEYVALUE)(storage.CUSTOM, &storage.Config{
+		CustomKeyValue: storage.CustomKeyValue{
+			MemorySize:       1024 * 1024 * 5,
+			CleaningEnable:   false,
+			CleaningInterval: time.Second,
+		},
+	}).(storage.INoSQLKeyValue)
+
+	limiter, err := ratelimit.New(store, ratelimit.Config{Algorithm: ratelimit.FIXEDWINDOW, Limit: 1, Window: time.Minute})
+	assert.NoError(t, err)
+
+	e := echo.New()
+	handler := ratelimit.Middleware(limiter, &hash.Client{})(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderAuthorization, "token")
+		rec := httptest.NewRecorder()
+		assert.NoError(t, handler(e.NewContext(req, rec)))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, serve().Code, "First request should pass")
+
+	rec := serve()
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code, "Second request should be limited")
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"), "Limited response should have a Retry-After header")
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-common-packages/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+type jobMessage struct {
+	Name     string `json:"name"`
+	Attempts int    `json:"attempts"`
+}
+
+func TestRedisPubSub(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Redis tests in short mode")
+	}
+
+	s, config := setupMiniRedis(t)
+	defer s.Close()
+
+	client := storage.New(nil, storage.NOSQLKEYVALUE)(storage.REDIS, &storage.Config{
+		Redis: *config,
+	}).(*storage.RedisClient)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	messages, err := client.Subscribe(ctx, reflect.TypeOf(jobMessage{}), "jobs")
+	assert.NoError(t, err, "Subscribe should not return an error")
+
+	_, err = client.Publish("jobs", jobMessage{Name: "report", Attempts: 2})
+	assert.NoError(t, err, "Publish should not return an error")
+
+	select {
+	case msg := <-messages:
+		assert.NoError(t, msg.Err, "Message should be decoded")
+		assert.Equal(t, "jobs", msg.Channel)
+		assert.Equal(t, &jobMessage{Name: "report", Attempts: 2}, msg.Data, "Message should be decoded into the data model")
+	case <-time.After(time.Second):
+		t.Fatal("Message was not received")
+	}
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		_, ok := <-messages
+		return !ok
+	}, time.Second, 10*time.Millisecond, "Channel should be closed when the context is done")
+}
+
+func TestRedisStreams(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Redis tests in short mode")
+	}
+
+	s, config := setupMiniRedis(t)
+	defer s.Close()
+
+	client := storage.New(nil, storage.NOSQLKEYVALUE)(storage.REDIS, &storage.Config{
+		Redis: *config,
+	}).(*storage.RedisClient)
+
+	assert.NoError(t, client.StreamCreateGroup("queue", "workers", "0"), "StreamCreateGroup should not return an error")
+	assert.NoError(t, client.StreamCreateGroup("queue", "workers", "0"), "StreamCreateGroup should accept an existing group")
+
+	id, err := client.StreamAdd("queue", map[string]interface{}{"job": "report"}, 1000)
+	assert.NoError(t, err, "StreamAdd should not return an error")
+	assert.NotEmpty(t, id, "StreamAdd should return the entry ID")
+
+	messages, err := client.StreamReadGroup("queue", "workers", "worker-1", 10, 0)
+	assert.NoError(t, err, "StreamReadGroup should not return an error")
+	assert.Len(t, messages, 1, "StreamReadGroup should return the new entry")
+	assert.Equal(t, id, messages[0].ID)
+	assert.Equal(t, "report", messages[0].Values["job"])
+
+	messages, err = client.StreamReadGroup("queue", "workers", "worker-1", 10, 0)
+	assert.NoError(t, err, "StreamReadGroup should not return an error when the stream is drained")
+	assert.Empty(t, messages, "Delivered entries should not be read again")
+
+	claimed, err := client.StreamClaimPending("queue", "workers", "worker-2", 0, 10)
+	assert.NoError(t, err, "StreamClaimPending should not return an error")
+	assert.Len(t, claimed, 1, "Unacknowledged entries should be claimed")
+
+	acked, err := client.StreamAck("queue", "workers", id)
+	assert.NoError(t, err, "StreamAck should not return an error")
+	assert.Equal(t, int64(1), acked)
+
+	claimed, err = client.StreamClaimPending("queue", "workers", "worker-2", 0, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, claimed, "Acknowledged entries should not be claimed")
+}
@@ -39,6 +39,7 @@ func TestRedisOperations(t *testing.T) {
 	})
 
 	assert.NotNil(t, redisClient, "Redis client should not be nil")
+	assert.NotNil(t, redisClient.(*storage.RedisClient).Client, "The single-node client should be exposed")
 
 	client, ok := redisClient.(storage.INoSQLKeyValue)
 	assert.True(t, ok, "Should be able to cast to INoSQLKeyValue")
@@ -70,3 +71,30 @@ func TestRedisOperations(t *testing.T) {
 	_, err = client.Get("non-existent-key")
 	assert.Nil(t, err, "Get should return a nil for non-existent key")
 }
+
+func TestRedisACLAuthentication(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Redis tests in short mode")
+	}
+
+	s, config := setupMiniRedis(t)
+	defer s.Close()
+
+	s.RequireUserAuth("app", "secret")
+	config.Username = "app"
+	config.Password = "secret"
+	config.DB = 2
+	config.PoolSize = 2
+	config.DialTimeout = time.Second
+
+	client := storage.New(nil, storage.NOSQLKEYVALUE)(storage.REDIS, &storage.Config{
+		Redis: *config,
+	}).(storage.INoSQLKeyValue)
+
+	err := client.Set("acl-key", "acl-value", time.Hour)
+	assert.NoError(t, err, "Set should not return an error with ACL authentication")
+
+	val, err := s.DB(2).Get("acl-key")
+	assert.NoError(t, err, "Value should be stored in the selected database")
+	assert.Equal(t, "acl-value", val)
+}
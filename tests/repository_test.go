@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-common-packages/storage"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type repositoryArticle struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Title     string             `bson:"title"`
+	Views     int                `bson:"views"`
+	CreatedAt time.Time          `bson:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt"`
+}
+
+type repositoryTag struct {
+	ID   string `bson:"_id,omitempty"`
+	Name string `bson:"name"`
+}
+
+func TestRepository(t *testing.T) {
+	client := newMemoryDocument(t)
+	ctx := context.Background()
+
+	articles, err := storage.NewRepository[repositoryArticle](client, "blog", "articles", nil)
+	assert.NoError(t, err)
+
+	first := &repositoryArticle{Title: "First", Views: 10}
+	second := &repositoryArticle{Title: "Second", Views: 20}
+	assert.NoError(t, articles.Insert(ctx, first, second))
+	assert.False(t, first.ID.IsZero(), "Insert should set the generated _id")
+	assert.False(t, first.CreatedAt.IsZero(), "Insert should set createdAt")
+	assert.Equal(t, first.CreatedAt, first.UpdatedAt)
+
+	found, err := articles.FindByID(ctx, first.ID.Hex())
+	assert.NoError(t, err, "Hex strings should match ObjectID values")
+	assert.Equal(t, *first, found)
+
+	found.Views++
+	time.Sleep(2 * time.Millisecond)
+	assert.NoError(t, articles.Update(ctx, &found))
+	assert.True(t, found.UpdatedAt.After(found.CreatedAt), "Update should set updatedAt")
+
+	items, err := articles.Find(ctx, bson.M{"views": bson.M{"$gt": 10}}, &storage.FindOptions{Sort: []storage.SortField{{Field: "views"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"First", "Second"}, []string{items[0].Title, items[1].Title})
+	assert.Equal(t, first.CreatedAt, items[0].CreatedAt, "Update should keep createdAt")
+
+	assert.NoError(t, articles.Delete(ctx, first.ID))
+	_, err = articles.FindByID(ctx, first.ID)
+	assert.True(t, errors.Is(err, storage.ErrDocumentNotFound))
+	assert.True(t, errors.Is(articles.Delete(ctx, first.ID), storage.ErrDocumentNotFound))
+	assert.True(t, errors.Is(articles.Update(ctx, first), storage.ErrDocumentNotFound))
+
+	tags, err := storage.NewRepository[repositoryTag](client, "blog", "tags", &storage.RepositoryOptions{NoTimestamps: true})
+	assert.NoError(t, err)
+
+	tag := &repositoryTag{Name: "go"}
+	assert.NoError(t, tags.Insert(ctx, tag))
+	assert.Len(t, tag.ID, 24, "String _id fields should get a hex ObjectID")
+
+	raw, err := client.Read("blog", "tags", bson.M{"_id": tag.ID}, 0, reflect.TypeOf(bson.M{}))
+	assert.NoError(t, err)
+	assert.NotContains(t, (*raw.(*[]bson.M))[0], "createdAt", "NoTimestamps should not stamp documents")
+
+	_, err = storage.NewRepository[string](client, "blog", "tags", nil)
+	assert.Error(t, err, "Repository type should be a struct or a map")
+}
+
+type repositoryAccount struct {
+	ID      string `bson:"_id,omitempty"`
+	Balance int    `bson:"balance"`
+	Version int64  `bson:"version"`
+}
+
+func TestRepositoryVersioning(t *testing.T) {
+	client := newMemoryDocument(t)
+	ctx := context.Background()
+
+	accounts, err := storage.NewRepository[repositoryAccount](client, "bank", "accounts", &storage.RepositoryOptions{
+		NoTimestamps: true,
+		VersionField: "version",
+	})
+	assert.NoError(t, err)
+
+	account := &repositoryAccount{ID: "a", Balance: 100}
+	assert.NoError(t, accounts.Insert(ctx, account))
+	assert.Equal(t, int64(1), account.Version, "Insert should start the version at 1")
+
+	first, err := accounts.FindByID(ctx, "a")
+	assert.NoError(t, err)
+	second, err := accounts.FindByID(ctx, "a")
+	assert.NoError(t, err)
+
+	first.Balance -= 30
+	assert.NoError(t, accounts.Update(ctx, &first))
+	assert.Equal(t, int64(2), first.Version)
+
+	second.Balance -= 50
+	err = accounts.Update(ctx, &second)
+	assert.True(t, errors.Is(err, storage.ErrVersionConflict), "Stale update should conflict")
+
+	stored, err := accounts.FindByID(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, 70, stored.Balance, "Conflicting update should not be written")
+
+	missing := &repositoryAccount{ID: "missing", Version: 1}
+	assert.True(t, errors.Is(accounts.Update(ctx, missing), storage.ErrDocumentNotFound))
+}
+
+func TestUpdateWithVersion(t *testing.T) {
+	client := newMemoryDocument(t)
+	ctx := context.Background()
+
+	_, err := client.Create("bank", "accounts", []interface{}{bson.M{"_id": "legacy", "balance": 10}})
+	assert.NoError(t, err)
+
+	_, err = storage.UpdateWithVersion(ctx, client, "bank", "accounts", bson.M{"_id": "legacy"}, bson.M{"$inc": bson.M{"balance": 5}}, "version", 0)
+	assert.NoError(t, err, "Documents without a version should be at version 0")
+
+	_, err = storage.UpdateWithVersion(ctx, client, "bank", "accounts", bson.M{"_id": "legacy"}, bson.M{"$set": bson.M{"balance": 0}}, "version", 0)
+	assert.True(t, errors.Is(err, storage.ErrVersionConflict))
+
+	_, err = storage.UpdateWithVersion(ctx, client, "bank", "accounts", bson.M{"_id": "legacy"}, bson.M{"$set": bson.M{"balance": 0}}, "version", 1)
+	assert.NoError(t, err)
+
+	account, err := client.FindOne(ctx, "bank", "accounts", bson.M{"_id": "legacy"}, reflect.TypeOf(bson.M{}))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, (*account.(*bson.M))["version"])
+
+	_, err = storage.UpdateWithVersion(ctx, client, "bank", "accounts", bson.M{"_id": "missing"}, bson.M{"$set": bson.M{"balance": 0}}, "version", 2)
+	assert.True(t, errors.Is(err, storage.ErrDocumentNotFound))
+
+	_, err = storage.UpdateWithVersion(ctx, client, "bank", "accounts", bson.M{"_id": "legacy"}, bson.M{"$set": bson.M{"version": 9}}, "version", 2)
+	assert.Error(t, err, "Version field should not be updatable")
+}
@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang-common-packages/storage"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var userSchema = bson.M{
+	"bsonType": "object",
+	"required": bson.A{"name", "age"},
+	"properties": bson.M{
+		"name":  bson.M{"bsonType": "string", "minLength": 1},
+		"age":   bson.M{"bsonType": "int", "minimum": 0, "maximum": 150},
+		"email": bson.M{"bsonType": "string", "pattern": "^[^@]+@[^@]+$"},
+		"tags":  bson.M{"bsonType": "array", "items": bson.M{"bsonType": "string"}, "uniqueItems": true},
+	},
+}
+
+func fieldErrors(t *testing.T, err error) []storage.FieldError {
+	var validationError *storage.SchemaValidationError
+	if !assert.True(t, errors.As(err, &validationError), "Should be a SchemaValidationError, got %v", err) {
+		return nil
+	}
+	assert.True(t, errors.Is(err, storage.ErrSchemaValidation))
+
+	return validationError.Errors
+}
+
+func TestSchemaValidation(t *testing.T) {
+	client := newMemoryDocument(t)
+	ctx := context.Background()
+
+	schemas, err := storage.NewSchemaValidation(client)
+	assert.NoError(t, err)
+	assert.NoError(t, schemas.RegisterSchema(ctx, "db", "users", userSchema, nil))
+
+	_, err = schemas.Create("db", "users", []interface{}{
+		memoryUser{ID: "u1", Name: "Ann", Age: 31, Tags: []string{"admin"}},
+		bson.M{"_id": "u2", "age": 200, "email": "bob", "tags": bson.A{"dev", 1, "dev"}},
+	})
+	assert.Equal(t, []storage.FieldError{
+		{Field: "age", Message: "must be at most 150"},
+		{Field: "email", Message: "must match ^[^@]+@[^@]+$"},
+		{Field: "name", Message: "is required"},
+		{Field: "tags.1", Message: "must be of type string, got int"},
+		{Field: "tags.2", Message: "duplicates item 0"},
+	}, fieldErrors(t, err))
+	assert.Contains(t, err.Error(), "document 1: document failed validation: age: must be at most 150")
+
+	count, err := client.EstimatedCount(ctx, "db", "users")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count, "Nothing should be written when a document is invalid")
+
+	_, err = schemas.Create("db", "users", []interface{}{memoryUser{ID: "u1", Name: "Ann", Age: 31}})
+	assert.NoError(t, err)
+
+	_, err = schemas.UpdateOne(ctx, "db", "users", bson.M{"_id": "u1"}, bson.M{"$set": bson.M{"age": "old"}, "$unset": bson.M{"name": ""}})
+	assert.Equal(t, []storage.FieldError{
+		{Field: "age", Message: "must be of type int, got string"},
+		{Field: "name", Message: "is required"},
+	}, fieldErrors(t, err))
+
+	_, err = schemas.UpdateOne(ctx, "db", "users", bson.M{"_id": "u1"}, bson.M{"$set": bson.M{"age": 32, "tags.0": "ops"}})
+	assert.NoError(t, err)
+
+	_, err = schemas.ReplaceOne(ctx, "db", "users", bson.M{"_id": "u1"}, bson.M{"name": ""})
+	assert.Equal(t, []storage.FieldError{
+		{Field: "age", Message: "is required"},
+		{Field: "name", Message: "must be at least 1 characters long"},
+	}, fieldErrors(t, err))
+
+	err = schemas.WithTransaction(ctx, func(tx storage.IDocumentTx) error {
+		_, err := tx.Update("db", "users", bson.M{}, bson.M{"$set": bson.M{"age": -1}})
+		return err
+	})
+	assert.True(t, errors.Is(err, storage.ErrSchemaValidation), "Transactions should validate writes")
+
+	_, err = schemas.BulkWrite(ctx, "db", "users", []storage.WriteModel{
+		{Operation: storage.WriteInsertOne, Document: memoryUser{ID: "u2", Name: "Bob", Age: 25}},
+		{Operation: storage.WriteInsertOne, Document: bson.M{"_id": "u3"}},
+	}, true)
+	assert.True(t, errors.Is(err, storage.ErrSchemaValidation))
+	assert.Contains(t, err.Error(), "operation 1")
+
+	_, err = schemas.Create("db", "others", []interface{}{bson.M{"age": "any"}})
+	assert.NoError(t, err, "Collections without schema should not be validated")
+
+	assert.NoError(t, schemas.RegisterSchema(ctx, "db", "users", nil, nil))
+	_, err = schemas.Create("db", "users", []interface{}{bson.M{"_id": "u3"}})
+	assert.NoError(t, err, "Removed schemas should not be validated")
+}
+
+func TestSchemaCompilation(t *testing.T) {
+	schemas, err := storage.NewSchemaValidation(newMemoryDocument(t))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	err = schemas.RegisterSchema(ctx, "db", "users", bson.M{"patternProperties": bson.M{}}, nil)
+	assert.True(t, errors.Is(err, storage.ErrUnsupportedOperator))
+
+	err = schemas.RegisterSchema(ctx, "db", "users", bson.M{"properties": bson.M{"age": bson.M{"minimum": "zero"}}}, nil)
+	assert.EqualError(t, err, "$jsonSchema.properties.age.minimum must be a number")
+
+	err = schemas.RegisterSchema(ctx, "db", "users", bson.M{
+		"additionalProperties": false,
+		"properties": bson.M{
+			"_id":    bson.M{},
+			"kind":   bson.M{"enum": bson.A{"a", "b"}},
+			"value":  bson.M{"oneOf": bson.A{bson.M{"type": "string"}, bson.M{"type": "number"}}},
+			"labels": bson.M{"additionalProperties": bson.M{"bsonType": "string"}, "maxProperties": 1},
+		},
+	}, nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, schemas.Validate("db", "users", bson.M{"_id": 1, "kind": "a", "value": 2.5, "labels": bson.M{"env": "prod"}}))
+	assert.Equal(t, []storage.FieldError{
+		{Field: "extra", Message: "is not allowed"},
+		{Field: "kind", Message: "must be one of [a b]"},
+		{Field: "labels", Message: "must have at most 1 fields"},
+		{Field: "labels.b", Message: "must be of type string, got bool"},
+		{Field: "value", Message: "must match exactly one schema of oneOf, matched 0"},
+	}, fieldErrors(t, schemas.Validate("db", "users", bson.M{
+		"kind":   "c",
+		"value":  true,
+		"extra":  1,
+		"labels": bson.D{{Key: "a", Value: "x"}, {Key: "b", Value: false}},
+	})))
+
+	_, err = schemas.Update("db", "users", bson.M{}, bson.M{"$set": bson.M{"extra": 1}})
+	assert.Equal(t, []storage.FieldError{{Field: "extra", Message: "is not allowed"}}, fieldErrors(t, err))
+}
+
+func TestSetValidator(t *testing.T) {
+	client := newMemoryDocument(t)
+	ctx := context.Background()
+
+	schemas, err := storage.NewSchemaValidation(client)
+	assert.NoError(t, err)
+	assert.NoError(t, schemas.RegisterSchema(ctx, "db", "users", userSchema, &storage.SchemaOptions{Install: true}))
+
+	// Writes bypassing the client-side validation are rejected by the store
+	_, err = client.Create("db", "users", []interface{}{bson.M{"_id": "u1", "name": "Ann"}})
+	var writeError *storage.WriteError
+	assert.True(t, errors.As(err, &writeError))
+	assert.Equal(t, 121, writeError.Code)
+	assert.Equal(t, "document failed validation: age: is required", writeError.Message)
+
+	_, err = client.Create("db", "users", []interface{}{memoryUser{ID: "u1", Name: "Ann", Age: 31}})
+	assert.NoError(t, err)
+
+	_, err = client.UpdateOne(ctx, "db", "users", bson.M{"_id": "u1"}, bson.M{"$inc": bson.M{"age": 200}})
+	assert.True(t, errors.As(err, &writeError), "Updates should be checked by the store")
+
+	assert.NoError(t, client.SetValidator(ctx, "db", "users", nil))
+	_, err = client.UpdateOne(ctx, "db", "users", bson.M{"_id": "u1"}, bson.M{"$inc": bson.M{"age": 200}})
+	assert.NoError(t, err, "Removed validators should not be checked")
+}
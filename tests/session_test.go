@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-common-packages/storage"
+	"github.com/golang-common-packages/storage/session"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type sessionData struct {
+	Role string `json:"role"`
+}
+
+func testSessionManager(t *testing.T, store storage.INoSQLKeyValue) {
+	manager, err := session.New(store, session.Config{
+		TTL:       time.Hour,
+		Sliding:   true,
+		DataModel: reflect.TypeOf(sessionData{}),
+	})
+	assert.NoError(t, err, "New should not return an error")
+
+	token, created, err := manager.Create("user-1", sessionData{Role: "admin"})
+	assert.NoError(t, err, "Create should not return an error")
+	assert.NotEmpty(t, token, "Create should return a token")
+	assert.NotEqual(t, token, created.ID, "Session ID should not reveal the token")
+
+	stored, err := store.Get("session:" + created.ID)
+	assert.NoError(t, err)
+	assert.NotNil(t, stored, "Session records should be stored under the key prefix")
+
+	loaded, err := manager.Load(token)
+	assert.NoError(t, err, "Load should not return an error")
+	assert.Equal(t, "user-1", loaded.UserID)
+	assert.Equal(t, &sessionData{Role: "admin"}, loaded.Data, "Load should decode the session data")
+	assert.False(t, loaded.ExpiresAt.Before(created.ExpiresAt), "Load should slide the expiration")
+
+	assert.NoError(t, manager.Update(token, sessionData{Role: "viewer"}), "Update should not return an error")
+	loaded, err = manager.Load(token)
+	assert.NoError(t, err)
+	assert.Equal(t, &sessionData{Role: "viewer"}, loaded.Data, "Update should replace the session data")
+
+	rotated, _, err := manager.Rotate(token)
+	assert.NoError(t, err, "Rotate should not return an error")
+	_, err = manager.Load(token)
+	assert.ErrorIs(t, err, session.ErrSessionNotFound, "Rotated token should stop working")
+	_, err = manager.Load(rotated)
+	assert.NoError(t, err, "New token should work")
+
+	_, _, err = manager.Create("user-1", sessionData{Role: "viewer"})
+	assert.NoError(t, err)
+
+	sessions, err := manager.List("user-1")
+	assert.NoError(t, err, "List should not return an error")
+	assert.Len(t, sessions, 2, "List should return every live session of the user")
+
+	revoked, err := manager.RevokeAll("user-1")
+	assert.NoError(t, err, "RevokeAll should not return an error")
+	assert.Equal(t, 2, revoked)
+
+	_, err = manager.Load(rotated)
+	assert.ErrorIs(t, err, session.ErrSessionNotFound, "Revoked session should not load")
+
+	sessions, err = manager.List("user-1")
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestCustomKeyValueSessions(t *testing.T) {
+	store := storage.New(nil, storage.NOSQLKEYVALUE)(storage.CUSTOM, &storage.Config{
+		CustomKeyValue: storage.CustomKeyValue{
+			MemorySize:       1024 * 1024 * 6,
+			CleaningEnable:   false,
+			CleaningInterval: time.Second,
+		},
+	}).(storage.INoSQLKeyValue)
+
+	testSessionManager(t, store)
+}
+
+func TestRedisSessions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Redis tests in short mode")
+	}
+
+	s, config := setupMiniRedis(t)
+	defer s.Close()
+
+	store := storage.New(nil, storage.NOSQLKEYVALUE)(storage.REDIS, &storage.Config{
+		Redis: *config,
+	}).(storage.INoSQLKeyValue)
+
+	testSessionManager(t, store)
+}
+
+func TestSessionMiddleware(t *testing.T) {
+	store := storage.New(nil, storage.NOSQLKEYVALUE)(storage.CUSTOM, &storage.Config{
+		CustomKeyValue: storage.CustomKeyValue{
+			MemorySize:       1024 * 1024 * 7,
+			CleaningEnable:   false,
+			CleaningInterval: time.Second,
+		},
+	}).(storage.INoSQLKeyValue)
+
+	manager, err := session.New(store, session.Config{TTL: time.Hour})
+	assert.NoError(t, err)
+
+	token, _, err := manager.Create("user-2", nil)
+	assert.NoError(t, err)
+
+	e := echo.New()
+	var userID string
+	handler := manager.Middleware()(func(c echo.Context) error {
+		s, ok := session.FromContext(c.Request().Context())
+		assert.True(t, ok, "Session should be attached to the request context")
+		userID = s.UserID
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+	assert.NoError(t, handler(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "user-2", userID)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer unknown")
+	rec = httptest.NewRecorder()
+	assert.NoError(t, handler(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "Unknown tokens should be rejected")
+}
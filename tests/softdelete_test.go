@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-common-packages/storage"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestSoftDelete(t *testing.T) {
+	client := newMemoryDocument(t)
+	seedMemoryUsers(t, client)
+	ctx := context.Background()
+
+	softDelete, err := storage.NewSoftDelete(client, &storage.SoftDeleteOptions{Retention: time.Hour})
+	assert.NoError(t, err)
+	userType := reflect.TypeOf(memoryUser{})
+
+	result, err := softDelete.Delete("db", "users", bson.M{"age": 25})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), result.(*mongo.DeleteResult).DeletedCount)
+
+	result, err = softDelete.DeleteOne(ctx, "db", "users", bson.M{"_id": "u2"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), result.(*mongo.DeleteResult).DeletedCount, "Deleted documents should not be deleted again")
+
+	items, err := softDelete.Read("db", "users", bson.M{}, 0, userType)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u1", "u3"}, userIDs(items), "Reads should exclude deleted documents")
+
+	count, err := softDelete.Count(ctx, "db", "users", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	_, err = softDelete.FindOne(ctx, "db", "users", bson.M{"_id": "u2"}, userType)
+	assert.True(t, errors.Is(err, storage.ErrDocumentNotFound))
+
+	count, err = client.Count(ctx, "db", "users", bson.M{"deletedAt": bson.M{"$exists": true}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count, "Deleted documents should be kept")
+
+	totals, err := softDelete.Aggregate(ctx, "db", "users", storage.NewPipeline().Count("users"), reflect.TypeOf(bson.M{}), nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, (*totals.(*[]bson.M))[0]["users"], "Aggregations should exclude deleted documents")
+
+	err = softDelete.WithTransaction(ctx, func(tx storage.IDocumentTx) error {
+		_, err := tx.DeleteOne("db", "users", bson.M{"_id": "u1"})
+		return err
+	})
+	assert.NoError(t, err)
+
+	exists, err := softDelete.Exists(ctx, "db", "users", bson.M{"_id": "u1"})
+	assert.NoError(t, err)
+	assert.False(t, exists, "Transactions should soft delete too")
+
+	_, err = softDelete.Restore(ctx, "db", "users", bson.M{"_id": "u1"})
+	assert.NoError(t, err)
+	exists, err = softDelete.Exists(ctx, "db", "users", bson.M{"_id": "u1"})
+	assert.NoError(t, err)
+	assert.True(t, exists, "Restored documents should be visible")
+
+	purged, err := softDelete.Purge(ctx, "db", "users")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), purged, "Documents within the retention should be kept")
+
+	immediate, err := storage.NewSoftDelete(client, nil)
+	assert.NoError(t, err)
+	purged, err = immediate.Purge(ctx, "db", "users")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+
+	name, err := softDelete.EnsureRetentionIndex(ctx, "db", "users")
+	assert.NoError(t, err)
+	assert.Equal(t, "deletedAt_1", name)
+}
+
+func TestSoftDeleteUpsert(t *testing.T) {
+	client := newMemoryDocument(t)
+	seedMemoryUsers(t, client)
+	ctx := context.Background()
+
+	softDelete, err := storage.NewSoftDelete(client, nil)
+	assert.NoError(t, err)
+	_, err = softDelete.DeleteOne(ctx, "db", "users", bson.M{"_id": "u1"})
+	assert.NoError(t, err)
+
+	_, err = softDelete.Upsert(ctx, "db", "users", bson.M{"_id": "u1"}, bson.M{"$set": bson.M{"name": "Ann"}})
+	var writeError *storage.WriteError
+	assert.True(t, errors.As(err, &writeError), "Upserting a deleted _id should conflict with it")
+	assert.Equal(t, 11000, writeError.Code)
+
+	_, err = softDelete.Restore(ctx, "db", "users", bson.M{"_id": "u1"})
+	assert.NoError(t, err)
+	result, err := softDelete.Upsert(ctx, "db", "users", bson.M{"_id": "u1"}, bson.M{"$set": bson.M{"name": "Ann"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), result.(*mongo.UpdateResult).MatchedCount, "Restored documents should be updated")
+}
+
+func TestSoftDeleteRepository(t *testing.T) {
+	client := newMemoryDocument(t)
+	ctx := context.Background()
+
+	softDelete, err := storage.NewSoftDelete(client, nil)
+	assert.NoError(t, err)
+
+	users, err := storage.NewRepository[memoryUser](softDelete, "db", "users", &storage.RepositoryOptions{NoTimestamps: true})
+	assert.NoError(t, err)
+
+	assert.NoError(t, users.Insert(ctx, &memoryUser{ID: "u1", Name: "Ann"}))
+	assert.NoError(t, users.Delete(ctx, "u1"))
+	assert.True(t, errors.Is(users.Delete(ctx, "u1"), storage.ErrDocumentNotFound))
+
+	_, err = users.FindByID(ctx, "u1")
+	assert.True(t, errors.Is(err, storage.ErrDocumentNotFound))
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang-common-packages/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func testStructuredKeyValue(t *testing.T, client storage.IStructuredKeyValue) {
+	assert.NoError(t, client.HSet("settings:1", "theme", "dark"), "HSet should not return an error")
+	assert.NoError(t, client.HSet("settings:1", "pageSize", 50), "HSet should accept non-string values")
+
+	value, err := client.HGet("settings:1", "pageSize")
+	assert.NoError(t, err, "HGet should not return an error")
+	assert.Equal(t, "50", value, "HGet should return the stored value")
+
+	value, err = client.HGet("settings:1", "missing")
+	assert.NoError(t, err, "HGet should not return an error for a missing field")
+	assert.Equal(t, "", value)
+
+	removed, err := client.HDel("settings:1", "theme", "missing")
+	assert.NoError(t, err, "HDel should not return an error")
+	assert.Equal(t, int64(1), removed, "HDel should count removed fields only")
+
+	length, err := client.LPush("recent", "a", "b", "c")
+	assert.NoError(t, err, "LPush should not return an error")
+	assert.Equal(t, int64(3), length)
+
+	values, err := client.LRange("recent", 0, -1)
+	assert.NoError(t, err, "LRange should not return an error")
+	assert.Equal(t, []string{"c", "b", "a"}, values, "LPush should prepend values")
+
+	values, err = client.LRange("recent", 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b"}, values)
+
+	added, err := client.SAdd("seen", "msg-1", "msg-2", "msg-1")
+	assert.NoError(t, err, "SAdd should not return an error")
+	assert.Equal(t, int64(2), added, "SAdd should ignore duplicates")
+
+	member, err := client.SIsMember("seen", "msg-2")
+	assert.NoError(t, err, "SIsMember should not return an error")
+	assert.True(t, member)
+
+	member, err = client.SIsMember("seen", "msg-3")
+	assert.NoError(t, err)
+	assert.False(t, member)
+
+	setMembers, err := client.SMembers("seen")
+	assert.NoError(t, err, "SMembers should not return an error")
+	assert.ElementsMatch(t, []string{"msg-1", "msg-2"}, setMembers)
+
+	removed, err = client.SRem("seen", "msg-1", "msg-3")
+	assert.NoError(t, err, "SRem should not return an error")
+	assert.Equal(t, int64(1), removed, "SRem should count removed members only")
+
+	added, err = client.ZAdd("leaderboard",
+		storage.ScoredMember{Member: "alice", Score: 30},
+		storage.ScoredMember{Member: "bob", Score: 10},
+		storage.ScoredMember{Member: "carol", Score: 20},
+	)
+	assert.NoError(t, err, "ZAdd should not return an error")
+	assert.Equal(t, int64(3), added)
+
+	members, err := client.ZRangeByScore("leaderboard", 15, math.Inf(1))
+	assert.NoError(t, err, "ZRangeByScore should not return an error")
+	assert.Equal(t, []storage.ScoredMember{{Member: "carol", Score: 20}, {Member: "alice", Score: 30}}, members)
+}
+
+func TestCustomStructuredKeyValue(t *testing.T) {
+	client := storage.New(nil, storage.NOSQLKEYVALUE)(storage.CUSTOM, &storage.Config{
+		CustomKeyValue: storage.CustomKeyValue{
+			MemorySize:       1024 * 1024 * 2,
+			CleaningEnable:   false,
+			CleaningInterval: time.Second,
+		},
+	}).(storage.IStructuredKeyValue)
+
+	testStructuredKeyValue(t, client)
+}
+
+func TestCustomStructuredKeyValueRemovesEmptyKeys(t *testing.T) {
+	client := storage.New(nil, storage.NOSQLKEYVALUE)(storage.CUSTOM, &storage.Config{
+		CustomKeyValue: storage.CustomKeyValue{
+			MemorySize:       1024 * 1024 * 3,
+			CleaningEnable:   false,
+			CleaningInterval: time.Second,
+		},
+	})
+	structured := client.(storage.IStructuredKeyValue)
+
+	var deleted []string
+	client.(storage.IKeyValueNotifier).OnDelete(func(event storage.KeyValueEvent) {
+		deleted = append(deleted, event.Key)
+	})
+
+	assert.NoError(t, structured.HSet("profile:1", "name", "alice"))
+	_, err := structured.SAdd("tags:1", "a")
+	assert.NoError(t, err)
+
+	_, err = structured.HDel("profile:1", "name")
+	assert.NoError(t, err)
+	_, err = structured.SRem("tags:1", "a")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"profile:1", "tags:1"}, deleted, "Removing the last field or member should delete the key")
+	assert.Equal(t, 0, client.(storage.INoSQLKeyValue).GetNumberOfRecords())
+}
+
+func TestRedisStructuredKeyValue(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Redis tests in short mode")
+	}
+
+	s, config := setupMiniRedis(t)
+	defer s.Close()
+
+	client := storage.New(nil, storage.NOSQLKEYVALUE)(storage.REDIS, &storage.Config{
+		Redis: *config,
+	}).(storage.IStructuredKeyValue)
+
+	testStructuredKeyValue(t, client)
+}
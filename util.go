@@ -2,6 +2,8 @@ package storage
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"hash/fnv"
 	"io"
@@ -31,6 +33,15 @@ func generateKey(data string) string {
 	return fmt.Sprint(hash.Sum64())
 }
 
+// generateToken returns a random hex encoded token
+func generateToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
 // streamToByte converts an io.Reader to a byte slice
 // Note: This function reads the entire stream into memory,
 // so it should be used with caution for large streams